@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/hallucinaut/securitycontrol/pkg/attack"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// attackCoverage validates the catalog's controls and shows how well they
+// cover the ATT&CK techniques mapped to them.
+func attackCoverage(ctx context.Context, args []string) {
+	fs, common := newFlagSet("attack")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	layer := fs.String("layer", "", "write an ATT&CK Navigator layer to a file (- for stdout)")
+	html := fs.String("html", "", "write an HTML heat map to a file (- for stdout)")
+	parseArgs(fs, args)
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+
+	validator := assess.validator(cat)
+	recordTests(ctx, cat, validator, *opts)
+	coverage := attack.Enterprise().Coverage(cat.Controls, validateAll(ctx, validator, *opts))
+
+	name := "Control Coverage"
+	if cat.Framework.Name != "" {
+		name = cat.Framework.Name + " Coverage"
+	}
+	if *layer != "" {
+		writeOutput(stdoutDash(*layer), func(w io.Writer) error {
+			return coverage.WriteNavigatorLayer(w, name)
+		})
+	}
+	if *html != "" {
+		writeOutput(stdoutDash(*html), func(w io.Writer) error {
+			return coverage.WriteHTMLHeatMap(w, "ATT&CK "+name)
+		})
+	}
+	if *layer == "-" || *html == "-" {
+		return
+	}
+
+	if format != report.FormatText {
+		r := report.New("attack", "ATT&CK Coverage")
+		attack.AddCoverage(r, coverage)
+		render(r, format)
+		return
+	}
+	if err := coverage.WriteHeatMap(os.Stdout); err != nil {
+		fatal(err)
+	}
+}
+
+// stdoutDash maps the "-" file name to writeOutput's standard output.
+func stdoutDash(name string) string {
+	if name == "-" {
+		return ""
+	}
+	return name
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hallucinaut/securitycontrol/pkg/catalog"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/crosswalk"
+	"github.com/hallucinaut/securitycontrol/pkg/history"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// lookupFramework returns the bundled compliance framework with the given
+// name, optionally followed by "@" and a version.
+func lookupFramework(name string) *crosswalk.Framework {
+	fw, ok := crosswalk.Bundled().Lookup(name)
+	if !ok {
+		var names []string
+		for _, f := range crosswalk.Bundled().Frameworks() {
+			names = append(names, f.Ref())
+		}
+		fatal(fmt.Errorf("unknown framework %q (available: %s)", name, strings.Join(names, ", ")))
+	}
+	return fw
+}
+
+// frameworkReport reports a run's control results against the requirements
+// of a compliance framework.
+func frameworkReport(name string, format report.Format, cat *catalog.Catalog, run *history.Run, results []control.ControlValidationResult) {
+	fw := lookupFramework(name)
+	m := crosswalk.Bundled().Map(fw, cat.Controls, results)
+
+	if format != report.FormatText {
+		r := report.New("report", fw.Title()+" Report")
+		if run != nil {
+			history.AddRun(r, run)
+		}
+		crosswalk.AddMapping(r, m)
+		render(r, format)
+		return
+	}
+
+	title := fw.Title() + " Report"
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", len(title)))
+	fmt.Println()
+	if run == nil {
+		fmt.Println("No runs recorded yet; run 'securitycontrol validate' first")
+	} else {
+		fmt.Printf("Run: %s (%s, %s)\n", run.ID, run.Command, run.StartedAt.Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("Mapped: %d/%d requirements, %.1f%% mean effectiveness\n\n", m.Mapped(), len(m.Requirements), m.Effectiveness()*100)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tREQUIREMENT\tCONTROLS\tEFFECTIVENESS")
+	for _, req := range m.Requirements {
+		controls, effectiveness := "-", "-"
+		if req.Mapped() {
+			controls = strings.Join(req.Controls(), ", ")
+			effectiveness = fmt.Sprintf("%.1f%%", req.Effectiveness*100)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", req.Requirement.ID, truncate(req.Requirement.Title, 60), controls, effectiveness)
+	}
+	w.Flush()
+
+	fmt.Println("\nBy family:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, f := range m.Families {
+		fmt.Fprintf(w, "  %s\t%s\t%d/%d mapped\t%.1f%%\n", f.Family.ID, f.Family.Name, f.Mapped, f.Requirements, f.Effectiveness*100)
+	}
+	w.Flush()
+
+	for _, ref := range m.Unresolved {
+		fmt.Fprintf(os.Stderr, "Warning: reference %s names no known framework requirement\n", ref)
+	}
+}
+
+// truncate shortens s to at most n runes, marking the cut with an ellipsis.
+func truncate(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n-1]) + "…"
+}
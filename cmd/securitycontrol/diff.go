@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hallucinaut/securitycontrol/pkg/diff"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// diffRuns compares two validation result files or recorded runs.
+func diffRuns(args []string) {
+	fs, common := newFlagSet("diff")
+	hist := storeFlags(fs, false)
+	failOnRegression := fs.Bool("fail-on-regression", false, "exit with status 1 if any control regressed")
+	paths := parseArgs(fs, args)
+	format := common.outputFormat()
+
+	if len(paths) != 2 {
+		fatal(fmt.Errorf("diff requires two result files or run IDs"))
+	}
+	from := loadSnapshot(paths[0], hist)
+	to := loadSnapshot(paths[1], hist)
+
+	d := diff.Compare(from.Results, to.Results)
+	d.From, d.To = from.Label, to.Label
+
+	switch format {
+	case report.FormatText:
+		fmt.Print(d.Text())
+	case report.FormatMarkdown:
+		fmt.Print(d.Markdown())
+	default:
+		render(d.Report(), format)
+	}
+
+	if *failOnRegression && len(d.Regressions()) > 0 {
+		os.Exit(exitPolicy)
+	}
+}
+
+// loadSnapshot loads control results from a file, falling back to a run ID
+// in the history store.
+func loadSnapshot(arg string, hist *historyFlags) *diff.Snapshot {
+	if _, err := os.Stat(arg); err == nil {
+		snap, err := diff.Load(arg)
+		if err != nil {
+			fatal(err)
+		}
+		return snap
+	}
+
+	run, err := hist.get(arg)
+	if err != nil {
+		fatal(fmt.Errorf("%s: no such file or recorded run", arg))
+	}
+	return &diff.Snapshot{Label: run.ID, Results: run.ControlResults()}
+}
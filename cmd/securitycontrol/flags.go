@@ -0,0 +1,370 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/catalog"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/engine"
+	"github.com/hallucinaut/securitycontrol/pkg/history"
+	"github.com/hallucinaut/securitycontrol/pkg/policy"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/report/junit"
+	"github.com/hallucinaut/securitycontrol/pkg/report/sarif"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// Exit codes distinguish a policy failure from a tool or usage error.
+const (
+	exitPolicy = 1
+	exitError  = 2
+)
+
+// stringList is a flag that may be repeated.
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// commonFlags holds flags shared by every command.
+type commonFlags struct {
+	catalogs stringList
+	format   string
+}
+
+// newFlagSet creates a flag set with the common flags registered.
+func newFlagSet(name string) (*flag.FlagSet, *commonFlags) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	common := &commonFlags{}
+	fs.Var(&common.catalogs, "catalog", "catalog file or directory (repeatable)")
+	fs.StringVar(&common.format, "format", "text", "output format: text, json, yaml, csv, markdown, sarif or junit")
+	return fs, common
+}
+
+// outputFormat returns the format selected with --format.
+func (c *commonFlags) outputFormat() report.Format {
+	format, err := report.ParseFormat(c.format)
+	if err != nil {
+		fatal(err)
+	}
+	return format
+}
+
+// engineFlags registers the validation engine flags on a flag set.
+func engineFlags(fs *flag.FlagSet) *engine.Options {
+	opts := engine.DefaultOptions()
+	fs.IntVar(&opts.Workers, "workers", opts.Workers, "number of concurrent validation workers")
+	fs.DurationVar(&opts.Timeout, "timeout", 0, "overall validation timeout (0 for none)")
+	fs.DurationVar(&opts.TaskTimeout, "control-timeout", 0, "timeout for each control or test (0 for none)")
+	fs.IntVar(&opts.Retries, "retries", 0, "retries for failing tests")
+	fs.DurationVar(&opts.Backoff, "retry-backoff", opts.Backoff, "initial delay between retries")
+	return &opts
+}
+
+// assessFlags holds the flags that control how controls are assessed: rules
+// files loaded with --rules, the issue codes suppressed with --suppress,
+// mapped to the controls they are suppressed for, and the scoring model file.
+type assessFlags struct {
+	rules    stringList
+	suppress map[string][]string
+	scoring  string
+}
+
+// assessOptions registers the --rules, --suppress and --scoring flags on a
+// flag set.
+func assessOptions(fs *flag.FlagSet) *assessFlags {
+	f := &assessFlags{suppress: make(map[string][]string)}
+	fs.Var(&f.rules, "rules", "rules file merged over the built-in issue rules (repeatable)")
+	fs.StringVar(&f.scoring, "scoring", "", "scoring model file (default: built-in model)")
+	fs.Func("suppress", "suppress an issue code, optionally for listed controls (CODE[:ID,...], repeatable)", func(v string) error {
+		code, ids, _ := strings.Cut(v, ":")
+		if code == "" {
+			return fmt.Errorf("issue code required")
+		}
+		if ids == "" {
+			f.suppress[code] = append(f.suppress[code], "")
+			return nil
+		}
+		f.suppress[code] = append(f.suppress[code], strings.Split(ids, ",")...)
+		return nil
+	})
+	return f
+}
+
+// validator creates a validator for the catalog's controls with the rules
+// and scoring model loaded and the suppressions applied. Suppressed codes
+// are checked once the rules are loaded, so codes defined by rules files can
+// be suppressed.
+func (f *assessFlags) validator(cat *catalog.Catalog) *control.ControlValidator {
+	validator := cat.NewValidator()
+	if f.scoring != "" {
+		model, err := control.LoadScoringModel(f.scoring)
+		if err != nil {
+			fatal(fmt.Errorf("failed to load scoring model: %w", err))
+		}
+		validator.SetScoringModel(model)
+	}
+	if len(f.rules) > 0 {
+		set, err := control.LoadRules(f.rules...)
+		if err != nil {
+			fatal(fmt.Errorf("failed to load rules: %w", err))
+		}
+		validator.SetRules(set)
+	}
+	for code, ids := range f.suppress {
+		if _, ok := control.LookupIssue(code); !ok {
+			fatal(fmt.Errorf("unknown issue code %q in --suppress", code))
+		}
+		for _, id := range ids {
+			if id == "" {
+				validator.Suppress(code)
+			} else {
+				validator.Suppress(code, id)
+			}
+		}
+	}
+	return validator
+}
+
+// policyFlags holds the CI gating flags.
+type policyFlags struct {
+	file      string
+	overrides policy.Thresholds
+}
+
+// gateFlags registers the CI gating flags on a flag set.
+func gateFlags(fs *flag.FlagSet) *policyFlags {
+	g := &policyFlags{}
+	fs.StringVar(&g.file, "policy", "", "policy file with default, per-framework and per-category thresholds")
+	fs.Func("fail-on", "fail when a control is ineffective, or partial or worse", func(s string) error {
+		failOn, err := policy.ParseFailOn(s)
+		g.overrides.FailOn = failOn
+		return err
+	})
+	fs.Func("min-effectiveness", "fail when a control's effectiveness is below this ratio", func(s string) error {
+		v, err := parseRatio(s)
+		g.overrides.MinEffectiveness = &v
+		return err
+	})
+	fs.Func("min-confidence", "fail when a control's confidence is below this ratio", func(s string) error {
+		v, err := parseRatio(s)
+		g.overrides.MinConfidence = &v
+		return err
+	})
+	return g
+}
+
+// load loads the policy file, if any, with the command line thresholds
+// overriding its defaults.
+func (g *policyFlags) load() *policy.Policy {
+	p := policy.New()
+	if g.file != "" {
+		var err error
+		if p, err = policy.Load(g.file); err != nil {
+			fatal(err)
+		}
+	}
+	p.Thresholds = p.Thresholds.Merge(g.overrides)
+	return p
+}
+
+// parseRatio parses a number between 0 and 1.
+func parseRatio(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if v < 0 || v > 1 {
+		return 0, fmt.Errorf("%v must be between 0 and 1", v)
+	}
+	return v, nil
+}
+
+// enforce prints policy violations to stderr and exits with exitPolicy if
+// there are any.
+func enforce(violations []policy.Violation) {
+	if len(violations) == 0 {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Policy check failed: %d violation(s)\n", len(violations))
+	for _, v := range violations {
+		fmt.Fprintf(os.Stderr, "  %s (%s): %s [%s]\n", v.ControlID, v.ControlName, v.Message, v.Rule)
+	}
+	os.Exit(exitPolicy)
+}
+
+// historyFlags holds the flags selecting the history store.
+type historyFlags struct {
+	dir      string
+	disabled bool
+}
+
+// storeFlags registers the history store flags on a flag set. Commands
+// that only read history do not register --no-history.
+func storeFlags(fs *flag.FlagSet, recording bool) *historyFlags {
+	h := &historyFlags{}
+	fs.StringVar(&h.dir, "history", history.DefaultDir, "history store directory")
+	if recording {
+		fs.BoolVar(&h.disabled, "no-history", false, "do not record the run in the history store")
+	}
+	return h
+}
+
+// open opens the history store for recording or pruning runs.
+func (h *historyFlags) open() *history.Store {
+	store, err := history.Open(h.dir)
+	if err != nil {
+		fatal(fmt.Errorf("history: %w", err))
+	}
+	return store
+}
+
+// read opens the history store for reading without creating it. It returns
+// nil when nothing has been recorded yet.
+func (h *historyFlags) read() *history.Store {
+	store, err := history.OpenReadOnly(h.dir)
+	if errors.Is(err, history.ErrNoHistory) {
+		return nil
+	}
+	if err != nil {
+		fatal(fmt.Errorf("history: %w", err))
+	}
+	return store
+}
+
+// get returns a recorded run by ID or "latest". It returns ErrNotFound
+// when nothing has been recorded yet.
+func (h *historyFlags) get(id string) (*history.Run, error) {
+	store := h.read()
+	if store == nil {
+		return nil, fmt.Errorf("%w: no history in %s", history.ErrNotFound, h.dir)
+	}
+	return store.Get(id)
+}
+
+// record stores the results of a run unless --no-history is set.
+func (h *historyFlags) record(command string, cat *catalog.Catalog, startedAt time.Time, controls []control.ControlValidationResult, tests []validate.ValidationResult) {
+	if h.disabled {
+		return
+	}
+
+	run := history.NewRun(command, version, startedAt)
+	run.CatalogHash = cat.Hash()
+	run.CatalogFiles = cat.Files
+	run.Framework = cat.Framework.Name
+	run.AddControlResults(controls)
+	run.AddTestResults(tests)
+	if err := h.open().Append(run); err != nil {
+		fatal(fmt.Errorf("history: %w", err))
+	}
+}
+
+// parseArgs parses flags and positional arguments in any order.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// loadCatalog loads the catalogs named on the command line, falling back
+// to the built-in common controls and tests.
+func (c *commonFlags) loadCatalog() *catalog.Catalog {
+	if len(c.catalogs) == 0 {
+		return catalog.Default()
+	}
+
+	cat, err := catalog.Load(c.catalogs...)
+	if err != nil {
+		fatal(err)
+	}
+	return cat
+}
+
+// recordTests runs the catalog's tests and records their outcomes so that
+// linked controls are scored from them.
+func recordTests(ctx context.Context, cat *catalog.Catalog, validator *control.ControlValidator, opts engine.Options) []validate.ValidationResult {
+	results, err := cat.NewTestValidator().ValidateAll(ctx, opts)
+	if err != nil {
+		fatal(fmt.Errorf("test execution stopped: %w", err))
+	}
+	for _, result := range results {
+		if result.Completed() {
+			validator.RecordTestOutcome(result.Outcome())
+		}
+	}
+	return results
+}
+
+// validateAll validates every control in the catalog.
+func validateAll(ctx context.Context, validator *control.ControlValidator, opts engine.Options) []control.ControlValidationResult {
+	results, err := validator.ValidateAll(ctx, opts)
+	if err != nil {
+		fatal(fmt.Errorf("validation stopped after %d controls: %w", len(results), err))
+	}
+	return results
+}
+
+// render writes a structured report to stdout.
+func render(r *report.Report, format report.Format) {
+	if err := report.Render(os.Stdout, r, format); err != nil {
+		fatal(err)
+	}
+}
+
+// renderResults writes control and test results as SARIF or JUnit XML, or
+// as the structured report for every other format.
+func renderResults(r *report.Report, format report.Format, cat *catalog.Catalog, controls []control.ControlValidationResult, tests []validate.ValidationResult) {
+	var err error
+	switch format {
+	case report.FormatSARIF:
+		if len(cat.Files) == 0 {
+			fmt.Fprintln(os.Stderr, "Warning: the built-in controls have no catalog file, so SARIF results carry only logical locations; pass --catalog for code-scanning uploads")
+		}
+		builder := sarif.NewBuilder(version)
+		builder.SetLocator(func(id string) (string, int, bool) {
+			loc, ok := cat.ControlLocation(id)
+			return filepath.ToSlash(loc.File), loc.Line, ok
+		})
+		err = sarif.Write(os.Stdout, builder.Build(cat.Controls, controls))
+	case report.FormatJUnit:
+		builder := junit.NewBuilder("securitycontrol")
+		builder.SetControls(cat.Controls)
+		builder.SetLocator(func(id string) (string, int, bool) {
+			loc, ok := cat.TestLocation(id)
+			return loc.File, loc.Line, ok
+		})
+		err = junit.Write(os.Stdout, builder.Build(cat.Tests, tests))
+	default:
+		render(r, format)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+// fatal prints an error and exits with exitError.
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	os.Exit(exitError)
+}
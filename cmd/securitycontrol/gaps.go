@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hallucinaut/securitycontrol/pkg/crosswalk"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// gapAnalysis validates the catalog's controls and shows how completely
+// they meet the requirements of a compliance framework.
+func gapAnalysis(ctx context.Context, args []string) {
+	fs, common := newFlagSet("gaps")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	framework := fs.String("framework", "", "compliance framework to analyze (nist-800-53, iso27001, cis, soc2, pci-dss)")
+	gapOpts := crosswalk.GapOptions{
+		MinEffectiveness: crosswalk.DefaultMinEffectiveness,
+		MaxControls:      crosswalk.DefaultMaxControls,
+	}
+	fs.Func("min-effectiveness", fmt.Sprintf("effectiveness a control needs to meet a requirement (default %g)", gapOpts.MinEffectiveness), func(s string) error {
+		v, err := parseRatio(s)
+		gapOpts.MinEffectiveness = v
+		return err
+	})
+	fs.IntVar(&gapOpts.MaxControls, "max-controls", gapOpts.MaxControls, "controls meeting a requirement above which it is over-covered")
+	parseArgs(fs, args)
+	if *framework == "" {
+		fatal(fmt.Errorf("gaps requires --framework"))
+	}
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+	fw := lookupFramework(*framework)
+
+	validator := assess.validator(cat)
+	recordTests(ctx, cat, validator, *opts)
+	results := validateAll(ctx, validator, *opts)
+	gaps := crosswalk.Analyze(crosswalk.Bundled().Map(fw, cat.Controls, results), cat.Controls, results, gapOpts)
+
+	if format != report.FormatText {
+		r := report.New("gaps", fw.Title()+" Gap Analysis")
+		crosswalk.AddGaps(r, gaps, gaps.Requirements)
+		render(r, format)
+		return
+	}
+
+	title := fw.Title() + " Gap Analysis"
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", len(title)))
+	fmt.Println()
+	fmt.Printf("Coverage: %.1f%% (%d met, %d partially met, %d unmet of %d requirements)\n\n",
+		gaps.Score()*100, gaps.Total.Met, gaps.Total.Partial, gaps.Total.Unmet, gaps.Total.Requirements)
+
+	fmt.Println("By family:")
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, f := range gaps.Families {
+		fmt.Fprintf(w, "  %s\t%s\t%.1f%%\t%d met\t%d partial\t%d unmet\n", f.Family.ID, f.Family.Name, f.Score()*100, f.Met, f.Partial, f.Unmet)
+	}
+	w.Flush()
+
+	fmt.Println("\nPartially met:")
+	printGaps(gaps.Status(crosswalk.GapPartial), func(g crosswalk.RequirementGap) string {
+		return "weak: " + strings.Join(g.Weak, ", ")
+	})
+
+	if gapOpts.MaxControls > 0 {
+		fmt.Printf("\nOver-covered (more than %d meeting control(s)):\n", gapOpts.MaxControls)
+		printGaps(gaps.OverCovered(), func(g crosswalk.RequirementGap) string {
+			return strings.Join(g.Meeting, ", ")
+		})
+	}
+
+	fmt.Println("\nUnmet:")
+	unmet := gaps.Status(crosswalk.GapUnmet)
+	if len(unmet) == 0 {
+		fmt.Println("  None")
+	}
+	for _, f := range gaps.Families {
+		var ids []string
+		for _, g := range unmet {
+			if g.Requirement.Family == f.Family.ID {
+				ids = append(ids, g.Requirement.ID)
+			}
+		}
+		if len(ids) > 0 {
+			fmt.Printf("  %s: %s\n", f.Family.ID, strings.Join(ids, ", "))
+		}
+	}
+}
+
+// printGaps lists requirements with a detail each, or "None".
+func printGaps(gaps []crosswalk.RequirementGap, detail func(crosswalk.RequirementGap) string) {
+	if len(gaps) == 0 {
+		fmt.Println("  None")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, g := range gaps {
+		fmt.Fprintf(w, "  %s\t%s\t%s\n", g.Requirement.ID, truncate(g.Requirement.Title, 60), detail(g))
+	}
+	w.Flush()
+}
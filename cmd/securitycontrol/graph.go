@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// statusFills are the node colors of the validation statuses in exported
+// dependency graphs.
+var statusFills = map[string]string{
+	"EFFECTIVE":           "#d4edda",
+	"PARTIALLY_EFFECTIVE": "#fff3cd",
+	"INEFFECTIVE":         "#f8d7da",
+}
+
+// dependencyGraph validates the catalog's controls and shows the controls
+// they depend on in validation order, with the controls degraded by
+// ineffective dependencies.
+func dependencyGraph(ctx context.Context, args []string) {
+	fs, common := newFlagSet("graph")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	dot := fs.String("dot", "", "write the graph as Graphviz DOT to a file (- for stdout)")
+	mermaid := fs.String("mermaid", "", "write the graph as a Mermaid flowchart to a file (- for stdout)")
+	parseArgs(fs, args)
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+
+	validator := assess.validator(cat)
+	recordTests(ctx, cat, validator, *opts)
+	results := validateAll(ctx, validator, *opts)
+	byID := make(map[string]control.ControlValidationResult, len(results))
+	for _, r := range results {
+		byID[r.ControlID] = r
+	}
+
+	g := control.DependencyGraph(cat.Controls)
+	for status, fill := range statusFills {
+		g.Style(strings.ToLower(status), fill)
+	}
+	for _, r := range results {
+		g.SetClass(r.ControlID, strings.ToLower(r.Status))
+	}
+	name := "Controls"
+	if cat.Framework.Name != "" {
+		name = cat.Framework.Name
+	}
+	if *dot != "" {
+		writeOutput(stdoutDash(*dot), func(w io.Writer) error {
+			return g.WriteDOT(w, name)
+		})
+	}
+	if *mermaid != "" {
+		writeOutput(stdoutDash(*mermaid), g.WriteMermaid)
+	}
+	if *dot == "-" || *mermaid == "-" {
+		return
+	}
+
+	// The loader rejects cycles, so every control is ordered
+	order, err := g.Order()
+	if err != nil {
+		fatal(err)
+	}
+	edges, degraded := 0, 0
+	for _, c := range cat.Controls {
+		edges += len(c.DependsOn)
+		if len(byID[c.ID].DegradedBy) > 0 {
+			degraded++
+		}
+	}
+
+	if format != report.FormatText {
+		r := report.New("graph", "Control Dependencies")
+		r.AddSummary("controls", len(order))
+		r.AddSummary("dependencies", edges)
+		r.AddSummary("degraded", degraded)
+		section := r.AddSection("controls", "Validation Order",
+			report.Column{Key: "order", Title: "Order", Kind: report.KindNumber},
+			report.Column{Key: "id", Title: "ID", Kind: report.KindString},
+			report.Column{Key: "name", Title: "Name", Kind: report.KindString},
+			report.Column{Key: "dependsOn", Title: "Depends On", Kind: report.KindList},
+			report.Column{Key: "dependents", Title: "Dependents", Kind: report.KindList},
+			report.Column{Key: "status", Title: "Status", Kind: report.KindString},
+			report.Column{Key: "effectiveness", Title: "Effectiveness", Kind: report.KindPercent},
+			report.Column{Key: "degradedBy", Title: "Degraded By", Kind: report.KindList},
+		)
+		for i, id := range order {
+			res := byID[id]
+			section.AddRow(i+1, id, res.ControlName, listOf(g.DependsOn(id)), listOf(g.Dependents(id)), res.Status, res.Effectiveness, listOf(res.DegradedBy))
+		}
+		render(r, format)
+		return
+	}
+
+	title := "Control Dependencies"
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", len(title)))
+	fmt.Println()
+	fmt.Printf("%d control(s), %d dependencies, %d degraded by ineffective dependencies\n\n", len(order), edges, degraded)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tID\tNAME\tDEPENDS ON\tSTATUS\tEFFECTIVENESS")
+	for i, id := range order {
+		res := byID[id]
+		deps := "-"
+		if len(g.DependsOn(id)) > 0 {
+			deps = strings.Join(g.DependsOn(id), ", ")
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%.1f%%\n", i+1, id, truncate(res.ControlName, 40), deps, res.Status, res.Effectiveness*100)
+	}
+	w.Flush()
+
+	// List each cascade once, from the ineffective control it starts at
+	fmt.Println("\nCascading failures:")
+	found := false
+	for _, id := range order {
+		if byID[id].Status != "INEFFECTIVE" || len(byID[id].DegradedBy) > 0 {
+			continue
+		}
+		var affected []string
+		for _, d := range g.Downstream(id) {
+			if len(byID[d].DegradedBy) > 0 {
+				affected = append(affected, d)
+			}
+		}
+		if len(affected) > 0 {
+			fmt.Printf("  %s degrades %s\n", id, strings.Join(affected, ", "))
+			found = true
+		}
+	}
+	if !found {
+		fmt.Println("  None")
+	}
+}
+
+// listOf returns an empty list for nil so that it encodes as [].
+func listOf(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/history"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+func runHistory(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: history subcommand required (list, show, prune)")
+		printUsage()
+		os.Exit(exitError)
+	}
+
+	switch args[0] {
+	case "list":
+		listHistory(args[1:])
+	case "show":
+		showHistory(args[1:])
+	case "prune":
+		pruneHistory(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown history command: %s\n", args[0])
+		printUsage()
+		os.Exit(exitError)
+	}
+}
+
+// listHistory lists recorded runs, oldest first.
+func listHistory(args []string) {
+	fs, common := newFlagSet("history list")
+	hist := storeFlags(fs, false)
+	parseArgs(fs, args)
+	format := common.outputFormat()
+
+	entries := []history.Entry{}
+	if store := hist.read(); store != nil {
+		var err error
+		if entries, err = store.List(); err != nil {
+			fatal(err)
+		}
+	}
+
+	if format != report.FormatText {
+		r := report.New("history", "Validation History")
+		r.AddSummary("runs", len(entries))
+		history.AddEntries(r, entries)
+		render(r, format)
+		return
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No runs recorded")
+		return
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RUN ID\tSTARTED\tCOMMAND\tEFFECTIVE\tPARTIAL\tINEFFECTIVE\tTESTS PASSED\tCATALOG")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d/%d\t%d\t%d\t%d/%d\t%s\n",
+			e.ID, e.StartedAt.Local().Format("2006-01-02 15:04:05"), e.Command,
+			e.Effective, e.Controls, e.PartiallyEffective, e.Ineffective,
+			e.TestsPassed, e.Tests, shortHash(e.CatalogHash))
+	}
+	tw.Flush()
+}
+
+// showHistory prints the results of a recorded run.
+func showHistory(args []string) {
+	fs, common := newFlagSet("history show")
+	hist := storeFlags(fs, false)
+	ids := parseArgs(fs, args)
+	format := common.outputFormat()
+
+	id := "latest"
+	if len(ids) > 0 {
+		id = ids[0]
+	}
+	run, err := hist.get(id)
+	if err != nil {
+		fatal(err)
+	}
+
+	if format != report.FormatText {
+		cat := common.loadCatalog()
+		r := report.New("history-run", "Validation Run "+run.ID)
+		history.AddRun(r, run)
+		control.AddResults(r, run.ControlResults())
+		validate.AddResults(r, run.TestResults())
+		renderResults(r, format, cat, run.ControlResults(), run.TestResults())
+		return
+	}
+
+	fmt.Printf("Run: %s\n", run.ID)
+	fmt.Printf("Command: %s\n", run.Command)
+	fmt.Printf("Started: %s\n", run.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	fmt.Printf("Duration: %s\n", run.FinishedAt.Sub(run.StartedAt).Round(time.Millisecond))
+	fmt.Printf("Tool Version: %s\n", run.ToolVersion)
+	fmt.Printf("Catalog: %s\n", run.CatalogHash)
+	if len(run.CatalogFiles) > 0 {
+		fmt.Printf("Catalog Files: %s\n", strings.Join(run.CatalogFiles, ", "))
+	}
+	if run.Framework != "" {
+		fmt.Printf("Framework: %s\n", run.Framework)
+	}
+	fmt.Println()
+	fmt.Println(control.GenerateResultsReport(run.ControlResults()))
+	fmt.Println(validate.GenerateResultsReport(run.TestResults()))
+}
+
+// pruneHistory removes runs outside a retention policy.
+func pruneHistory(args []string) {
+	fs, _ := newFlagSet("history prune")
+	hist := storeFlags(fs, false)
+	keepLast := fs.Int("keep-last", 0, "keep only the most recent n runs")
+	maxAge := fs.String("max-age", "", "remove runs older than this, such as 90d, 12w or 720h")
+	dryRun := fs.Bool("dry-run", false, "list the runs that would be removed")
+	parseArgs(fs, args)
+
+	retention := history.Retention{KeepLast: *keepLast}
+	if *maxAge != "" {
+		age, err := parseAge(*maxAge)
+		if err != nil {
+			fatal(err)
+		}
+		retention.MaxAge = age
+	}
+	if retention.KeepLast <= 0 && retention.MaxAge <= 0 {
+		fatal(fmt.Errorf("retention policy required (--keep-last or --max-age)"))
+	}
+
+	store := hist.open()
+	var removed []history.Entry
+	var err error
+	if *dryRun {
+		removed, err = store.Plan(retention, time.Now())
+	} else {
+		removed, err = store.Prune(retention, time.Now())
+	}
+	if err != nil {
+		fatal(err)
+	}
+
+	verb := "Removed"
+	if *dryRun {
+		verb = "Would remove"
+	}
+	for _, e := range removed {
+		fmt.Printf("%s %s (%s)\n", verb, e.ID, e.StartedAt.Local().Format("2006-01-02 15:04:05"))
+	}
+	fmt.Printf("%s %d run(s)\n", verb, len(removed))
+}
+
+// parseAge parses a duration that may use d (days) and w (weeks) units.
+func parseAge(s string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if n, ok := strings.CutSuffix(s, suffix); ok {
+			v, err := strconv.Atoi(n)
+			if err != nil || v < 0 {
+				return 0, fmt.Errorf("invalid age %q", s)
+			}
+			return time.Duration(v) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q", s)
+	}
+	return d, nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 12 {
+		return hash[:12]
+	}
+	return hash
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/hallucinaut/securitycontrol/pkg/lint"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/report/sarif"
+)
+
+// lintCatalog checks catalog files for mistakes, optionally fixing the
+// trivially fixable ones, and exits with exitPolicy if errors remain, or
+// warnings with --strict.
+func lintCatalog(args []string) {
+	fs, common := newFlagSet("lint")
+	fix := fs.Bool("fix", false, "fix trivially fixable problems in place")
+	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
+	minSeverity := fs.String("severity", string(lint.SeverityInfo), "lowest severity to report: error, warning or info")
+	var disabled stringList
+	fs.Var(&disabled, "disable", "disable a lint rule (repeatable)")
+	paths := append(common.catalogs, parseArgs(fs, args)...)
+	format := common.outputFormat()
+	if format == report.FormatJUnit {
+		fatal(fmt.Errorf("lint does not support --format junit"))
+	}
+	if len(paths) == 0 {
+		fatal(fmt.Errorf("lint requires catalog files or directories"))
+	}
+	severity, err := lint.ParseSeverity(*minSeverity)
+	if err != nil {
+		fatal(err)
+	}
+	linter := lint.NewLinter()
+	if err := linter.Disable(disabled...); err != nil {
+		fatal(err)
+	}
+
+	findings, err := linter.Lint(paths...)
+	if err != nil {
+		fatal(err)
+	}
+	fixed := 0
+	if *fix {
+		if fixed, err = lint.Apply(findings); err != nil {
+			fatal(err)
+		}
+		if fixed > 0 {
+			if findings, err = linter.Lint(paths...); err != nil {
+				fatal(err)
+			}
+		}
+	}
+
+	var shown []lint.Finding
+	for _, f := range findings {
+		if f.Severity.Rank() >= severity.Rank() {
+			shown = append(shown, f)
+		}
+	}
+
+	switch format {
+	case report.FormatText:
+		for _, f := range shown {
+			suffix := ""
+			if f.Fix != nil {
+				suffix = " (fixable)"
+			}
+			fmt.Printf("%s%s\n", f, suffix)
+		}
+		if fixed > 0 {
+			fmt.Printf("Fixed %d problem(s)\n", fixed)
+		}
+		if len(shown) == 0 {
+			fmt.Println("No problems found")
+		} else {
+			fmt.Printf("%d problem(s): %d error(s), %d warning(s), %d info\n",
+				len(shown), lint.Count(shown, lint.SeverityError), lint.Count(shown, lint.SeverityWarning), lint.Count(shown, lint.SeverityInfo))
+			if n := len(lint.Fixable(shown)); n > 0 {
+				fmt.Printf("%d fixable with --fix\n", n)
+			}
+		}
+	case report.FormatSARIF:
+		if err := sarif.Write(os.Stdout, lint.SARIF(shown, version)); err != nil {
+			fatal(err)
+		}
+	default:
+		r := report.New("lint", "Catalog Lint")
+		r.AddSummary("fixed", fixed)
+		lint.AddFindings(r, shown)
+		render(r, format)
+	}
+
+	if lint.Count(findings, lint.SeverityError) > 0 || (*strict && lint.Count(findings, lint.SeverityWarning) > 0) {
+		os.Exit(exitPolicy)
+	}
+}
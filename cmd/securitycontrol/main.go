@@ -1,13 +1,18 @@
 package main
 
 import (
-	"os/signal"
-	"syscall"
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/history"
+	"github.com/hallucinaut/securitycontrol/pkg/policy"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
 	"github.com/hallucinaut/securitycontrol/pkg/validate"
 )
 
@@ -19,29 +24,58 @@ func main() {
 		return
 	}
 
+	// Cancel in-flight validation on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	switch os.Args[1] {
 	case "validate":
-		validateControls()
+		validateControls(ctx, os.Args[2:])
 	case "test":
 		if len(os.Args) < 3 {
-			fmt.Println("Error: control ID required")
+			fmt.Fprintln(os.Stderr, "Error: control or test ID required")
 			printUsage()
-			return
+			os.Exit(exitError)
 		}
-//		testControl(os.Args[2])
+		testControl(ctx, os.Args[2:])
 	case "controls":
-		listControls()
+		listControls(os.Args[2:])
 	case "report":
-		generateReport()
+		generateReport(ctx, os.Args[2:])
 	case "status":
-		checkStatus()
+		checkStatus(ctx, os.Args[2:])
+	case "oscal":
+		runOSCAL(ctx, os.Args[2:])
+	case "history":
+		runHistory(os.Args[2:])
+	case "diff":
+		diffRuns(os.Args[2:])
+	case "rules":
+		runRules(os.Args[2:])
+	case "risk":
+		riskRegister(ctx, os.Args[2:])
+	case "simulate":
+		simulate(ctx, os.Args[2:])
+	case "attack":
+		attackCoverage(ctx, os.Args[2:])
+	case "gaps":
+		gapAnalysis(ctx, os.Args[2:])
+	case "migrate":
+		migrateFramework(os.Args[2:])
+	case "lint":
+		lintCatalog(os.Args[2:])
+	case "graph":
+		dependencyGraph(ctx, os.Args[2:])
+	case "schema":
+		os.Stdout.Write(report.Schema())
 	case "version":
-		fmt.Printf("securitycontrol version %s\n", version)
+		printVersion(os.Args[2:])
 	case "help", "--help", "-h":
 		printUsage()
 	default:
-		fmt.Printf("Unknown command: %s\n", os.Args[1])
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", os.Args[1])
 		printUsage()
+		os.Exit(exitError)
 	}
 }
 
@@ -53,33 +87,136 @@ Usage:
 
 Commands:
   validate     Validate all security controls
-  test <id>    Test specific control
+  test <id>    Run the tests of a control, or a single test
   controls     List available controls
-  report       Generate validation report
+  report       Generate a report of the latest (or --run) recorded run
   status       Check control status
+  oscal        Import or export OSCAL documents
+  history      List, show or prune recorded runs
+  diff <a> <b> Compare two result files or recorded runs
+  rules        List issue rules, or run their tests
+  risk         Show inherent and residual risk of risk scenarios
+  simulate     Simulate annual losses of quantified risk scenarios
+  attack       Show MITRE ATT&CK technique coverage as a heat map
+  gaps         Show unmet and partially met requirements of a framework
+  migrate      Show framework changes between versions and references to re-map
+  lint [path]  Check catalog files for mistakes, optionally fixing them
+  graph        Show control dependencies and cascading failures
+  schema       Print the JSON schema of --format json output
   version      Show version information
   help         Show this help message
 
+Options:
+  --catalog <path>         Load controls from a YAML file or directory (repeatable)
+  --format <format>        Output format: text, json, yaml, csv, markdown,
+                           sarif or junit
+  --workers <n>            Concurrent validation workers (default: CPU count)
+  --timeout <duration>     Overall validation timeout
+  --control-timeout <d>    Timeout for each control or test
+  --retries <n>            Retries for failing tests, with exponential backoff
+  --fail-on <status>       Fail when a control is ineffective, or partial or worse
+  --min-effectiveness <r>  Fail when a control's effectiveness is below r (0-1);
+                           with gaps, the effectiveness that meets a requirement
+  --min-confidence <r>     Fail when a control's confidence is below r (0-1)
+  --policy <file>          Thresholds per framework and category (YAML)
+  --rules <file>           Merge a rules file over the built-in issue rules (repeatable)
+  --suppress <code>[:ids]  Suppress an issue code, optionally for listed controls
+  --scoring <file>         Score controls with a scoring model file (YAML)
+  --scenarios <file>       Risk scenarios to assess besides the catalog's (risk, simulate)
+  --under-controlled       List only under-controlled risk scenarios (risk)
+  --iterations <n>         Simulated years (simulate, default: 10000)
+  --seed <n>               Random seed (simulate, default: 1)
+  --layer <file>           Write an ATT&CK Navigator layer (attack)
+  --html <file>            Write an HTML heat map (attack)
+  --dot <file>             Write the dependency graph as Graphviz DOT (graph)
+  --mermaid <file>         Write the dependency graph as a Mermaid flowchart (graph)
+  --framework <name>       Compliance framework, such as pci-dss@3.2.1 (report, gaps,
+                           migrate)
+  --max-controls <n>       Over-covered above n meeting controls; 0 disables (gaps)
+  --from <version>         Framework version references are written against (migrate)
+  --to <version>           Framework version to migrate to (migrate, default: latest)
+  --fix                    Fix trivially fixable problems in place (lint)
+  --strict                 Fail on warnings as well as errors (lint)
+  --severity <level>       Lowest severity to report: error, warning or info (lint)
+  --disable <rule>         Disable a lint rule (lint, repeatable)
+  --category, --type, --status <value>
+                           Values of imported controls without them (oscal import)
+  --history <dir>          History store (default: .securitycontrol/history)
+  --no-history             Do not record the run (validate, status and test)
+  --dry-run                Show the tests that would run (test)
+  --interactive            Prompt for each step of manual tests (test)
+
+Exit codes:
+  0  success
+  1  policy failure (validate and status), failing test (test and
+     rules test) or catalog errors (lint)
+  2  tool or usage error
+
 Examples:
   securitycontrol validate
+  securitycontrol validate --catalog controls/
   securitycontrol test ctrl-001
-  securitycontrol controls
-`,)
+  securitycontrol test test-003 --dry-run
+  securitycontrol controls --catalog controls.yaml
+  securitycontrol status --format json
+  securitycontrol validate --format sarif > results.sarif
+  securitycontrol validate --fail-on partial --min-effectiveness 0.85
+  securitycontrol validate --rules rules.yaml --suppress SC-OWNER-001:ctrl-004
+  securitycontrol rules test --rules rules.yaml
+  securitycontrol validate --scoring examples/scoring.yaml
+  securitycontrol risk --catalog examples/catalog --under-controlled
+  securitycontrol simulate --catalog examples/catalog --seed 7
+  securitycontrol attack --catalog examples/catalog --layer layer.json
+  securitycontrol report --catalog examples/catalog --framework iso27001
+  securitycontrol gaps --catalog examples/catalog --framework soc2
+  securitycontrol migrate --catalog examples/catalog --framework pci-dss --from 3.2.1
+  securitycontrol lint examples/catalog --format sarif > lint.sarif
+  securitycontrol graph --catalog examples/catalog --dot - | dot -Tsvg > graph.svg
+  securitycontrol history list
+  securitycontrol history prune --keep-last 50 --max-age 90d
+  securitycontrol diff before.json after.json --format markdown
+  securitycontrol oscal import profile.json -o controls.yaml
+  securitycontrol oscal export assessment-results --catalog controls/
+`)
 }
 
-func validateControls() {
+func validateControls(ctx context.Context, args []string) {
+	fs, common := newFlagSet("validate")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	gate := gateFlags(fs)
+	hist := storeFlags(fs, true)
+	parseArgs(fs, args)
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+	gatePolicy := gate.load()
+	startedAt := time.Now()
+
+	if format != report.FormatText {
+		validator := assess.validator(cat)
+		tests := recordTests(ctx, cat, validator, *opts)
+		results := validateAll(ctx, validator, *opts)
+		hist.record("validate", cat, startedAt, results, tests)
+		violations := gatePolicy.Evaluate(cat.Framework.Name, cat.Controls, results)
+		r := report.New("validate", "Security Control Validation")
+		control.AddResults(r, results)
+		validate.AddResults(r, tests)
+		if gatePolicy.Active() {
+			policy.AddViolations(r, violations)
+		}
+		renderResults(r, format, cat, results, tests)
+		enforce(violations)
+		return
+	}
+
 	fmt.Println("Security Control Validation")
 	fmt.Println("==========================")
 	fmt.Println()
 
 	// Create validator
-	validator := control.NewControlValidator()
-
-	// Add common controls
-	commonControls := control.CreateCommonControls()
-	for _, ctrl := range commonControls {
-		validator.AddControl(ctrl)
-	}
+	validator := assess.validator(cat)
+	commonControls := cat.Controls
+	tests := recordTests(ctx, cat, validator, *opts)
 
 	fmt.Println("Controls to Validate:")
 	for i, ctrl := range commonControls {
@@ -91,72 +228,49 @@ func validateControls() {
 	fmt.Println()
 
 	// Validate controls
-	for _, ctrl := range commonControls {
-		result := validator.ValidateControl(ctrl.ID)
-		if result != nil {
-			fmt.Printf("[%s] %s\n", result.Status, ctrl.Name)
-			fmt.Printf("    Effectiveness: %.1f%%\n", result.Effectiveness*100)
-			fmt.Printf("    Confidence: %.1f%%\n", result.Confidence*100)
-			if len(result.Issues) > 0 {
-				fmt.Printf("    Issues: %d\n", len(result.Issues))
-			}
-			fmt.Println()
+	results := validateAll(ctx, validator, *opts)
+	for _, result := range results {
+		fmt.Printf("[%s] %s\n", result.Status, result.ControlName)
+		fmt.Printf("    Effectiveness: %.1f%%\n", result.Effectiveness*100)
+		fmt.Printf("    Confidence: %.1f%%\n", result.Confidence*100)
+		if !result.ProjectedEffectiveUntil.IsZero() {
+			fmt.Printf("    Projected effective until: %s\n", effectiveUntil(result))
+		}
+		if result.Untested {
+			fmt.Println("    Tests: UNTESTED")
+		} else {
+			fmt.Printf("    Tests: %d/%d passed\n", result.TestsPassed, result.TestsLinked)
+		}
+		if len(result.Issues) > 0 {
+			fmt.Printf("    Issues: %d\n", len(result.Issues))
 		}
+		fmt.Println()
 	}
 
 	fmt.Println(control.GenerateReport(validator))
+	hist.record("validate", cat, startedAt, results, tests)
+	enforce(gatePolicy.Evaluate(cat.Framework.Name, cat.Controls, results))
 }
 
-//func testControl(controlID string) {
-//	fmt.Printf("Testing Control: %s\n", controlID)
-//	fmt.Println()
-//
-//	// Create validator
-//	validator := validate.NewControlValidator()
-//
-//	// Add common tests
-//	commonTests := validate.CreateCommonControlTests()
-//	for _, test := range commonTests {
-//		validator.AddControlTest(test)
-//	}
-//
-//	// Find and run test
-//	found := false
-//	for _, test := range commonTests {
-//		if test.ID == controlID {
-//			fmt.Printf("Test: %s\n", test.Name)
-//			fmt.Printf("Description: %s\n", test.Description)
-//			fmt.Printf("Method: %s\n\n", test.Method)
-//
-//			// Run test
-//			result := validator.ValidateControlTest(test)
-//			fmt.Printf("Result: %s\n", result.ValidationResult)
-//			fmt.Printf("Effectiveness: %.1f%%\n", result.Effectiveness*100)
-//			fmt.Printf("Risk Remaining: %.1f%%\n", result.RiskRemaining*100)
-//
-//			if len(result.Recommendations) > 0 {
-//				fmt.Println("\nRecommendations:")
-//				for _, rec := range result.Recommendations {
-//					fmt.Printf("  • %s\n", rec)
-//				}
-//			}
-//
-//			found = true
-//			break
-//		}
-//	}
-//
-//	if !found {
-//		fmt.Println("Control not found:", controlID)
-//	}
-//}
-//
-func listControls() {
+func listControls(args []string) {
+	fs, common := newFlagSet("controls")
+	parseArgs(fs, args)
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+
+	if format != report.FormatText {
+		r := report.New("controls", "Available Security Controls")
+		r.AddSummary("controls", len(cat.Controls))
+		control.AddControls(r, cat.Controls)
+		render(r, format)
+		return
+	}
+
 	fmt.Println("Available Security Controls")
 	fmt.Println("===========================")
 	fmt.Println()
 
-	controls := control.CreateCommonControls()
+	controls := cat.Controls
 
 	fmt.Println("Controls by Category:")
 	fmt.Println()
@@ -180,47 +294,109 @@ func listControls() {
 	fmt.Printf("Total Controls: %d\n", len(controls))
 }
 
-func generateReport() {
-	fmt.Println("Generate Validation Report")
-	fmt.Println("=========================")
-	fmt.Println()
+func generateReport(ctx context.Context, args []string) {
+	fs, common := newFlagSet("report")
+	hist := storeFlags(fs, false)
+	runID := fs.String("run", "latest", "run to report on")
+	framework := fs.String("framework", "", "report against a compliance framework (nist-800-53, iso27001, cis, soc2, pci-dss)")
+	parseArgs(fs, args)
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+
+	// Report on a stored run
+	var results []control.ControlValidationResult
+	var tests []validate.ValidationResult
+	run, err := hist.get(*runID)
+	switch {
+	case err == nil:
+		results = run.ControlResults()
+		tests = run.TestResults()
+	case errors.Is(err, history.ErrNotFound) && *runID == "latest":
+		// No runs recorded yet
+	default:
+		fatal(err)
+	}
 
-	// Create validators
-	controlValidator := control.NewControlValidator()
-	validateValidator := validate.NewControlValidator()
+	if *framework != "" {
+		frameworkReport(*framework, format, cat, run, results)
+		return
+	}
 
-	// Add controls
-	commonControls := control.CreateCommonControls()
-	for _, ctrl := range commonControls {
-		controlValidator.AddControl(ctrl)
+	if format != report.FormatText {
+		r := report.New("report", "Validation Report")
+		if run != nil {
+			history.AddRun(r, run)
+		}
+		control.AddResults(r, results)
+		validate.AddResults(r, tests)
+		renderResults(r, format, cat, results, tests)
+		return
 	}
 
-	// Add tests
-	commonTests := validate.CreateCommonControlTests()
-	for _, test := range commonTests {
-		validateValidator.AddControlTest(test)
+	fmt.Println("Generate Validation Report")
+	fmt.Println("=========================")
+	fmt.Println()
+
+	if run == nil {
+		fmt.Println("No runs recorded yet; run 'securitycontrol validate' first")
+		fmt.Println()
+	} else {
+		fmt.Printf("Run: %s (%s, %s)\n", run.ID, run.Command, run.StartedAt.Format("2006-01-02 15:04:05"))
+		fmt.Printf("Catalog: %s\n", run.CatalogHash)
+		fmt.Println()
 	}
 
 	// Generate reports
 	fmt.Println("=== Control Validation Report ===")
-	fmt.Println(control.GenerateReport(controlValidator))
+	fmt.Println(control.GenerateResultsReport(results))
 
 	fmt.Println("\n=== Test Validation Report ===")
-	fmt.Println(validate.GenerateValidationReport(validateValidator))
+	fmt.Println(validate.GenerateResultsReport(tests))
 }
 
-func checkStatus() {
+func checkStatus(ctx context.Context, args []string) {
+	fs, common := newFlagSet("status")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	gate := gateFlags(fs)
+	hist := storeFlags(fs, true)
+	parseArgs(fs, args)
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+	gatePolicy := gate.load()
+	startedAt := time.Now()
+
+	if format != report.FormatText {
+		validator := assess.validator(cat)
+		tests := recordTests(ctx, cat, validator, *opts)
+		r := report.New("status", "Security Control Status")
+		for _, status := range []control.ControlStatus{
+			control.StatusImplemented,
+			control.StatusPartiallyImplemented,
+			control.StatusNotImplemented,
+			control.StatusDeprecated,
+		} {
+			r.AddSummary(string(status), len(validator.GetControlsByStatus(status)))
+		}
+		results := validateAll(ctx, validator, *opts)
+		hist.record("status", cat, startedAt, results, tests)
+		violations := gatePolicy.Evaluate(cat.Framework.Name, cat.Controls, results)
+		control.AddResults(r, results)
+		if gatePolicy.Active() {
+			policy.AddViolations(r, violations)
+		}
+		renderResults(r, format, cat, results, tests)
+		enforce(violations)
+		return
+	}
+
 	fmt.Println("Security Control Status")
 	fmt.Println("=======================")
 	fmt.Println()
 
-	validator := control.NewControlValidator()
-
-	// Add common controls
-	commonControls := control.CreateCommonControls()
-	for _, ctrl := range commonControls {
-		validator.AddControl(ctrl)
-	}
+	validator := assess.validator(cat)
+	commonControls := cat.Controls
+	tests := recordTests(ctx, cat, validator, *opts)
 
 	fmt.Println("Control Status Summary:")
 	fmt.Println()
@@ -241,10 +417,31 @@ func checkStatus() {
 	fmt.Println()
 
 	// Validate all controls
-	for _, ctrl := range commonControls {
-		result := validator.ValidateControl(ctrl.ID)
-		if result != nil {
-			fmt.Printf("[%s] %.1f%% effective - %s\n", result.Status, result.Effectiveness*100, ctrl.Name)
+	results := validateAll(ctx, validator, *opts)
+	for _, result := range results {
+		untested := ""
+		if result.Untested {
+			untested = " (untested)"
 		}
+		fmt.Printf("[%s] %.1f%% effective - %s%s\n", result.Status, result.Effectiveness*100, result.ControlName, untested)
+	}
+
+	hist.record("status", cat, startedAt, results, tests)
+	enforce(gatePolicy.Evaluate(cat.Framework.Name, cat.Controls, results))
+}
+
+func printVersion(args []string) {
+	fs, common := newFlagSet("version")
+	parseArgs(fs, args)
+	format := common.outputFormat()
+
+	if format != report.FormatText {
+		r := report.New("version", "securitycontrol")
+		r.AddSummary("version", version)
+		r.AddSummary("schemaVersion", report.SchemaVersion)
+		render(r, format)
+		return
 	}
-}
\ No newline at end of file
+
+	fmt.Printf("securitycontrol version %s\n", version)
+}
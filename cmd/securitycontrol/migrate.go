@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hallucinaut/securitycontrol/pkg/crosswalk"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// migrateFramework shows how the requirements of a compliance framework
+// changed between two versions and which of the catalog's control
+// references need to be re-mapped.
+func migrateFramework(args []string) {
+	fs, common := newFlagSet("migrate")
+	framework := fs.String("framework", "", "compliance framework to migrate (such as pci-dss)")
+	fromVersion := fs.String("from", "", "version the catalog's references are written against")
+	toVersion := fs.String("to", "", "version to migrate to (default: latest)")
+	parseArgs(fs, args)
+	if *framework == "" || *fromVersion == "" {
+		fatal(fmt.Errorf("migrate requires --framework and --from"))
+	}
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+
+	from := lookupFramework(*framework + "@" + *fromVersion)
+	to := lookupFramework(*framework)
+	if *toVersion != "" {
+		to = lookupFramework(*framework + "@" + *toVersion)
+	}
+	m, err := crosswalk.Bundled().Migrate(from, to, cat.Controls)
+	if err != nil {
+		fatal(err)
+	}
+
+	if format != report.FormatText {
+		r := report.New("migrate", from.Title()+" to "+to.Version+" Migration")
+		crosswalk.AddMigration(r, m)
+		render(r, format)
+		return
+	}
+
+	title := fmt.Sprintf("%s → %s Migration", from.Title(), to.Version)
+	fmt.Println(title)
+	fmt.Println(strings.Repeat("=", len([]rune(title))))
+	fmt.Println()
+	fmt.Printf("Changes: %d new, %d removed, %d split, %d merged, %d restructured, %d renumbered, %d unchanged\n",
+		m.Count(crosswalk.ChangeNew), m.Count(crosswalk.ChangeRemoved), m.Count(crosswalk.ChangeSplit),
+		m.Count(crosswalk.ChangeMerged), m.Count(crosswalk.ChangeRestructured), m.Count(crosswalk.ChangeRenumbered),
+		m.Count(crosswalk.ChangeUnchanged))
+
+	var kind crosswalk.ChangeKind
+	var w *tabwriter.Writer
+	for _, c := range m.Changes {
+		if c.Kind == crosswalk.ChangeUnchanged {
+			continue
+		}
+		if c.Kind != kind {
+			if w != nil {
+				w.Flush()
+			}
+			kind = c.Kind
+			fmt.Printf("\n%s%s:\n", strings.ToUpper(string(kind[:1])), kind[1:])
+			w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		}
+		var ids string
+		switch c.Kind {
+		case crosswalk.ChangeNew:
+			ids = c.To[0].ID
+		case crosswalk.ChangeRemoved:
+			ids = c.From[0].ID
+		default:
+			ids = requirementIDs(c.From) + " → " + requirementIDs(c.To)
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", ids, truncate(c.Title(), 70))
+	}
+	if w != nil {
+		w.Flush()
+	}
+
+	fmt.Printf("\nReferences to re-map (%d):\n", len(m.Remaps))
+	if len(m.Remaps) == 0 {
+		fmt.Println("  None")
+		return
+	}
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, rm := range m.Remaps {
+		suggested := "no successor"
+		if len(rm.Suggested) > 0 {
+			suggested = strings.Join(rm.Suggested, ", ")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t→ %s\n", rm.Control, rm.Reference, rm.Kind, suggested)
+	}
+	w.Flush()
+}
+
+// requirementIDs joins the IDs of requirements.
+func requirementIDs(reqs []crosswalk.Requirement) string {
+	ids := make([]string, len(reqs))
+	for i, req := range reqs {
+		ids[i] = req.ID
+	}
+	return strings.Join(ids, ", ")
+}
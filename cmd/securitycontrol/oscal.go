@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/hallucinaut/securitycontrol/pkg/catalog"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/oscal"
+)
+
+func runOSCAL(ctx context.Context, args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: oscal subcommand required (import, export)")
+		printUsage()
+		os.Exit(exitError)
+	}
+
+	switch args[0] {
+	case "import":
+		importOSCAL(args[1:])
+	case "export":
+		exportOSCAL(ctx, args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown oscal command: %s\n", args[0])
+		printUsage()
+		os.Exit(exitError)
+	}
+}
+
+// importOSCAL converts an OSCAL catalog or profile into a YAML catalog.
+// Controls without securitycontrol properties get the category, type and
+// status given by flags, and the result is checked by the loader before
+// it is written.
+func importOSCAL(args []string) {
+	fs, _ := newFlagSet("oscal import")
+	output := fs.String("o", "", "write the catalog to a file instead of stdout")
+	category := fs.String("category", string(oscal.ImportDefaults.Category), "category of controls without one")
+	controlType := fs.String("type", string(oscal.ImportDefaults.Type), "type of controls without one")
+	status := fs.String("status", string(oscal.ImportDefaults.Status), "status of controls without one")
+	files := parseArgs(fs, args)
+	if len(files) != 1 {
+		fatal(fmt.Errorf("OSCAL catalog or profile file required"))
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		fatal(err)
+	}
+	doc, err := oscal.Decode(f)
+	f.Close()
+	if err != nil {
+		fatal(err)
+	}
+
+	var cat *oscal.Catalog
+	switch {
+	case doc.Catalog != nil:
+		cat = doc.Catalog
+	case doc.Profile != nil:
+		if cat, err = oscal.ResolveProfileFile(files[0]); err != nil {
+			fatal(err)
+		}
+	default:
+		fatal(fmt.Errorf("%s is not an OSCAL catalog or profile", files[0]))
+	}
+
+	defaults := oscal.Defaults{
+		Category: control.ControlCategory(*category),
+		Type:     control.ControlType(*controlType),
+		Status:   control.ControlStatus(*status),
+	}
+	data, err := catalog.Marshal(oscal.ImportCatalog(cat, defaults))
+	if err != nil {
+		fatal(err)
+	}
+	name := *output
+	if name == "" {
+		name = "imported catalog"
+	}
+	if _, err := catalog.NewLoader().Parse(name, data); err != nil {
+		fatal(err)
+	}
+	writeOutput(*output, func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// exportOSCAL validates the catalog and exports an OSCAL document.
+func exportOSCAL(ctx context.Context, args []string) {
+	fs, common := newFlagSet("oscal export")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	output := fs.String("o", "", "write the document to a file instead of stdout")
+	title := fs.String("title", "Security Control Validation", "document title")
+	systemID := fs.String("system-id", "", "system identifier for POA&M documents")
+	kinds := parseArgs(fs, args)
+	if len(kinds) != 1 {
+		fatal(fmt.Errorf("export kind required (catalog, assessment-results, poam)"))
+	}
+	cat := common.loadCatalog()
+
+	exporter := oscal.NewExporter(*title)
+	exporter.SetSystemID(*systemID)
+
+	doc := &oscal.Document{}
+	switch kinds[0] {
+	case "catalog":
+		doc.Catalog = oscal.ExportCatalog(cat.Framework)
+	case "assessment-results", "poam":
+		validator := assess.validator(cat)
+		testResults := recordTests(ctx, cat, validator, *opts)
+		validateAll(ctx, validator, *opts)
+
+		if kinds[0] == "poam" {
+			doc.PlanOfActionAndMilestones = exporter.PlanOfActionAndMilestones(validator.GetValidationResults())
+			break
+		}
+
+		exporter.AddControlTests(cat.Tests)
+		doc.AssessmentResults = exporter.AssessmentResults(validator.GetValidationResults(), testResults)
+	default:
+		fmt.Printf("Unknown export kind: %s\n", kinds[0])
+		return
+	}
+
+	writeOutput(*output, func(w io.Writer) error {
+		return oscal.Encode(w, doc)
+	})
+}
+
+// writeOutput writes to the named file, or stdout when name is empty.
+func writeOutput(name string, write func(w io.Writer) error) {
+	if name == "" {
+		if err := write(os.Stdout); err != nil {
+			fatal(err)
+		}
+		return
+	}
+
+	f, err := os.Create(name)
+	if err != nil {
+		fatal(err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		fatal(err)
+	}
+}
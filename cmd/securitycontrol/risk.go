@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hallucinaut/securitycontrol/pkg/catalog"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/risk"
+)
+
+// riskRegister validates the catalog's controls and shows the inherent and
+// residual risk of each risk scenario. Scenarios come from the catalog's
+// risks and any --scenarios file.
+func riskRegister(ctx context.Context, args []string) {
+	fs, common := newFlagSet("risk")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	scenariosFile := fs.String("scenarios", "", "additional risk scenarios file (YAML)")
+	underControlled := fs.Bool("under-controlled", false, "list only scenarios that no effective, implemented control mitigates")
+	parseArgs(fs, args)
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+
+	scenarios := loadScenarios(cat, *scenariosFile)
+
+	validator := assess.validator(cat)
+	recordTests(ctx, cat, validator, *opts)
+	reg := risk.Assess(cat.Framework, scenarios, validateAll(ctx, validator, *opts))
+
+	assessments := reg.Assessments
+	if *underControlled {
+		assessments = reg.UnderControlled()
+	}
+
+	if format != report.FormatText {
+		r := report.New("risk", "Risk Register")
+		risk.AddRegister(r, reg, assessments)
+		render(r, format)
+		return
+	}
+
+	if *underControlled {
+		printUnderControlled(assessments)
+		return
+	}
+
+	fmt.Println("Risk Register")
+	fmt.Println("=============")
+	fmt.Println()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSCENARIO\tASSET\tTHREAT\tINHERENT\tRESIDUAL\tREDUCTION")
+	for _, a := range reg.Assessments {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%.1f\t%.1f\t%.1f%%\n", a.Scenario.ID, a.Scenario.Name, a.Scenario.Asset,
+			a.Scenario.Threat, a.Scenario.Inherent(), a.ResidualRisk, a.Reduction()*100)
+	}
+	w.Flush()
+
+	fmt.Println("\nBy category:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, t := range reg.Categories {
+		fmt.Fprintf(w, "  %s\t%d scenario(s)\t%.1f → %.1f\t(-%.1f%%)\n", t.Name, t.Scenarios, t.InherentRisk, t.ResidualRisk, t.Reduction()*100)
+	}
+	w.Flush()
+
+	fmt.Printf("\nTotal: %.1f inherent → %.1f residual (-%.1f%%)\n", reg.Total.InherentRisk, reg.Total.ResidualRisk, reg.Total.Reduction()*100)
+
+	if under := reg.UnderControlled(); len(under) > 0 {
+		ids := make([]string, 0, len(under))
+		for _, a := range under {
+			ids = append(ids, a.Scenario.ID)
+		}
+		fmt.Printf("Under-controlled: %s (see --under-controlled)\n", strings.Join(ids, ", "))
+	}
+
+	for _, a := range reg.Assessments {
+		for _, m := range a.Mitigations {
+			if m.Missing {
+				fmt.Fprintf(os.Stderr, "Warning: %s links to control %s, which was not validated\n", a.Scenario.ID, m.ControlID)
+			}
+		}
+	}
+}
+
+// loadScenarios returns the catalog's risk scenarios followed by those of a
+// scenarios file, if one is given.
+func loadScenarios(cat *catalog.Catalog, path string) []risk.RiskScenario {
+	scenarios := cat.Risks
+	if path != "" {
+		extra, err := risk.Load(path)
+		if err != nil {
+			fatal(err)
+		}
+		scenarios = append(append([]risk.RiskScenario(nil), scenarios...), extra...)
+		if err := risk.Validate(scenarios); err != nil {
+			fatal(err)
+		}
+	}
+	if len(scenarios) == 0 {
+		fatal(fmt.Errorf("no risk scenarios: add risks to the catalog or pass --scenarios"))
+	}
+	return scenarios
+}
+
+// printUnderControlled lists under-controlled scenarios with the state of
+// each of their controls.
+func printUnderControlled(assessments []risk.Assessment) {
+	if len(assessments) == 0 {
+		fmt.Println("No under-controlled risk scenarios.")
+		return
+	}
+
+	fmt.Printf("Under-Controlled Risks (%d)\n", len(assessments))
+	fmt.Println("======================")
+	for _, a := range assessments {
+		s := a.Scenario
+		fmt.Printf("\n%s: %s\n", s.ID, s.Name)
+		if s.Asset != "" || s.Threat != "" {
+			fmt.Printf("  Asset: %s  Threat: %s\n", s.Asset, s.Threat)
+		}
+		fmt.Printf("  Inherent risk: %.1f\n", s.Inherent())
+		if len(a.Mitigations) == 0 {
+			fmt.Println("  No mitigating controls")
+		}
+		for _, m := range a.Mitigations {
+			state := m.Status
+			switch {
+			case m.Missing:
+				state = "not validated"
+			case m.ControlStatus == control.StatusNotImplemented:
+				state = string(m.ControlStatus)
+			}
+			fmt.Printf("  - %s %s: %s\n", m.ControlID, m.ControlName, state)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/rules"
+)
+
+func runRules(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "Error: rules subcommand required (list, test)")
+		printUsage()
+		os.Exit(exitError)
+	}
+
+	switch args[0] {
+	case "list":
+		listRules(args[1:])
+	case "test":
+		testRules(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown rules command: %s\n", args[0])
+		printUsage()
+		os.Exit(exitError)
+	}
+}
+
+// loadRules loads the built-in rules merged with the given rules files.
+func loadRules(paths []string) *rules.Set {
+	set, err := control.LoadRules(paths...)
+	if err != nil {
+		fatal(fmt.Errorf("failed to load rules: %w", err))
+	}
+	return set
+}
+
+// listRules lists the issue rules, with those of any rules files, given
+// with --rules or as arguments, merged over the built-in ones.
+func listRules(args []string) {
+	fs, common := newFlagSet("rules list")
+	var files stringList
+	fs.Var(&files, "rules", "rules file merged over the built-in issue rules (repeatable)")
+	paths := append(files, parseArgs(fs, args)...)
+	format := common.outputFormat()
+	set := loadRules(paths)
+
+	if format != report.FormatText {
+		r := report.New("rules", "Issue Rules")
+		r.AddSummary("rules", len(set.Rules))
+		r.AddSummary("active", len(set.Active()))
+		section := r.AddSection("rules", "Rules",
+			report.Column{Key: "id", Title: "ID", Kind: report.KindString},
+			report.Column{Key: "code", Title: "Code", Kind: report.KindString},
+			report.Column{Key: "severity", Title: "Severity", Kind: report.KindString},
+			report.Column{Key: "disabled", Title: "Disabled", Kind: report.KindBool},
+			report.Column{Key: "when", Title: "When", Kind: report.KindString},
+			report.Column{Key: "description", Title: "Description", Kind: report.KindString},
+			report.Column{Key: "tests", Title: "Tests", Kind: report.KindNumber},
+		)
+		for _, rule := range set.Rules {
+			section.AddRow(rule.ID, rule.Issue.Code, rule.Issue.Severity, rule.Disabled, rule.When, rule.Description, len(rule.Tests))
+		}
+		render(r, format)
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCODE\tSEVERITY\tWHEN")
+	for _, rule := range set.Rules {
+		when := rule.When
+		if rule.Disabled {
+			when = "(disabled)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", rule.ID, rule.Issue.Code, rule.Issue.Severity, when)
+	}
+	w.Flush()
+
+	fmt.Printf("\nFields: %s\n", strings.Join(control.RuleSchema.Fields(), ", "))
+	fmt.Println("Functions:")
+	for _, doc := range rules.Functions() {
+		fmt.Printf("  %s\n", doc)
+	}
+}
+
+// testRules runs the unit tests of the issue rules, with those of any
+// rules files given with --rules or as arguments, and exits with
+// exitPolicy if any fail.
+func testRules(args []string) {
+	fs, common := newFlagSet("rules test")
+	var files stringList
+	fs.Var(&files, "rules", "rules file merged over the built-in issue rules (repeatable)")
+	paths := append(files, parseArgs(fs, args)...)
+	format := common.outputFormat()
+	results := loadRules(paths).RunTests(time.Now())
+
+	failed := 0
+	for _, result := range results {
+		if !result.Passed {
+			failed++
+		}
+	}
+
+	if format != report.FormatText {
+		r := report.New("rules-test", "Issue Rule Tests")
+		r.AddSummary("tests", len(results))
+		r.AddSummary("failed", failed)
+		section := r.AddSection("tests", "Tests",
+			report.Column{Key: "rule", Title: "Rule", Kind: report.KindString},
+			report.Column{Key: "test", Title: "Test", Kind: report.KindString},
+			report.Column{Key: "passed", Title: "Passed", Kind: report.KindBool},
+			report.Column{Key: "fired", Title: "Fired", Kind: report.KindBool},
+			report.Column{Key: "error", Title: "Error", Kind: report.KindString},
+		)
+		for _, result := range results {
+			section.AddRow(result.RuleID, result.Test, result.Passed, result.Fired, errorString(result.Err))
+		}
+		render(r, format)
+	} else {
+		for _, result := range results {
+			status := "PASS"
+			if !result.Passed {
+				status = "FAIL"
+			}
+			fmt.Printf("[%s] %s: %s\n", status, result.RuleID, result.Test)
+			switch {
+			case result.Err != nil:
+				fmt.Printf("    Error: %s\n", result.Err)
+			case !result.Passed:
+				fmt.Printf("    Rule fired: %t, expected %t\n", result.Fired, !result.Fired)
+			}
+		}
+		fmt.Printf("\n%d test(s), %d failed\n", len(results), failed)
+	}
+
+	if failed > 0 {
+		os.Exit(exitPolicy)
+	}
+}
+
+// errorString returns the message of an error, or "" if it is nil.
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
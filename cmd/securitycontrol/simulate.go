@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/simulation"
+)
+
+// simulate validates the catalog's controls and estimates the annual loss
+// exposure of the quantified risk scenarios.
+func simulate(ctx context.Context, args []string) {
+	fs, common := newFlagSet("simulate")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	scenariosFile := fs.String("scenarios", "", "additional risk scenarios file (YAML)")
+	iterations := fs.Int("iterations", simulation.DefaultIterations, "number of simulated years")
+	seed := fs.Int64("seed", 1, "random seed; the same seed gives the same result")
+	parseArgs(fs, args)
+	if *iterations <= 0 {
+		fatal(fmt.Errorf("--iterations must be positive"))
+	}
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+	scenarios := loadScenarios(cat, *scenariosFile)
+
+	validator := assess.validator(cat)
+	recordTests(ctx, cat, validator, *opts)
+	results := validateAll(ctx, validator, *opts)
+
+	res, err := simulation.Simulate(cat.Framework, scenarios, results, simulation.Options{Iterations: *iterations, Seed: *seed})
+	if err != nil {
+		fatal(err)
+	}
+
+	if format != report.FormatText {
+		r := report.New("simulate", "Loss Simulation")
+		simulation.AddResult(r, res)
+		render(r, format)
+		return
+	}
+
+	fmt.Println("Loss Simulation")
+	fmt.Println("===============")
+	fmt.Printf("%d simulated years, seed %d\n\n", res.Iterations, res.Seed)
+
+	fmt.Printf("Annualized loss expectancy: %.0f inherent → %.0f residual\n\n", res.InherentALE, res.ResidualALE)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tSCENARIO\tEVENTS STOPPED\tLOSS AVOIDED\tINHERENT ALE\tRESIDUAL ALE")
+	for _, s := range res.Scenarios {
+		fmt.Fprintf(w, "%s\t%s\t%.1f%%\t%.1f%%\t%.0f\t%.0f\n", s.Scenario.ID, s.Scenario.Name,
+			s.FrequencyReduction*100, s.MagnitudeReduction*100, s.InherentALE, s.ResidualALE)
+	}
+	w.Flush()
+
+	fmt.Println("\nLoss exceedance:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "  PROBABILITY\tINHERENT\tRESIDUAL")
+	for _, p := range res.Exceedance {
+		fmt.Fprintf(w, "  %g%%\t%.0f\t%.0f\n", p.Probability*100, p.InherentLoss, p.ResidualLoss)
+	}
+	w.Flush()
+
+	fmt.Println("\nMarginal value of controls:")
+	w = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, c := range res.Controls {
+		fmt.Fprintf(w, "  %s\t%s\t%.0f\t%s\n", c.ControlID, c.ControlName, c.MarginalValue, strings.Join(c.Scenarios, ", "))
+	}
+	w.Flush()
+
+	if len(res.Skipped) > 0 {
+		fmt.Fprintf(os.Stderr, "Warning: no frequency or magnitude estimate for %s; not simulated\n", strings.Join(res.Skipped, ", "))
+	}
+}
@@ -0,0 +1,309 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/catalog"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// testControl executes the tests of a control, or a single test, streaming
+// their progress and recording the outcome in the history store.
+func testControl(ctx context.Context, args []string) {
+	fs, common := newFlagSet("test")
+	opts := engineFlags(fs)
+	assess := assessOptions(fs)
+	hist := storeFlags(fs, true)
+	dryRun := fs.Bool("dry-run", false, "show the tests that would be executed without running them")
+	interactive := fs.Bool("interactive", false, "prompt for the result of each step of manual tests")
+	ids := parseArgs(fs, args)
+	if len(ids) != 1 {
+		fatal(fmt.Errorf("test requires a control or test ID"))
+	}
+	cat := common.loadCatalog()
+	format := common.outputFormat()
+
+	ctrl, tests := resolveTests(cat, ids[0])
+
+	registry := validate.DefaultRegistry()
+	if *interactive {
+		// Prompts go to stderr when stdout carries a structured report
+		prompts := io.Writer(os.Stdout)
+		if format != report.FormatText {
+			prompts = os.Stderr
+		}
+		manual := validate.NewInteractiveExecutor(os.Stdin, prompts)
+		for _, kind := range []string{
+			string(validate.MethodDocumentation),
+			string(validate.MethodInterview),
+			string(validate.MethodObservation),
+			string(validate.MethodTesting),
+			validate.KindManual,
+		} {
+			registry.Register(kind, manual)
+		}
+		opts.Workers = 1
+	}
+
+	if *dryRun {
+		printPlan(ctrl, tests, registry, format)
+		return
+	}
+
+	progress := io.Writer(os.Stdout)
+	if format != report.FormatText {
+		progress = os.Stderr
+	}
+
+	validator := validate.NewControlValidator()
+	validator.SetRegistry(registry)
+	for _, test := range tests {
+		validator.AddControlTest(test)
+	}
+
+	startedAt := time.Now()
+	results, err := validator.ValidateAll(validate.WithProgress(ctx, printProgress(progress)), *opts)
+	if err != nil {
+		fatal(fmt.Errorf("test execution stopped: %w", err))
+	}
+
+	var controlResults []control.ControlValidationResult
+	if ctrl != nil {
+		controls := assess.validator(cat)
+		for _, result := range results {
+			if result.Completed() {
+				controls.RecordTestOutcome(result.Outcome())
+			}
+		}
+		// Degrade the control by the recorded results of its dependencies
+		if upstream := control.DependencyGraph(cat.Controls).Upstream(ctrl.ID); len(upstream) > 0 {
+			controls.AddValidationResults(hist.latestResults(upstream)...)
+		}
+		if result := controls.ValidateControl(ctrl.ID); result != nil {
+			controlResults = append(controlResults, *result)
+		}
+	}
+	hist.record("test", cat, startedAt, controlResults, results)
+
+	if format != report.FormatText {
+		r := report.New("test", "Security Control Test")
+		if ctrl != nil {
+			control.AddResults(r, controlResults)
+		}
+		validate.AddResults(r, results)
+		renderResults(r, format, cat, controlResults, results)
+	} else {
+		printTestResults(results, controlResults)
+	}
+
+	for _, result := range results {
+		if !result.TestPassed {
+			os.Exit(exitPolicy)
+		}
+	}
+}
+
+// latestResults returns the latest recorded result of each listed control,
+// searching the runs of the history store newest first. Controls that were
+// never recorded are left out.
+func (h *historyFlags) latestResults(ids []string) []control.ControlValidationResult {
+	store := h.read()
+	if store == nil {
+		return nil
+	}
+	entries, err := store.List()
+	if err != nil {
+		fatal(fmt.Errorf("history: %w", err))
+	}
+
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var results []control.ControlValidationResult
+	for i := len(entries) - 1; i >= 0 && len(wanted) > 0; i-- {
+		run, err := store.Get(entries[i].ID)
+		if err != nil {
+			fatal(fmt.Errorf("history: %w", err))
+		}
+		for _, result := range run.ControlResults() {
+			if wanted[result.ControlID] {
+				delete(wanted, result.ControlID)
+				results = append(results, result)
+			}
+		}
+	}
+	return results
+}
+
+// resolveTests returns the tests selected by an ID: every test linked to a
+// control, or a single test. The control is nil when a test ID was given.
+func resolveTests(cat *catalog.Catalog, id string) (*control.SecurityControl, []validate.ControlTest) {
+	if ctrl := cat.GetControl(id); ctrl != nil {
+		if len(ctrl.Tests) == 0 {
+			fatal(fmt.Errorf("control %s has no linked tests", id))
+		}
+		tests := make([]validate.ControlTest, 0, len(ctrl.Tests))
+		for _, testID := range ctrl.Tests {
+			test := cat.GetTest(testID)
+			if test == nil {
+				fatal(fmt.Errorf("control %s links to unknown test %s", id, testID))
+			}
+			tests = append(tests, *test)
+		}
+		return ctrl, tests
+	}
+
+	if test := cat.GetTest(id); test != nil {
+		return nil, []validate.ControlTest{*test}
+	}
+	fatal(fmt.Errorf("no control or test with ID %s", id))
+	return nil, nil
+}
+
+// printPlan shows the tests that would be executed and how.
+func printPlan(ctrl *control.SecurityControl, tests []validate.ControlTest, registry *validate.Registry, format report.Format) {
+	if format != report.FormatText {
+		r := report.New("test-plan", "Security Control Test Plan")
+		validate.AddPlan(r, tests)
+		render(r, format)
+		return
+	}
+
+	if ctrl != nil {
+		fmt.Printf("Control: %s - %s\n", ctrl.ID, ctrl.Name)
+	}
+	fmt.Printf("Dry run: %d test(s) would be executed\n", len(tests))
+	for _, test := range tests {
+		fmt.Printf("\nTest: %s - %s\n", test.ID, test.Name)
+		fmt.Printf("  Method: %s\n", test.Method)
+		fmt.Printf("  Executor: %s\n", describeExecutor(registry, test))
+		for i, step := range test.Steps {
+			fmt.Printf("  Step %d: %s\n", i+1, step)
+		}
+		if cmd := test.Command; cmd != nil {
+			fmt.Printf("  Command: %s\n", cmd)
+			if cmd.Dir != "" {
+				fmt.Printf("  Directory: %s\n", cmd.Dir)
+			}
+			if names := cmd.EnvNames(); len(names) > 0 {
+				fmt.Printf("  Environment: %s (values not shown)\n", strings.Join(names, ", "))
+			}
+			timeout := cmd.Timeout
+			if timeout <= 0 {
+				timeout = validate.DefaultCommandTimeout
+			}
+			fmt.Printf("  Timeout: %s\n", timeout)
+			fmt.Printf("  Expect exit code: %d\n", cmd.ExpectExitCode)
+			if cmd.ExpectStdout != "" {
+				fmt.Printf("  Expect stdout: %s\n", cmd.ExpectStdout)
+			}
+			for _, a := range cmd.ExpectJSON {
+				switch {
+				case a.Equals != "":
+					fmt.Printf("  Expect JSON: %s == %s\n", a.Path, a.Equals)
+				case a.Matches != "":
+					fmt.Printf("  Expect JSON: %s =~ %s\n", a.Path, a.Matches)
+				default:
+					fmt.Printf("  Expect JSON: %s exists\n", a.Path)
+				}
+			}
+		}
+		if test.ExpectedResult != "" {
+			fmt.Printf("  Expected: %s\n", test.ExpectedResult)
+		}
+	}
+}
+
+// describeExecutor describes how a test would be executed.
+func describeExecutor(registry *validate.Registry, test validate.ControlTest) string {
+	executor, ok := registry.Lookup(test)
+	if !ok {
+		return "none registered"
+	}
+	switch executor.(type) {
+	case *validate.ShellExecutor:
+		if test.Command == nil {
+			return "recorded result (no command)"
+		}
+		return "shell command"
+	case *validate.InteractiveExecutor:
+		return "interactive prompts"
+	case validate.ManualExecutor:
+		if test.TestedAt.IsZero() {
+			return "recorded result (none recorded)"
+		}
+		return "recorded result from " + test.TestedAt.Format("2006-01-02")
+	}
+	return fmt.Sprintf("%T", executor)
+}
+
+// printProgress returns a progress function writing test progress to w.
+func printProgress(w io.Writer) validate.ProgressFunc {
+	var mu sync.Mutex
+	return func(event validate.ProgressEvent) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch event.Kind {
+		case validate.ProgressStart:
+			fmt.Fprintf(w, "Running %s: %s\n", event.TestID, event.Message)
+		case validate.ProgressStep:
+			fmt.Fprintf(w, "  [%d/%d] %s\n", event.Step, event.Steps, event.Message)
+		case validate.ProgressFinish:
+			fmt.Fprintf(w, "  %s in %s\n", event.Outcome.Result(), event.Outcome.Duration.Round(time.Millisecond))
+		}
+	}
+}
+
+// printTestResults prints test results and the resulting control status.
+func printTestResults(results []validate.ValidationResult, controls []control.ControlValidationResult) {
+	for _, result := range results {
+		fmt.Printf("\n[%s] %s - %s\n", result.ValidationResult, result.TestID, result.ControlName)
+		if result.ActualResult != "" {
+			fmt.Printf("    Actual: %s\n", result.ActualResult)
+		}
+		if result.Error != "" {
+			fmt.Printf("    Error: %s\n", result.Error)
+		}
+		if result.Attempts > 1 {
+			fmt.Printf("    Attempts: %d\n", result.Attempts)
+		}
+		for _, e := range result.Evidence {
+			fmt.Printf("    Evidence: %s sha256:%s\n", e.Name, e.SHA256)
+		}
+		for _, rec := range result.Recommendations {
+			fmt.Printf("    • %s\n", rec)
+		}
+	}
+
+	for _, result := range controls {
+		fmt.Printf("\nControl: [%s] %s\n", result.Status, result.ControlName)
+		fmt.Printf("    Effectiveness: %.1f%%\n", result.Effectiveness*100)
+		fmt.Printf("    Confidence: %.1f%%\n", result.Confidence*100)
+		if !result.ProjectedEffectiveUntil.IsZero() {
+			fmt.Printf("    Projected effective until: %s\n", effectiveUntil(result))
+		}
+		fmt.Printf("    Tests: %d/%d passed\n", result.TestsPassed, result.TestsLinked)
+		for _, issue := range result.Issues {
+			fmt.Printf("    Issue: [%s] %s\n", issue.Severity, issue)
+		}
+	}
+}
+
+// effectiveUntil formats the date a control is projected to stop being
+// effective, marking dates that have passed.
+func effectiveUntil(result control.ControlValidationResult) string {
+	date := result.ProjectedEffectiveUntil.Format("2006-01-02")
+	if result.ProjectedEffectiveUntil.Before(result.ValidatedAt) {
+		date += " (lapsed, re-verify)"
+	}
+	return date
+}
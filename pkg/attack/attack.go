@@ -0,0 +1,133 @@
+// Package attack maps security controls to MITRE ATT&CK techniques and
+// measures how well the techniques are covered. An offline subset of the
+// ATT&CK Enterprise matrix is bundled.
+package attack
+
+import (
+	_ "embed"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed enterprise.yaml
+var enterpriseYAML []byte
+
+// Tactic is an ATT&CK tactic, the adversary's goal.
+type Tactic struct {
+	ID        string `yaml:"id"`
+	ShortName string `yaml:"shortName"`
+	Name      string `yaml:"name"`
+}
+
+// Technique is an ATT&CK technique or sub-technique. Sub-techniques have
+// the tactics of their parent.
+type Technique struct {
+	ID      string   `yaml:"id"`
+	Name    string   `yaml:"name"`
+	Tactics []string `yaml:"tactics,omitempty"`
+}
+
+// Parent returns the ID of a sub-technique's parent technique, or "" for a
+// technique.
+func (t Technique) Parent() string {
+	if i := strings.IndexByte(t.ID, '.'); i >= 0 {
+		return t.ID[:i]
+	}
+	return ""
+}
+
+// Matrix is a set of tactics and the techniques under them.
+type Matrix struct {
+	Version    string      `yaml:"version"`
+	Tactics    []Tactic    `yaml:"tactics"`
+	Techniques []Technique `yaml:"techniques"`
+
+	index map[string]int
+}
+
+var techniqueID = regexp.MustCompile(`^T\d{4}(\.\d{3})?$`)
+
+// enterprise is the bundled matrix.
+var enterprise *Matrix
+
+func init() {
+	m, err := ParseMatrix(enterpriseYAML)
+	if err != nil {
+		panic(fmt.Sprintf("attack: invalid enterprise.yaml: %v", err))
+	}
+	enterprise = m
+}
+
+// Enterprise returns the bundled ATT&CK Enterprise matrix.
+func Enterprise() *Matrix {
+	return enterprise
+}
+
+// ParseMatrix parses a matrix from YAML. Sub-techniques without tactics get
+// those of their parent.
+func ParseMatrix(data []byte) (*Matrix, error) {
+	var m Matrix
+	if err := yaml.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+
+	tactics := make(map[string]bool, len(m.Tactics))
+	for _, t := range m.Tactics {
+		tactics[t.ShortName] = true
+	}
+	m.index = make(map[string]int, len(m.Techniques))
+	for i, t := range m.Techniques {
+		if !techniqueID.MatchString(t.ID) {
+			return nil, fmt.Errorf("invalid technique ID %q", t.ID)
+		}
+		if _, ok := m.index[t.ID]; ok {
+			return nil, fmt.Errorf("duplicate technique %s", t.ID)
+		}
+		if parent := t.Parent(); parent != "" && len(t.Tactics) == 0 {
+			p, ok := m.index[parent]
+			if !ok {
+				return nil, fmt.Errorf("sub-technique %s listed before its parent", t.ID)
+			}
+			m.Techniques[i].Tactics = m.Techniques[p].Tactics
+		}
+		for _, tactic := range m.Techniques[i].Tactics {
+			if !tactics[tactic] {
+				return nil, fmt.Errorf("technique %s has unknown tactic %q", t.ID, tactic)
+			}
+		}
+		m.index[t.ID] = i
+	}
+	return &m, nil
+}
+
+// Technique returns the technique with the given ID. Sub-techniques that
+// are not listed are known by their parent's name and tactics.
+func (m *Matrix) Technique(id string) (Technique, bool) {
+	if i, ok := m.index[id]; ok {
+		return m.Techniques[i], true
+	}
+	if !techniqueID.MatchString(id) {
+		return Technique{}, false
+	}
+	t := Technique{ID: id}
+	parent, ok := m.index[t.Parent()]
+	if t.Parent() == "" || !ok {
+		return Technique{}, false
+	}
+	t.Name = m.Techniques[parent].Name
+	t.Tactics = m.Techniques[parent].Tactics
+	return t, true
+}
+
+// Validate checks that technique IDs are in the matrix.
+func (m *Matrix) Validate(ids []string) error {
+	for _, id := range ids {
+		if _, ok := m.Technique(id); !ok {
+			return fmt.Errorf("unknown ATT&CK technique %q", id)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package attack
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+func TestEnterprise(t *testing.T) {
+	m := Enterprise()
+	if len(m.Tactics) != 14 {
+		t.Errorf("tactics = %d, want 14", len(m.Tactics))
+	}
+
+	tech, ok := m.Technique("T1078")
+	if !ok || tech.Name != "Valid Accounts" || len(tech.Tactics) != 4 {
+		t.Errorf("T1078 = %+v, %v", tech, ok)
+	}
+	// Listed sub-techniques inherit their parent's tactics
+	if sub, ok := m.Technique("T1110.003"); !ok || sub.Name != "Password Spraying" || sub.Tactics[0] != "credential-access" {
+		t.Errorf("T1110.003 = %+v, %v", sub, ok)
+	}
+	// Unlisted sub-techniques of a known technique are accepted
+	if sub, ok := m.Technique("T1003.006"); !ok || sub.Parent() != "T1003" {
+		t.Errorf("T1003.006 = %+v, %v", sub, ok)
+	}
+
+	if err := m.Validate([]string{"T1078", "T1566.001"}); err != nil {
+		t.Error(err)
+	}
+	for _, id := range []string{"T9999", "T9999.001", "1078", "t1078"} {
+		if err := m.Validate([]string{id}); err == nil {
+			t.Errorf("%s: expected error", id)
+		}
+	}
+
+	if _, err := ParseMatrix([]byte("tactics: [{shortName: a}]\ntechniques: [{id: T0001, tactics: [b]}]")); err == nil {
+		t.Error("expected error for unknown tactic")
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	controls := []control.SecurityControl{
+		{ID: "mfa", Techniques: []string{"T1110.003", "T1621"}},
+		{ID: "siem", Techniques: []string{"T1110"}},
+		{ID: "untested", Techniques: []string{"T1486"}},
+	}
+	results := []control.ControlValidationResult{
+		{ControlID: "mfa", Effectiveness: 0.5},
+		{ControlID: "siem", Effectiveness: 0.5},
+	}
+	cov := Enterprise().Coverage(controls, results)
+
+	scores := make(map[string]TechniqueCoverage)
+	for _, tc := range cov.Techniques {
+		scores[tc.Technique.ID] = tc
+	}
+	// Both controls count toward the parent and the sub-technique
+	for _, id := range []string{"T1110", "T1110.003"} {
+		if tc := scores[id]; math.Abs(tc.Score-0.75) > 1e-9 || len(tc.Controls) != 2 {
+			t.Errorf("%s = %+v", id, tc)
+		}
+	}
+	if tc := scores["T1486"]; tc.Covered() || len(tc.Controls) != 1 {
+		t.Errorf("untested control covers T1486: %+v", tc)
+	}
+	if _, ok := scores["T1110.001"]; ok {
+		t.Error("unmapped sub-technique listed")
+	}
+
+	for _, tactic := range cov.Tactics {
+		if tactic.Tactic.ShortName != "credential-access" {
+			continue
+		}
+		if tactic.Covered != 2 || math.Abs(tactic.Score-1.25/float64(len(tactic.Techniques))) > 1e-9 {
+			t.Errorf("credential access = %d covered, %v", tactic.Covered, tactic.Score)
+		}
+	}
+	// T1110.003 is listed after T1110; T1110 and T1621 are covered
+	if got := len(cov.Uncovered()); got != len(cov.Techniques)-1-2 {
+		t.Errorf("uncovered = %d", got)
+	}
+
+	var layer Layer
+	var buf bytes.Buffer
+	if err := cov.WriteNavigatorLayer(&buf, "Test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(buf.Bytes(), &layer); err != nil {
+		t.Fatal(err)
+	}
+	if layer.Domain != "enterprise-attack" || len(layer.Techniques) != len(cov.Techniques) {
+		t.Errorf("layer = %+v", layer)
+	}
+	for _, lt := range layer.Techniques {
+		if lt.TechniqueID == "T1621" && (lt.Score != 50 || lt.Comment != "Controls: mfa") {
+			t.Errorf("T1621 = %+v", lt)
+		}
+	}
+
+	buf.Reset()
+	if err := cov.WriteHeatMap(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if text := buf.String(); !strings.Contains(text, "T1110.003") || !strings.Contains(text, "Impact: T1531") {
+		t.Errorf("heat map:\n%s", text)
+	}
+
+	buf.Reset()
+	if err := cov.WriteHTMLHeatMap(&buf, "Coverage <Test>"); err != nil {
+		t.Fatal(err)
+	}
+	if html := buf.String(); !strings.Contains(html, "Coverage &lt;Test&gt;") || !strings.Contains(html, "background: #ff6666") {
+		t.Error("HTML heat map missing title or uncovered cells")
+	}
+}
@@ -0,0 +1,180 @@
+package attack
+
+import (
+	"sort"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// TechniqueCoverage is how well a technique is covered by the controls
+// mapped to it.
+type TechniqueCoverage struct {
+	Technique Technique
+	Controls  []string
+	// Score combines the validated effectiveness of the controls as
+	// independent layers of defense: 1 − Π (1 − effectiveness).
+	Score float64
+}
+
+// Covered reports whether an effective control covers the technique.
+func (t TechniqueCoverage) Covered() bool {
+	return t.Score > 0
+}
+
+// TacticCoverage is the coverage of the techniques under a tactic.
+type TacticCoverage struct {
+	Tactic     Tactic
+	Techniques []TechniqueCoverage
+	Covered    int
+	// Score is the mean score of the tactic's techniques.
+	Score float64
+}
+
+// Coverage is the coverage of a matrix by a set of controls.
+type Coverage struct {
+	Version string
+	Tactics []TacticCoverage
+	// Techniques lists every technique of the matrix in order, each
+	// followed by its sub-techniques that controls are mapped to.
+	Techniques []TechniqueCoverage
+}
+
+// Score returns the mean score of the matrix's techniques.
+func (c *Coverage) Score() float64 {
+	total, n := 0.0, 0
+	for _, t := range c.Techniques {
+		if t.Technique.Parent() == "" {
+			total += t.Score
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
+
+// Uncovered returns the techniques, not counting sub-techniques, that no
+// effective control covers.
+func (c *Coverage) Uncovered() []TechniqueCoverage {
+	var uncovered []TechniqueCoverage
+	for _, t := range c.Techniques {
+		if t.Technique.Parent() == "" && !t.Covered() {
+			uncovered = append(uncovered, t)
+		}
+	}
+	return uncovered
+}
+
+// Coverage computes how well controls cover the matrix, weighting each
+// control by its validated effectiveness. Controls without a validation
+// result count as mapped but not effective. A control mapped to a technique
+// covers its sub-techniques, and one mapped to a sub-technique counts
+// toward its parent.
+func (m *Matrix) Coverage(controls []control.SecurityControl, results []control.ControlValidationResult) *Coverage {
+	effectiveness := make(map[string]float64, len(results))
+	for _, r := range results {
+		effectiveness[r.ControlID] = r.Effectiveness
+	}
+
+	mapped := make(map[string][]string)
+	subs := make(map[string][]string)
+	for _, c := range controls {
+		for _, id := range c.Techniques {
+			if _, ok := m.Technique(id); !ok {
+				continue
+			}
+			if len(mapped[id]) == 0 && id != parentOf(id) {
+				subs[parentOf(id)] = append(subs[parentOf(id)], id)
+			}
+			mapped[id] = appendUnique(mapped[id], c.ID)
+		}
+	}
+
+	cover := func(t Technique, ids ...string) TechniqueCoverage {
+		tc := TechniqueCoverage{Technique: t}
+		for _, id := range ids {
+			for _, c := range mapped[id] {
+				tc.Controls = appendUnique(tc.Controls, c)
+			}
+		}
+		remaining := 1.0
+		for _, c := range tc.Controls {
+			remaining *= 1 - clamp(effectiveness[c])
+		}
+		tc.Score = 1 - remaining
+		return tc
+	}
+
+	cov := &Coverage{Version: m.Version}
+	parents := make(map[string]TechniqueCoverage)
+	for _, t := range m.Techniques {
+		if t.Parent() != "" {
+			continue
+		}
+		sort.Strings(subs[t.ID])
+		tc := cover(t, append([]string{t.ID}, subs[t.ID]...)...)
+		parents[t.ID] = tc
+		cov.Techniques = append(cov.Techniques, tc)
+		for _, id := range subs[t.ID] {
+			sub, _ := m.Technique(id)
+			cov.Techniques = append(cov.Techniques, cover(sub, t.ID, id))
+		}
+	}
+
+	for _, tactic := range m.Tactics {
+		tc := TacticCoverage{Tactic: tactic}
+		for _, t := range m.Techniques {
+			if t.Parent() != "" || !contains(t.Tactics, tactic.ShortName) {
+				continue
+			}
+			coverage := parents[t.ID]
+			tc.Techniques = append(tc.Techniques, coverage)
+			tc.Score += coverage.Score
+			if coverage.Covered() {
+				tc.Covered++
+			}
+		}
+		if len(tc.Techniques) > 0 {
+			tc.Score /= float64(len(tc.Techniques))
+		}
+		cov.Tactics = append(cov.Tactics, tc)
+	}
+	return cov
+}
+
+// parentOf returns the parent technique of a sub-technique ID, or the ID
+// itself.
+func parentOf(id string) string {
+	if parent := (Technique{ID: id}).Parent(); parent != "" {
+		return parent
+	}
+	return id
+}
+
+func appendUnique(list []string, s string) []string {
+	if contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// clamp limits a share to [0, 1].
+func clamp(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
@@ -0,0 +1,156 @@
+package attack
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// heatSymbol returns the text heat map symbol of a coverage score.
+func heatSymbol(score float64) string {
+	switch {
+	case score >= 0.9:
+		return "█"
+	case score >= 0.6:
+		return "▓"
+	case score >= 0.3:
+		return "▒"
+	case score > 0:
+		return "░"
+	default:
+		return "·"
+	}
+}
+
+// WriteHeatMap writes a text heat map: a row of cells per tactic, one per
+// technique, followed by the covered techniques and the uncovered ones.
+func (c *Coverage) WriteHeatMap(w io.Writer) error {
+	var sb strings.Builder
+	title := fmt.Sprintf("ATT&CK Coverage (Enterprise v%s)", c.Version)
+	sb.WriteString(title + "\n" + strings.Repeat("=", len(title)) + "\n")
+	fmt.Fprintf(&sb, "Overall: %.1f%%, %d technique(s) uncovered\n\n", c.Score()*100, len(c.Uncovered()))
+
+	width := 0
+	for _, t := range c.Tactics {
+		if len(t.Tactic.Name) > width {
+			width = len(t.Tactic.Name)
+		}
+	}
+	for _, t := range c.Tactics {
+		cells := make([]string, len(t.Techniques))
+		for i, tc := range t.Techniques {
+			cells[i] = heatSymbol(tc.Score)
+		}
+		fmt.Fprintf(&sb, "%-*s  %5.1f%%  %2d/%-2d  %s\n", width, t.Tactic.Name, t.Score*100, t.Covered, len(t.Techniques), strings.Join(cells, ""))
+	}
+	sb.WriteString("\nLegend: █ ≥90%  ▓ ≥60%  ▒ ≥30%  ░ >0%  · uncovered\n")
+
+	sb.WriteString("\nCovered techniques:\n")
+	var covered []TechniqueCoverage
+	width = 0
+	for _, t := range c.Techniques {
+		if t.Covered() {
+			covered = append(covered, t)
+			if len(t.Technique.Name) > width {
+				width = len(t.Technique.Name)
+			}
+		}
+	}
+	for _, t := range covered {
+		fmt.Fprintf(&sb, "  %s %-10s %-*s  %5.1f%%  %s\n", heatSymbol(t.Score), t.Technique.ID, width, t.Technique.Name, t.Score*100, strings.Join(t.Controls, ", "))
+	}
+	if len(covered) == 0 {
+		sb.WriteString("  None\n")
+	}
+
+	sb.WriteString("\nUncovered techniques:\n")
+	for _, t := range c.Tactics {
+		var ids []string
+		for _, tc := range t.Techniques {
+			if !tc.Covered() {
+				ids = append(ids, tc.Technique.ID)
+			}
+		}
+		if len(ids) > 0 {
+			fmt.Fprintf(&sb, "  %s: %s\n", t.Tactic.Name, strings.Join(ids, ", "))
+		}
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+var heatMapTemplate = template.Must(template.New("heatmap").Funcs(template.FuncMap{
+	"color":   heatColor,
+	"percent": func(x float64) string { return strconv.FormatFloat(x*100, 'f', 1, 64) + "%" },
+	"join":    strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; margin: 1.5em; }
+table { border-collapse: collapse; table-layout: fixed; }
+th, td { border: 1px solid #ccc; padding: 4px; vertical-align: top; font-size: 11px; width: 110px; }
+th { background: #ddd; }
+td.technique { height: 3em; }
+.legend span { display: inline-block; padding: 2px 8px; margin-right: 4px; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>Overall coverage {{percent .Coverage.Score}}, weighted by validated control effectiveness. {{len .Coverage.Uncovered}} technique(s) uncovered.</p>
+<p class="legend"><span style="background: ` + colorUncovered + `">Uncovered</span><span style="background: ` + colorPartial + `">Partially covered</span><span style="background: ` + colorCovered + `">Covered</span></p>
+<table>
+<tr>{{range .Coverage.Tactics}}<th>{{.Tactic.Name}}<br>{{.Covered}}/{{len .Techniques}} · {{percent .Score}}</th>{{end}}</tr>
+{{range $row := .Rows}}<tr>{{range $row}}{{if .}}<td class="technique" style="background: {{color .Score}}" title="{{.Technique.ID}} {{.Technique.Name}}: {{percent .Score}}{{if .Controls}} ({{join .Controls ", "}}){{end}}">{{.Technique.ID}}<br>{{.Technique.Name}}</td>{{else}}<td></td>{{end}}{{end}}</tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// WriteHTMLHeatMap writes the coverage as an HTML page with a column of
+// technique cells per tactic, colored from uncovered to covered.
+func (c *Coverage) WriteHTMLHeatMap(w io.Writer, title string) error {
+	rows := 0
+	for _, t := range c.Tactics {
+		if len(t.Techniques) > rows {
+			rows = len(t.Techniques)
+		}
+	}
+	grid := make([][]*TechniqueCoverage, rows)
+	for i := range grid {
+		grid[i] = make([]*TechniqueCoverage, len(c.Tactics))
+		for j := range c.Tactics {
+			if i < len(c.Tactics[j].Techniques) {
+				grid[i][j] = &c.Tactics[j].Techniques[i]
+			}
+		}
+	}
+
+	return heatMapTemplate.Execute(w, struct {
+		Title    string
+		Coverage *Coverage
+		Rows     [][]*TechniqueCoverage
+	}{title, c, grid})
+}
+
+// heatColor returns the CSS color of a coverage score on the gradient from
+// colorUncovered through colorPartial to colorCovered.
+func heatColor(score float64) template.CSS {
+	from, to, t := colorUncovered, colorPartial, clamp(score)*2
+	if t > 1 {
+		from, to, t = colorPartial, colorCovered, t-1
+	}
+	var rgb [3]int64
+	for i := range rgb {
+		a, _ := strconv.ParseInt(from[1+2*i:3+2*i], 16, 0)
+		b, _ := strconv.ParseInt(to[1+2*i:3+2*i], 16, 0)
+		rgb[i] = a + int64(math.Round(float64(b-a)*t))
+	}
+	return template.CSS(fmt.Sprintf("#%02x%02x%02x", rgb[0], rgb[1], rgb[2]))
+}
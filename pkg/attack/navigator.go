@@ -0,0 +1,117 @@
+package attack
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+// Layer is an ATT&CK Navigator layer.
+type Layer struct {
+	Name        string           `json:"name"`
+	Versions    LayerVersions    `json:"versions"`
+	Domain      string           `json:"domain"`
+	Description string           `json:"description,omitempty"`
+	Techniques  []LayerTechnique `json:"techniques"`
+	Gradient    LayerGradient    `json:"gradient"`
+	LegendItems []LegendItem     `json:"legendItems"`
+
+	ShowTacticRowBackground bool   `json:"showTacticRowBackground"`
+	TacticRowBackground     string `json:"tacticRowBackground"`
+}
+
+// LayerVersions are the ATT&CK, Navigator and layer format versions of a
+// layer.
+type LayerVersions struct {
+	Attack    string `json:"attack"`
+	Navigator string `json:"navigator"`
+	Layer     string `json:"layer"`
+}
+
+// LayerTechnique is the score of a technique in a layer.
+type LayerTechnique struct {
+	TechniqueID string          `json:"techniqueID"`
+	Score       int             `json:"score"`
+	Comment     string          `json:"comment,omitempty"`
+	Enabled     bool            `json:"enabled"`
+	Metadata    []LayerMetadata `json:"metadata,omitempty"`
+}
+
+// LayerMetadata is a name and value shown with a technique.
+type LayerMetadata struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// LayerGradient maps scores to colors.
+type LayerGradient struct {
+	Colors   []string `json:"colors"`
+	MinValue int      `json:"minValue"`
+	MaxValue int      `json:"maxValue"`
+}
+
+// LegendItem is an entry of a layer's legend.
+type LegendItem struct {
+	Label string `json:"label"`
+	Color string `json:"color"`
+}
+
+// Heat map colors, from uncovered to fully covered.
+const (
+	colorUncovered = "#ff6666"
+	colorPartial   = "#ffe766"
+	colorCovered   = "#8ec843"
+)
+
+// NavigatorLayer returns an ATT&CK Navigator layer scoring every technique
+// from 0 (uncovered) to 100 (covered by fully effective controls).
+func (c *Coverage) NavigatorLayer(name string) *Layer {
+	layer := &Layer{
+		Name:        name,
+		Versions:    LayerVersions{Attack: c.Version, Navigator: "4.9.1", Layer: "4.5"},
+		Domain:      "enterprise-attack",
+		Description: fmt.Sprintf("Control coverage weighted by validated effectiveness (%.0f%% overall)", c.Score()*100),
+		Techniques:  make([]LayerTechnique, 0, len(c.Techniques)),
+		Gradient: LayerGradient{
+			Colors:   []string{colorUncovered, colorPartial, colorCovered},
+			MinValue: 0,
+			MaxValue: 100,
+		},
+		LegendItems: []LegendItem{
+			{Label: "Uncovered", Color: colorUncovered},
+			{Label: "Partially covered", Color: colorPartial},
+			{Label: "Covered", Color: colorCovered},
+		},
+		ShowTacticRowBackground: true,
+		TacticRowBackground:     "#dddddd",
+	}
+	for _, t := range c.Techniques {
+		lt := LayerTechnique{
+			TechniqueID: t.Technique.ID,
+			Score:       percent(t.Score),
+			Enabled:     true,
+		}
+		if len(t.Controls) > 0 {
+			lt.Comment = "Controls: " + strings.Join(t.Controls, ", ")
+			for _, id := range t.Controls {
+				lt.Metadata = append(lt.Metadata, LayerMetadata{Name: "control", Value: id})
+			}
+		}
+		layer.Techniques = append(layer.Techniques, lt)
+	}
+	return layer
+}
+
+// WriteNavigatorLayer writes the coverage as an ATT&CK Navigator layer.
+func (c *Coverage) WriteNavigatorLayer(w io.Writer, name string) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(c.NavigatorLayer(name))
+}
+
+// percent converts a share to a whole percentage.
+func percent(x float64) int {
+	return int(math.Round(x * 100))
+}
@@ -0,0 +1,43 @@
+package attack
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// TacticColumns are the columns of a report section of tactic coverage.
+var TacticColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "tactic", Title: "Tactic", Kind: report.KindString},
+	{Key: "techniques", Title: "Techniques", Kind: report.KindNumber},
+	{Key: "covered", Title: "Covered", Kind: report.KindNumber},
+	{Key: "score", Title: "Score", Kind: report.KindPercent},
+}
+
+// TechniqueColumns are the columns of a report section of technique
+// coverage.
+var TechniqueColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "technique", Title: "Technique", Kind: report.KindString},
+	{Key: "tactics", Title: "Tactics", Kind: report.KindList},
+	{Key: "controls", Title: "Controls", Kind: report.KindList},
+	{Key: "score", Title: "Score", Kind: report.KindPercent},
+	{Key: "covered", Title: "Covered", Kind: report.KindBool},
+}
+
+// AddCoverage adds the overall coverage to a report's summary and the
+// "tactics" and "techniques" sections.
+func AddCoverage(r *report.Report, c *Coverage) {
+	r.AddSummary("attackVersion", c.Version)
+	r.AddSummaryKind("score", c.Score(), report.KindPercent)
+	r.AddSummary("uncovered", len(c.Uncovered()))
+
+	tactics := r.AddSection("tactics", "Coverage by Tactic", TacticColumns...)
+	for _, t := range c.Tactics {
+		tactics.AddRow(t.Tactic.ID, t.Tactic.Name, len(t.Techniques), t.Covered, t.Score)
+	}
+
+	techniques := r.AddSection("techniques", "Coverage by Technique", TechniqueColumns...)
+	for _, t := range c.Techniques {
+		techniques.AddRow(t.Technique.ID, t.Technique.Name, t.Technique.Tactics, t.Controls, t.Score, t.Covered())
+	}
+}
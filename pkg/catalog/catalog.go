@@ -0,0 +1,896 @@
+// Package catalog loads security control catalogs from YAML files.
+package catalog
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hallucinaut/securitycontrol/pkg/attack"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/risk"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// Catalog represents a set of controls loaded from one or more files.
+type Catalog struct {
+	Framework control.ControlFramework
+	Controls  []control.SecurityControl
+	Tests     []validate.ControlTest
+	Risks     []risk.RiskScenario
+	Files     []string
+
+	controlLocations map[string]Location
+	testLocations    map[string]Location
+	riskLocations    map[string]Location
+	fileDigests      []string
+}
+
+// Loader loads control catalogs.
+type Loader struct {
+	now time.Time
+}
+
+// frameworkSpec is the YAML form of a control framework.
+type frameworkSpec struct {
+	Name        string `yaml:"name"`
+	Version     string `yaml:"version,omitempty"`
+	Description string `yaml:"description,omitempty"`
+	LastUpdated string `yaml:"lastUpdated,omitempty"`
+}
+
+// controlSpec is the YAML form of a security control.
+type controlSpec struct {
+	ID             string   `yaml:"id"`
+	Name           string   `yaml:"name"`
+	Description    string   `yaml:"description,omitempty"`
+	Category       string   `yaml:"category,omitempty"`
+	Type           string   `yaml:"type,omitempty"`
+	SubCategory    string   `yaml:"subCategory,omitempty"`
+	RiskReduction  float64  `yaml:"riskReduction,omitempty"`
+	Implementation string   `yaml:"implementation,omitempty"`
+	Verification   string   `yaml:"verification,omitempty"`
+	Maintenance    string   `yaml:"maintenance,omitempty"`
+	Owner          string   `yaml:"owner,omitempty"`
+	Status         string   `yaml:"status,omitempty"`
+	LastVerified   string   `yaml:"lastVerified,omitempty"`
+	NextReview     string   `yaml:"nextReview,omitempty"`
+	Evidence       []string `yaml:"evidence,omitempty"`
+	References     []string `yaml:"references,omitempty"`
+	Tests          []string `yaml:"tests,omitempty"`
+	Techniques     []string `yaml:"techniques,omitempty"`
+	DependsOn      []string `yaml:"dependsOn,omitempty"`
+}
+
+// testSpec is the YAML form of a control test.
+type testSpec struct {
+	ID             string       `yaml:"id"`
+	Name           string       `yaml:"name"`
+	Description    string       `yaml:"description,omitempty"`
+	Method         string       `yaml:"method"`
+	Kind           string       `yaml:"kind,omitempty"`
+	Command        *commandSpec `yaml:"command,omitempty"`
+	Steps          []string     `yaml:"steps,omitempty"`
+	ExpectedResult string       `yaml:"expectedResult,omitempty"`
+	ActualResult   string       `yaml:"actualResult,omitempty"`
+	Passed         bool         `yaml:"passed,omitempty"`
+	Notes          string       `yaml:"notes,omitempty"`
+	TestedAt       string       `yaml:"testedAt,omitempty"`
+	TestedBy       string       `yaml:"testedBy,omitempty"`
+}
+
+// commandSpec is the YAML form of an automated test command.
+type commandSpec struct {
+	Run            string              `yaml:"run"`
+	Args           []string            `yaml:"args,omitempty"`
+	Env            map[string]string   `yaml:"env,omitempty"`
+	Dir            string              `yaml:"dir,omitempty"`
+	Timeout        string              `yaml:"timeout,omitempty"`
+	ExpectExitCode int                 `yaml:"expectExitCode,omitempty"`
+	ExpectStdout   string              `yaml:"expectStdout,omitempty"`
+	ExpectJSON     []jsonAssertionSpec `yaml:"expectJSON,omitempty"`
+}
+
+// jsonAssertionSpec is the YAML form of a JSON output assertion.
+type jsonAssertionSpec struct {
+	Path    string `yaml:"path"`
+	Equals  string `yaml:"equals,omitempty"`
+	Matches string `yaml:"matches,omitempty"`
+}
+
+// documentSpec is the YAML form of a catalog file.
+type documentSpec struct {
+	Framework *frameworkSpec `yaml:"framework,omitempty"`
+	Controls  []controlSpec  `yaml:"controls"`
+}
+
+// documentKeys lists the keys allowed at the top level of a catalog file.
+var documentKeys = map[string]bool{
+	"framework": true,
+	"controls":  true,
+	"tests":     true,
+	"risks":     true,
+}
+
+var (
+	frameworkKeys = specKeys(frameworkSpec{})
+	controlKeys   = specKeys(controlSpec{})
+	testKeys      = specKeys(testSpec{})
+	commandKeys   = specKeys(commandSpec{})
+	assertionKeys = specKeys(jsonAssertionSpec{})
+	riskKeys      = specKeys(risk.RiskScenario{})
+	estimateKeys  = specKeys(risk.Estimate{})
+)
+
+var categories = map[control.ControlCategory]bool{
+	control.CategoryPreventive: true,
+	control.CategoryDetective:  true,
+	control.CategoryCorrective: true,
+	control.CategoryDeterrent:  true,
+	control.CategoryRecovery:   true,
+}
+
+var types = map[control.ControlType]bool{
+	control.TypeTechnical:      true,
+	control.TypeAdministrative: true,
+	control.TypePhysical:       true,
+}
+
+var statuses = map[control.ControlStatus]bool{
+	control.StatusImplemented:          true,
+	control.StatusPartiallyImplemented: true,
+	control.StatusNotImplemented:       true,
+	control.StatusDeprecated:           true,
+}
+
+var methods = map[validate.ValidationMethod]bool{
+	validate.MethodDocumentation: true,
+	validate.MethodInterview:     true,
+	validate.MethodObservation:   true,
+	validate.MethodTesting:       true,
+	validate.MethodAutomation:    true,
+}
+
+// Location records where a control, test or risk scenario was defined.
+type Location struct {
+	File string
+	Line int
+}
+
+// loadState carries state across the files of a single load.
+type loadState struct {
+	catalog   *Catalog
+	seen      map[string]Location
+	seenTests map[string]Location
+	seenRisks map[string]Location
+	riskNodes map[string]linkNode
+	depNodes  map[string]linkNode
+	framework string
+	errs      ErrorList
+}
+
+// linkNode is where the links of a risk scenario or control to other
+// controls were defined.
+type linkNode struct {
+	file string
+	node *yaml.Node
+}
+
+// NewLoader creates a new catalog loader.
+func NewLoader() *Loader {
+	return &Loader{now: time.Now()}
+}
+
+// SetNow sets the reference time used to resolve relative dates.
+func (l *Loader) SetNow(now time.Time) {
+	l.now = now
+}
+
+// Load loads catalogs from files and directories using a default loader.
+func Load(paths ...string) (*Catalog, error) {
+	return NewLoader().Load(paths...)
+}
+
+// Load loads catalogs from files and directories. Directories are walked
+// recursively for .yaml and .yml files. All errors are collected and
+// returned together as an ErrorList.
+func (l *Loader) Load(paths ...string) (*Catalog, error) {
+	files, err := expandPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	st := newLoadState()
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			st.errs = append(st.errs, &Error{File: file, Msg: err.Error()})
+			continue
+		}
+		l.parse(file, data, st)
+	}
+	st.checkRisks()
+	st.checkDependencies()
+
+	if err := st.errs.err(); err != nil {
+		return nil, err
+	}
+	return st.finish(), nil
+}
+
+// Files returns the catalog files of files and directories, in the order
+// Load reads them.
+func Files(paths ...string) ([]string, error) {
+	return expandPaths(paths)
+}
+
+// Parse parses a single catalog document. The name is used in error
+// messages only.
+func (l *Loader) Parse(name string, data []byte) (*Catalog, error) {
+	st := newLoadState()
+	l.parse(name, data, st)
+	st.checkRisks()
+	st.checkDependencies()
+	if err := st.errs.err(); err != nil {
+		return nil, err
+	}
+	return st.finish(), nil
+}
+
+// FromControls builds a catalog from in-memory controls and tests.
+func FromControls(controls []control.SecurityControl, tests []validate.ControlTest) *Catalog {
+	return &Catalog{
+		Framework: control.ControlFramework{Controls: controls},
+		Controls:  controls,
+		Tests:     tests,
+	}
+}
+
+// Default returns the built-in common controls and tests.
+func Default() *Catalog {
+	return FromControls(control.CreateCommonControls(), validate.CreateCommonControlTests())
+}
+
+// ControlLocation returns where the control with the given ID was defined.
+// Controls that were not loaded from a file have no location.
+func (c *Catalog) ControlLocation(id string) (Location, bool) {
+	loc, ok := c.controlLocations[id]
+	return loc, ok
+}
+
+// TestLocation returns where the test with the given ID was defined.
+func (c *Catalog) TestLocation(id string) (Location, bool) {
+	loc, ok := c.testLocations[id]
+	return loc, ok
+}
+
+// Hash returns a SHA-256 digest identifying the catalog's content. Catalogs
+// loaded from files hash the file contents, so relative dates do not change
+// the digest; in-memory catalogs hash their control and test definitions.
+func (c *Catalog) Hash() string {
+	h := sha256.New()
+	if len(c.fileDigests) > 0 {
+		for _, digest := range c.fileDigests {
+			fmt.Fprintln(h, digest)
+		}
+	} else {
+		for _, ctrl := range c.Controls {
+			fmt.Fprintf(h, "control\x00%s\x00%s\x00%s\x00%s\x00%v\x00%v\n", ctrl.ID, ctrl.Name, ctrl.Category, ctrl.Status, ctrl.RiskReduction, ctrl.Tests)
+		}
+		for _, test := range c.Tests {
+			fmt.Fprintf(h, "test\x00%s\x00%s\x00%s\x00%s\n", test.ID, test.Name, test.Method, test.ExpectedResult)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// RiskLocation returns where the risk scenario with the given ID was
+// defined.
+func (c *Catalog) RiskLocation(id string) (Location, bool) {
+	loc, ok := c.riskLocations[id]
+	return loc, ok
+}
+
+// GetTest returns the control test with the given ID.
+func (c *Catalog) GetTest(id string) *validate.ControlTest {
+	for i := range c.Tests {
+		if c.Tests[i].ID == id {
+			return &c.Tests[i]
+		}
+	}
+	return nil
+}
+
+// GetControl returns the control with the given ID.
+func (c *Catalog) GetControl(id string) *control.SecurityControl {
+	for i := range c.Controls {
+		if c.Controls[i].ID == id {
+			return &c.Controls[i]
+		}
+	}
+	return nil
+}
+
+// NewValidator creates a control validator holding the catalog's controls.
+func (c *Catalog) NewValidator() *control.ControlValidator {
+	validator := control.NewControlValidator()
+	for _, ctrl := range c.Controls {
+		validator.AddControl(ctrl)
+	}
+	return validator
+}
+
+// NewTestValidator creates a test validator holding the catalog's tests.
+func (c *Catalog) NewTestValidator() *validate.ControlValidator {
+	validator := validate.NewControlValidator()
+	for _, test := range c.Tests {
+		validator.AddControlTest(test)
+	}
+	return validator
+}
+
+// Marshal encodes a framework and its controls as a catalog file.
+func Marshal(fw control.ControlFramework) ([]byte, error) {
+	doc := documentSpec{Controls: make([]controlSpec, 0, len(fw.Controls))}
+	if fw.Name != "" || fw.Version != "" || fw.Description != "" {
+		doc.Framework = &frameworkSpec{
+			Name:        fw.Name,
+			Version:     fw.Version,
+			Description: fw.Description,
+			LastUpdated: formatDate(fw.LastUpdated),
+		}
+	}
+
+	for _, ctrl := range fw.Controls {
+		doc.Controls = append(doc.Controls, controlSpec{
+			ID:             ctrl.ID,
+			Name:           ctrl.Name,
+			Description:    ctrl.Description,
+			Category:       string(ctrl.Category),
+			Type:           string(ctrl.Type),
+			SubCategory:    ctrl.SubCategory,
+			RiskReduction:  ctrl.RiskReduction,
+			Implementation: ctrl.Implementation,
+			Verification:   ctrl.Verification,
+			Maintenance:    ctrl.Maintenance,
+			Owner:          ctrl.Owner,
+			Status:         string(ctrl.Status),
+			LastVerified:   formatDate(ctrl.LastVerified),
+			NextReview:     formatDate(ctrl.NextReview),
+			Evidence:       ctrl.Evidence,
+			References:     ctrl.References,
+			Tests:          ctrl.Tests,
+			Techniques:     ctrl.Techniques,
+			DependsOn:      ctrl.DependsOn,
+		})
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
+
+func newLoadState() *loadState {
+	return &loadState{
+		catalog:   &Catalog{},
+		seen:      make(map[string]Location),
+		seenTests: make(map[string]Location),
+		seenRisks: make(map[string]Location),
+		riskNodes: make(map[string]linkNode),
+		depNodes:  make(map[string]linkNode),
+	}
+}
+
+func (st *loadState) finish() *Catalog {
+	st.catalog.Framework.Controls = st.catalog.Controls
+	st.catalog.controlLocations = st.seen
+	st.catalog.testLocations = st.seenTests
+	st.catalog.riskLocations = st.seenRisks
+	return st.catalog
+}
+
+func (st *loadState) errorf(file string, node *yaml.Node, format string, args ...interface{}) {
+	err := &Error{File: file, Msg: fmt.Sprintf(format, args...)}
+	if node != nil {
+		err.Line = node.Line
+		err.Column = node.Column
+	}
+	st.errs = append(st.errs, err)
+}
+
+// parse parses one catalog file into the load state.
+func (l *Loader) parse(file string, data []byte, st *loadState) {
+	st.catalog.Files = append(st.catalog.Files, file)
+	sum := sha256.Sum256(data)
+	st.catalog.fileDigests = append(st.catalog.fileDigests, hex.EncodeToString(sum[:]))
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		st.errs = append(st.errs, yamlErrors(file, err)...)
+		return
+	}
+	if len(doc.Content) == 0 {
+		return
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		st.errorf(file, root, "catalog must be a mapping with framework, controls, tests and risks keys")
+		return
+	}
+	if !st.checkKeys(file, root, documentKeys, "catalog") {
+		return
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch key.Value {
+		case "framework":
+			l.parseFramework(file, value, st)
+		case "controls":
+			if value.Kind != yaml.SequenceNode {
+				st.errorf(file, value, "controls must be a list")
+				continue
+			}
+			for _, node := range value.Content {
+				l.parseControl(file, node, st)
+			}
+		case "tests":
+			if value.Kind != yaml.SequenceNode {
+				st.errorf(file, value, "tests must be a list")
+				continue
+			}
+			for _, node := range value.Content {
+				l.parseTest(file, node, st)
+			}
+		case "risks":
+			if value.Kind != yaml.SequenceNode {
+				st.errorf(file, value, "risks must be a list")
+				continue
+			}
+			for _, node := range value.Content {
+				l.parseRisk(file, node, st)
+			}
+		}
+	}
+}
+
+// parseFramework parses the framework section of a catalog file.
+func (l *Loader) parseFramework(file string, node *yaml.Node, st *loadState) {
+	if node.Kind != yaml.MappingNode {
+		st.errorf(file, node, "framework must be a mapping")
+		return
+	}
+	if !st.checkKeys(file, node, frameworkKeys, "framework") {
+		return
+	}
+
+	var spec frameworkSpec
+	if err := node.Decode(&spec); err != nil {
+		st.errs = append(st.errs, yamlErrors(file, err)...)
+		return
+	}
+
+	if st.framework != "" && spec.Name != st.framework {
+		st.errorf(file, valueNode(node, "name"), "framework %q conflicts with framework %q declared earlier", spec.Name, st.framework)
+		return
+	}
+	st.framework = spec.Name
+
+	lastUpdated, err := ParseDate(spec.LastUpdated, l.now)
+	if err != nil {
+		st.errorf(file, valueNode(node, "lastUpdated"), "%v", err)
+	}
+
+	fw := &st.catalog.Framework
+	fw.Name = spec.Name
+	fw.Version = spec.Version
+	fw.Description = spec.Description
+	fw.LastUpdated = lastUpdated
+}
+
+// parseControl parses and validates a single control entry.
+func (l *Loader) parseControl(file string, node *yaml.Node, st *loadState) {
+	if node.Kind != yaml.MappingNode {
+		st.errorf(file, node, "control must be a mapping")
+		return
+	}
+	if !st.checkKeys(file, node, controlKeys, "control") {
+		return
+	}
+
+	var spec controlSpec
+	if err := node.Decode(&spec); err != nil {
+		st.errs = append(st.errs, yamlErrors(file, err)...)
+		return
+	}
+
+	before := len(st.errs)
+
+	if strings.TrimSpace(spec.ID) == "" {
+		st.errorf(file, node, "control is missing required field id")
+	} else if prev, ok := st.seen[spec.ID]; ok {
+		st.errorf(file, valueNode(node, "id"), "duplicate control ID %q (first defined at %s:%d)", spec.ID, prev.File, prev.Line)
+	} else {
+		st.seen[spec.ID] = Location{File: file, Line: node.Line}
+	}
+
+	if strings.TrimSpace(spec.Name) == "" {
+		st.errorf(file, node, "control %q is missing required field name", spec.ID)
+	}
+	if !categories[control.ControlCategory(spec.Category)] {
+		st.errorf(file, valueNode(node, "category"), "control %q has unknown category %q", spec.ID, spec.Category)
+	}
+	if !types[control.ControlType(spec.Type)] {
+		st.errorf(file, valueNode(node, "type"), "control %q has unknown type %q", spec.ID, spec.Type)
+	}
+	if !statuses[control.ControlStatus(spec.Status)] {
+		st.errorf(file, valueNode(node, "status"), "control %q has unknown status %q", spec.ID, spec.Status)
+	}
+	if err := attack.Enterprise().Validate(spec.Techniques); err != nil {
+		st.errorf(file, valueNode(node, "techniques"), "control %q: %v", spec.ID, err)
+	}
+	if spec.RiskReduction < 0 || spec.RiskReduction > 1 {
+		st.errorf(file, valueNode(node, "riskReduction"), "control %q riskReduction %v must be between 0 and 1", spec.ID, spec.RiskReduction)
+	}
+
+	lastVerified, err := ParseDate(spec.LastVerified, l.now)
+	if err != nil {
+		st.errorf(file, valueNode(node, "lastVerified"), "control %q: %v", spec.ID, err)
+	}
+	nextReview, err := ParseDate(spec.NextReview, l.now)
+	if err != nil {
+		st.errorf(file, valueNode(node, "nextReview"), "control %q: %v", spec.ID, err)
+	}
+
+	if len(st.errs) > before {
+		return
+	}
+
+	st.depNodes[spec.ID] = linkNode{file: file, node: valueNode(node, "dependsOn")}
+	st.catalog.Controls = append(st.catalog.Controls, control.SecurityControl{
+		ID:             spec.ID,
+		Name:           spec.Name,
+		Description:    spec.Description,
+		Category:       control.ControlCategory(spec.Category),
+		Type:           control.ControlType(spec.Type),
+		SubCategory:    spec.SubCategory,
+		RiskReduction:  spec.RiskReduction,
+		Implementation: spec.Implementation,
+		Verification:   spec.Verification,
+		Maintenance:    spec.Maintenance,
+		Owner:          spec.Owner,
+		Status:         control.ControlStatus(spec.Status),
+		LastVerified:   lastVerified,
+		NextReview:     nextReview,
+		Evidence:       spec.Evidence,
+		References:     spec.References,
+		Tests:          spec.Tests,
+		Techniques:     spec.Techniques,
+		DependsOn:      spec.DependsOn,
+	})
+}
+
+// parseTest parses and validates a single control test entry.
+func (l *Loader) parseTest(file string, node *yaml.Node, st *loadState) {
+	if node.Kind != yaml.MappingNode {
+		st.errorf(file, node, "test must be a mapping")
+		return
+	}
+	if !st.checkKeys(file, node, testKeys, "test") {
+		return
+	}
+	if cmd := valueNode(node, "command"); cmd != node && cmd.Kind == yaml.MappingNode {
+		if !st.checkKeys(file, cmd, commandKeys, "command") {
+			return
+		}
+		if json := valueNode(cmd, "expectJSON"); json != cmd && json.Kind == yaml.SequenceNode {
+			for _, a := range json.Content {
+				if a.Kind == yaml.MappingNode && !st.checkKeys(file, a, assertionKeys, "expectJSON") {
+					return
+				}
+			}
+		}
+	}
+
+	var spec testSpec
+	if err := node.Decode(&spec); err != nil {
+		st.errs = append(st.errs, yamlErrors(file, err)...)
+		return
+	}
+
+	before := len(st.errs)
+
+	if strings.TrimSpace(spec.ID) == "" {
+		st.errorf(file, node, "test is missing required field id")
+	} else if prev, ok := st.seenTests[spec.ID]; ok {
+		st.errorf(file, valueNode(node, "id"), "duplicate test ID %q (first defined at %s:%d)", spec.ID, prev.File, prev.Line)
+	} else {
+		st.seenTests[spec.ID] = Location{File: file, Line: node.Line}
+	}
+
+	if strings.TrimSpace(spec.Name) == "" {
+		st.errorf(file, node, "test %q is missing required field name", spec.ID)
+	}
+	if !methods[validate.ValidationMethod(spec.Method)] {
+		st.errorf(file, valueNode(node, "method"), "test %q has unknown method %q", spec.ID, spec.Method)
+	}
+
+	testedAt, err := ParseDate(spec.TestedAt, l.now)
+	if err != nil {
+		st.errorf(file, valueNode(node, "testedAt"), "test %q: %v", spec.ID, err)
+	}
+	command := st.command(file, valueNode(node, "command"), spec)
+
+	if len(st.errs) > before {
+		return
+	}
+
+	st.catalog.Tests = append(st.catalog.Tests, validate.ControlTest{
+		ID:             spec.ID,
+		Name:           spec.Name,
+		Description:    spec.Description,
+		Method:         validate.ValidationMethod(spec.Method),
+		Kind:           spec.Kind,
+		Command:        command,
+		Steps:          spec.Steps,
+		ExpectedResult: spec.ExpectedResult,
+		ActualResult:   spec.ActualResult,
+		Passed:         spec.Passed,
+		Notes:          spec.Notes,
+		TestedAt:       testedAt,
+		TestedBy:       spec.TestedBy,
+	})
+}
+
+// parseRisk parses and validates a single risk scenario entry.
+func (l *Loader) parseRisk(file string, node *yaml.Node, st *loadState) {
+	if node.Kind != yaml.MappingNode {
+		st.errorf(file, node, "risk must be a mapping")
+		return
+	}
+	if !st.checkKeys(file, node, riskKeys, "risk") {
+		return
+	}
+	for _, key := range []string{"frequency", "magnitude"} {
+		if e := valueNode(node, key); e != node && e.Kind == yaml.MappingNode && !st.checkKeys(file, e, estimateKeys, key) {
+			return
+		}
+	}
+
+	var scenario risk.RiskScenario
+	if err := node.Decode(&scenario); err != nil {
+		st.errs = append(st.errs, yamlErrors(file, err)...)
+		return
+	}
+
+	before := len(st.errs)
+
+	if strings.TrimSpace(scenario.ID) == "" {
+		st.errorf(file, node, "risk is missing required field id")
+	} else if prev, ok := st.seenRisks[scenario.ID]; ok {
+		st.errorf(file, valueNode(node, "id"), "duplicate risk ID %q (first defined at %s:%d)", scenario.ID, prev.File, prev.Line)
+	} else {
+		st.seenRisks[scenario.ID] = Location{File: file, Line: node.Line}
+	}
+
+	if strings.TrimSpace(scenario.Name) == "" {
+		st.errorf(file, node, "risk %q is missing required field name", scenario.ID)
+	}
+	if err := scenario.Validate(); err != nil {
+		st.errorf(file, node, "risk %q: %v", scenario.ID, err)
+	}
+
+	if len(st.errs) > before {
+		return
+	}
+
+	st.riskNodes[scenario.ID] = linkNode{file: file, node: valueNode(node, "controls")}
+	st.catalog.Risks = append(st.catalog.Risks, scenario)
+}
+
+// checkRisks reports risk scenarios linked to controls that are not in the
+// catalog. It runs once all files are parsed, since scenarios and controls
+// may be defined in different files.
+func (st *loadState) checkRisks() {
+	for _, scenario := range st.catalog.Risks {
+		at := st.riskNodes[scenario.ID]
+		for i, id := range scenario.Controls {
+			if _, ok := st.seen[id]; ok {
+				continue
+			}
+			node := at.node
+			if i < len(node.Content) {
+				node = node.Content[i]
+			}
+			st.errorf(at.file, node, "risk %q links to unknown control %q", scenario.ID, id)
+		}
+	}
+}
+
+// checkDependencies reports controls that depend on controls that are not
+// in the catalog, and dependency cycles. Like checkRisks, it runs once all
+// files are parsed.
+func (st *loadState) checkDependencies() {
+	for _, ctrl := range st.catalog.Controls {
+		at := st.depNodes[ctrl.ID]
+		for i, id := range ctrl.DependsOn {
+			if _, ok := st.seen[id]; ok {
+				continue
+			}
+			node := at.node
+			if i < len(node.Content) {
+				node = node.Content[i]
+			}
+			st.errorf(at.file, node, "control %q depends on unknown control %q", ctrl.ID, id)
+		}
+	}
+	for _, cycle := range control.DependencyGraph(st.catalog.Controls).Cycles() {
+		at := st.depNodes[cycle[0]]
+		st.errorf(at.file, at.node, "dependency cycle: %s", strings.Join(append(cycle, cycle[0]), " -> "))
+	}
+}
+
+// command converts and validates a test's command.
+func (st *loadState) command(file string, node *yaml.Node, spec testSpec) *validate.Command {
+	if spec.Command == nil {
+		return nil
+	}
+
+	cs := spec.Command
+	cmd := &validate.Command{
+		Path:           cs.Run,
+		Args:           cs.Args,
+		Env:            cs.Env,
+		Dir:            cs.Dir,
+		ExpectExitCode: cs.ExpectExitCode,
+		ExpectStdout:   cs.ExpectStdout,
+	}
+
+	if strings.TrimSpace(cs.Run) == "" {
+		st.errorf(file, node, "test %q command is missing required field run", spec.ID)
+	}
+	if cs.Timeout != "" {
+		timeout, err := time.ParseDuration(cs.Timeout)
+		if err != nil || timeout <= 0 {
+			st.errorf(file, valueNode(node, "timeout"), "test %q has invalid timeout %q", spec.ID, cs.Timeout)
+		}
+		cmd.Timeout = timeout
+	}
+	if cs.ExpectStdout != "" {
+		if _, err := regexp.Compile(cs.ExpectStdout); err != nil {
+			st.errorf(file, valueNode(node, "expectStdout"), "test %q has invalid expectStdout pattern: %v", spec.ID, err)
+		}
+	}
+
+	assertions := valueNode(node, "expectJSON")
+	for i, a := range cs.ExpectJSON {
+		at := assertions
+		if i < len(assertions.Content) {
+			at = assertions.Content[i]
+		}
+		if err := validate.ValidateJSONPath(a.Path); err != nil {
+			st.errorf(file, valueNode(at, "path"), "test %q: %v", spec.ID, err)
+		}
+		if a.Matches != "" {
+			if _, err := regexp.Compile(a.Matches); err != nil {
+				st.errorf(file, valueNode(at, "matches"), "test %q has invalid pattern: %v", spec.ID, err)
+			}
+		}
+		cmd.ExpectJSON = append(cmd.ExpectJSON, validate.JSONAssertion{
+			Path:    a.Path,
+			Equals:  a.Equals,
+			Matches: a.Matches,
+		})
+	}
+	return cmd
+}
+
+// checkKeys reports keys of a mapping node that are not in the schema.
+func (st *loadState) checkKeys(file string, node *yaml.Node, allowed map[string]bool, what string) bool {
+	ok := true
+	for i := 0; i < len(node.Content); i += 2 {
+		key := node.Content[i]
+		if !allowed[key.Value] {
+			st.errorf(file, key, "unknown %s field %q", what, key.Value)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// valueNode returns the value node for a key in a mapping node, or the
+// mapping itself when the key is absent.
+func valueNode(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return node
+}
+
+// specKeys returns the YAML keys declared on a spec struct.
+func specKeys(spec interface{}) map[string]bool {
+	keys := make(map[string]bool)
+	t := reflect.TypeOf(spec)
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if tag != "" && tag != "-" {
+			keys[tag] = true
+		}
+	}
+	return keys
+}
+
+var yamlLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// yamlErrors converts yaml.v3 errors into located catalog errors.
+func yamlErrors(file string, err error) ErrorList {
+	var msgs []string
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		msgs = typeErr.Errors
+	} else {
+		msgs = []string{err.Error()}
+	}
+
+	errs := make(ErrorList, 0, len(msgs))
+	for _, msg := range msgs {
+		e := &Error{File: file, Msg: strings.TrimPrefix(msg, "yaml: ")}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			e.Line, _ = strconv.Atoi(m[1])
+			e.Msg = m[2]
+		}
+		errs = append(errs, e)
+	}
+	return errs
+}
+
+// expandPaths expands directories into the catalog files they contain.
+func expandPaths(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		var found []string
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			ext := strings.ToLower(filepath.Ext(p))
+			if !d.IsDir() && (ext == ".yaml" || ext == ".yml") {
+				found = append(found, p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(found)
+		files = append(files, found...)
+	}
+	return files, nil
+}
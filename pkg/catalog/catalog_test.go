@@ -0,0 +1,232 @@
+package catalog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseCatalog(t *testing.T) {
+	data := `
+framework:
+  name: Test
+  version: "2"
+controls:
+  - id: c-1
+    name: MFA
+    category: preventive
+    type: technical
+    status: implemented
+    riskReduction: 0.4
+    lastVerified: -90d
+    nextReview: 2030-01-02
+`
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	loader := NewLoader()
+	loader.SetNow(now)
+
+	cat, err := loader.Parse("test.yaml", []byte(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if cat.Framework.Name != "Test" || len(cat.Framework.Controls) != 1 {
+		t.Errorf("unexpected framework: %+v", cat.Framework)
+	}
+
+	ctrl := cat.GetControl("c-1")
+	if ctrl == nil {
+		t.Fatal("control c-1 not loaded")
+	}
+	if want := now.AddDate(0, 0, -90); !ctrl.LastVerified.Equal(want) {
+		t.Errorf("LastVerified = %v, want %v", ctrl.LastVerified, want)
+	}
+	if ctrl.NextReview.Year() != 2030 {
+		t.Errorf("NextReview = %v", ctrl.NextReview)
+	}
+	if loc, ok := cat.ControlLocation("c-1"); !ok || loc.File != "test.yaml" || loc.Line != 6 {
+		t.Errorf("ControlLocation = %+v, %v", loc, ok)
+	}
+
+	// Relative dates must not change the catalog hash
+	loader.SetNow(now.AddDate(0, 0, 1))
+	later, err := loader.Parse("test.yaml", []byte(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cat.Hash() != later.Hash() {
+		t.Error("catalog hash changed with the reference time")
+	}
+}
+
+func TestParseCatalogErrors(t *testing.T) {
+	data := `controls:
+  - id: c-1
+    name: One
+    category: preventive
+    type: technical
+    status: implemented
+  - id: c-1
+    name: Two
+    category: bogus
+    type: technical
+    status: implemented
+    colour: red
+    techniques: [T1078, T9999]
+`
+	_, err := NewLoader().Parse("bad.yaml", []byte(data))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	msg := err.Error()
+	for _, want := range []string{`bad.yaml:12:5: unknown control field "colour"`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+
+	data = strings.Replace(data, "    colour: red\n", "", 1)
+	_, err = NewLoader().Parse("bad.yaml", []byte(data))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+
+	msg = err.Error()
+	for _, want := range []string{
+		`bad.yaml:7:9: duplicate control ID "c-1" (first defined at bad.yaml:2)`,
+		`bad.yaml:9:15: control "c-1" has unknown category "bogus"`,
+		`bad.yaml:12:17: control "c-1": unknown ATT&CK technique "T9999"`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+}
+
+func TestParseRisks(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("controls.yaml", `controls:
+  - id: c-1
+    name: MFA
+    category: preventive
+    type: technical
+    status: implemented
+`)
+	write("risks.yaml", `risks:
+  - id: r-1
+    name: Account takeover
+    asset: Accounts
+    threat: Phishing
+    likelihood: 4
+    impact: 5
+    controls: [c-1]
+`)
+
+	cat, err := Load(dir)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if len(cat.Risks) != 1 || cat.Risks[0].Inherent() != 20 || cat.Risks[0].Controls[0] != "c-1" {
+		t.Errorf("risks = %+v", cat.Risks)
+	}
+	if loc, ok := cat.RiskLocation("r-1"); !ok || loc.Line != 2 {
+		t.Errorf("RiskLocation = %+v, %v", loc, ok)
+	}
+
+	write("risks.yaml", `risks:
+  - id: r-1
+    name: Account takeover
+    controls: [c-1, c-2]
+  - id: r-1
+    name: Again
+    likelihood: -1
+`)
+	_, err = Load(dir)
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`risks.yaml:4:21: risk "r-1" links to unknown control "c-2"`,
+		`risks.yaml:5:9: duplicate risk ID "r-1"`,
+		`risk "r-1": inherent risk, likelihood and impact must not be negative`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+}
+
+func TestParseDependencies(t *testing.T) {
+	data := `controls:
+  - id: mfa
+    name: MFA
+    category: preventive
+    type: technical
+    status: implemented
+    dependsOn: [idp]
+  - id: idp
+    name: Identity Provider
+    category: preventive
+    type: technical
+    status: implemented
+`
+	cat, err := NewLoader().Parse("deps.yaml", []byte(data))
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if deps := cat.GetControl("mfa").DependsOn; len(deps) != 1 || deps[0] != "idp" {
+		t.Errorf("DependsOn = %v", deps)
+	}
+
+	data += "    dependsOn: [mfa, sso]\n"
+	_, err = NewLoader().Parse("deps.yaml", []byte(data))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	msg := err.Error()
+	for _, want := range []string{
+		`deps.yaml:13:22: control "idp" depends on unknown control "sso"`,
+		`deps.yaml:7:16: dependency cycle: mfa -> idp -> mfa`,
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("error %q does not contain %q", msg, want)
+		}
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2024, 6, 15, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"-90d", now.AddDate(0, 0, -90)},
+		{"+2w", now.AddDate(0, 0, 14)},
+		{"-6m", now.AddDate(0, -6, 0)},
+		{"-1y", now.AddDate(-1, 0, 0)},
+		{"2024-01-02", time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"", time.Time{}},
+	}
+	for _, tt := range tests {
+		got, err := ParseDate(tt.expr, now)
+		if err != nil {
+			t.Errorf("ParseDate(%q): %v", tt.expr, err)
+			continue
+		}
+		if !got.Equal(tt.want) {
+			t.Errorf("ParseDate(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+
+	if _, err := ParseDate("-5x", now); err == nil {
+		t.Error("expected error for unknown unit")
+	}
+}
@@ -0,0 +1,86 @@
+package catalog
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dateLayouts are the absolute date formats accepted in catalogs.
+var dateLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+// ParseDate parses an absolute or relative date expression.
+//
+// Absolute dates use ISO 8601 ("2024-05-01" or RFC 3339). Relative
+// expressions are offsets from now such as "-90d", "+2w", "-6m" or "-1y",
+// where d, w, m and y are days, weeks, months and years. "now" and "today"
+// are also accepted.
+func ParseDate(expr string, now time.Time) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	switch strings.ToLower(expr) {
+	case "":
+		return time.Time{}, nil
+	case "now":
+		return now, nil
+	case "today":
+		y, m, d := now.Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	}
+
+	if expr[0] == '-' || expr[0] == '+' {
+		return parseRelativeDate(expr, now)
+	}
+
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, expr); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q: expected YYYY-MM-DD, RFC 3339 or a relative offset like -90d", expr)
+}
+
+// parseRelativeDate parses a signed offset such as "-90d".
+func parseRelativeDate(expr string, now time.Time) (time.Time, error) {
+	if len(expr) < 3 {
+		return time.Time{}, fmt.Errorf("invalid relative date %q", expr)
+	}
+
+	n, err := strconv.Atoi(expr[1 : len(expr)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, fmt.Errorf("invalid relative date %q", expr)
+	}
+	if expr[0] == '-' {
+		n = -n
+	}
+
+	switch expr[len(expr)-1] {
+	case 'd':
+		return now.AddDate(0, 0, n), nil
+	case 'w':
+		return now.AddDate(0, 0, 7*n), nil
+	case 'm':
+		return now.AddDate(0, n, 0), nil
+	case 'y':
+		return now.AddDate(n, 0, 0), nil
+	default:
+		return time.Time{}, fmt.Errorf("invalid relative date %q: unit must be d, w, m or y", expr)
+	}
+}
+
+// formatDate formats a date for a catalog file, omitting the time of day
+// when it is midnight.
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	if h, m, s := t.Clock(); h == 0 && m == 0 && s == 0 && t.Nanosecond() == 0 {
+		return t.Format("2006-01-02")
+	}
+	return t.Format(time.RFC3339)
+}
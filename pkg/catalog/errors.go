@@ -0,0 +1,46 @@
+package catalog
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Error represents a catalog error at a specific location.
+type Error struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+// Error returns the error message prefixed with its location.
+func (e *Error) Error() string {
+	switch {
+	case e.File != "" && e.Line > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+	case e.File != "":
+		return fmt.Sprintf("%s: %s", e.File, e.Msg)
+	default:
+		return e.Msg
+	}
+}
+
+// ErrorList collects every error found while loading a catalog.
+type ErrorList []*Error
+
+// Error returns all errors, one per line.
+func (l ErrorList) Error() string {
+	msgs := make([]string, len(l))
+	for i, err := range l {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// err returns the list as an error, or nil when it is empty.
+func (l ErrorList) err() error {
+	if len(l) == 0 {
+		return nil
+	}
+	return l
+}
@@ -2,8 +2,14 @@
 package control
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/engine"
+	"github.com/hallucinaut/securitycontrol/pkg/rules"
 )
 
 // ControlCategory represents a category of security control.
@@ -21,67 +27,104 @@ const (
 type ControlType string
 
 const (
-	TypeTechnical ControlType = "technical"
+	TypeTechnical      ControlType = "technical"
 	TypeAdministrative ControlType = "administrative"
-	TypePhysical ControlType = "physical"
+	TypePhysical       ControlType = "physical"
 )
 
 // ControlStatus represents a control status.
 type ControlStatus string
 
 const (
-	StatusImplemented ControlStatus = "implemented"
+	StatusImplemented          ControlStatus = "implemented"
 	StatusPartiallyImplemented ControlStatus = "partially_implemented"
-	StatusNotImplemented ControlStatus = "not_implemented"
-	StatusDeprecated ControlStatus = "deprecated"
+	StatusNotImplemented       ControlStatus = "not_implemented"
+	StatusDeprecated           ControlStatus = "deprecated"
 )
 
 // SecurityControl represents a security control.
 type SecurityControl struct {
-	ID              string
-	Name            string
-	Description     string
-	Category        ControlCategory
-	Type            ControlType
-	SubCategory     string
-	RiskReduction   float64
-	Implementation  string
-	Verification    string
-	Maintenance     string
-	Owner           string
-	Status          ControlStatus
-	LastVerified    time.Time
-	NextReview      time.Time
-	Evidence        []string
-	References      []string
+	ID             string
+	Name           string
+	Description    string
+	Category       ControlCategory
+	Type           ControlType
+	SubCategory    string
+	RiskReduction  float64
+	Implementation string
+	Verification   string
+	Maintenance    string
+	Owner          string
+	Status         ControlStatus
+	LastVerified   time.Time
+	NextReview     time.Time
+	Evidence       []string
+	References     []string
+	Tests          []string
+	// Techniques are the MITRE ATT&CK techniques the control mitigates or
+	// detects, such as T1078 or T1110.003.
+	Techniques []string
+	// DependsOn lists the IDs of the controls this control relies on, such
+	// as MFA relying on the identity provider.
+	DependsOn []string
+}
+
+// TestOutcome records one execution of a control test.
+type TestOutcome struct {
+	TestID   string
+	Passed   bool
+	TestedAt time.Time
 }
 
 // ControlFramework represents a security control framework.
 type ControlFramework struct {
-	Name          string
-	Version       string
-	Description   string
-	Controls      []SecurityControl
-	LastUpdated   time.Time
+	Name        string
+	Version     string
+	Description string
+	Controls    []SecurityControl
+	LastUpdated time.Time
 }
 
 // ControlValidator validates security controls.
 type ControlValidator struct {
+	mu       sync.RWMutex
 	controls []SecurityControl
 	results  []ControlValidationResult
+	history  map[string][]TestOutcome
+	suppress map[string][]string
+	rules    *rules.Set
+	model    ScoringModel
 }
 
 // ControlValidationResult represents a control validation result.
 type ControlValidationResult struct {
-	ControlID      string
-	ControlName    string
-	Status         string
-	Effectiveness  float64
-	Confidence     float64
-	Issues         []string
-	Evidence       []string
-	Recommendations []string
-	ValidatedAt    time.Time
+	ControlID               string
+	ControlName             string
+	Status                  string
+	Effectiveness           float64
+	Confidence              float64
+	Issues                  []Issue
+	Evidence                []string
+	Recommendations         []string
+	TestsLinked             int
+	TestsPassed             int
+	TestsFailed             int
+	Untested                bool
+	ScoringModel            string
+	ProjectedEffectiveUntil time.Time
+	// DegradedBy lists the ineffective controls this control depends on.
+	DegradedBy  []string
+	ValidatedAt time.Time
+}
+
+// testSummary summarizes the recorded outcomes of a control's linked tests.
+type testSummary struct {
+	linked  int
+	passed  int
+	failed  int
+	runs    int
+	score   float64
+	failing []TestOutcome
 }
 
 // NewControlValidator creates a new control validator.
@@ -89,21 +132,98 @@ func NewControlValidator() *ControlValidator {
 	return &ControlValidator{
 		controls: make([]SecurityControl, 0),
 		results:  make([]ControlValidationResult, 0),
+		history:  make(map[string][]TestOutcome),
+		suppress: make(map[string][]string),
+		rules:    BuiltinRules(),
+		model:    DefaultScoringModel(),
+	}
+}
+
+// SetScoringModel sets the model used to score controls.
+func (v *ControlValidator) SetScoringModel(model ScoringModel) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.model = model
+}
+
+// ScoringModel returns the model used to score controls.
+func (v *ControlValidator) ScoringModel() ScoringModel {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.model
+}
+
+// SetRules sets the rules used to identify control issues, replacing the
+// built-in rules.
+func (v *ControlValidator) SetRules(set *rules.Set) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.rules = set
+}
+
+// Suppress suppresses issues with the given code for the listed controls,
+// or for every control when none are listed. Suppressed issues are not
+// reported and do not affect scoring.
+func (v *ControlValidator) Suppress(code string, controlIDs ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if len(controlIDs) == 0 {
+		controlIDs = []string{"*"}
+	}
+	v.suppress[code] = append(v.suppress[code], controlIDs...)
+}
+
+// suppressed reports whether an issue is suppressed for a control.
+func (v *ControlValidator) suppressed(controlID string, issue Issue) bool {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	for _, id := range v.suppress[issue.Code] {
+		if id == "*" || id == controlID {
+			return true
+		}
 	}
+	return false
 }
 
 // AddControl adds a security control.
 func (v *ControlValidator) AddControl(control SecurityControl) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	v.controls = append(v.controls, control)
 }
 
+// RecordTestOutcome records the outcome of a control test run. Controls
+// that list the test in Tests are scored from these outcomes.
+func (v *ControlValidator) RecordTestOutcome(outcome TestOutcome) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.history[outcome.TestID] = append(v.history[outcome.TestID], outcome)
+}
+
+// GetTestHistory returns the recorded outcomes of a test, oldest first.
+func (v *ControlValidator) GetTestHistory(testID string) []TestOutcome {
+	v.mu.RLock()
+	history := append([]TestOutcome(nil), v.history[testID]...)
+	v.mu.RUnlock()
+
+	sort.SliceStable(history, func(i, j int) bool {
+		return history[i].TestedAt.Before(history[j].TestedAt)
+	})
+	return history
+}
+
 // GetControls returns all controls.
 func (v *ControlValidator) GetControls() []SecurityControl {
-	return v.controls
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]SecurityControl(nil), v.controls...)
 }
 
 // GetControlsByCategory returns controls by category.
 func (v *ControlValidator) GetControlsByCategory(category ControlCategory) []SecurityControl {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
 	var result []SecurityControl
 	for _, control := range v.controls {
 		if control.Category == category {
@@ -115,6 +235,9 @@ func (v *ControlValidator) GetControlsByCategory(category ControlCategory) []Sec
 
 // GetControlsByStatus returns controls by status.
 func (v *ControlValidator) GetControlsByStatus(status ControlStatus) []SecurityControl {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
 	var result []SecurityControl
 	for _, control := range v.controls {
 		if control.Status == status {
@@ -124,180 +247,207 @@ func (v *ControlValidator) GetControlsByStatus(status ControlStatus) []SecurityC
 	return result
 }
 
-// ValidateControl validates a security control.
+// ValidateControl validates a security control and degrades its result by
+// the latest results of the controls it depends on. Dependencies without a
+// result are validated first.
 func (v *ControlValidator) ValidateControl(controlID string) *ControlValidationResult {
-	control := v.getControlByID(controlID)
+	control := GetControl(v, controlID)
 	if control == nil {
 		return nil
 	}
 
-	result := &ControlValidationResult{
-		ControlID:   control.ID,
-		ControlName: control.Name,
-		Status:      "VALIDATING",
-		Effectiveness: 0.0,
-		Confidence:  0.0,
-		Issues:      make([]string, 0),
-		Evidence:    make([]string, 0),
-		Recommendations: make([]string, 0),
-		ValidatedAt: time.Now(),
-	}
-
-	// Validate control implementation
-	effective := v.validateControlImplementation(*control)
-	result.Effectiveness = effective
-
-	// Check for issues
-	issues := v.identifyIssues(*control)
-	result.Issues = issues
-
-	// Determine confidence
-	confidence := v.calculateConfidence(*control, issues)
-	result.Confidence = confidence
-
-	// Generate recommendations
-	recommendations := v.generateRecommendations(*control, issues)
-	result.Recommendations = recommendations
-
-	// Determine overall status
-	if len(issues) == 0 && effective >= 0.9 {
-		result.Status = "EFFECTIVE"
-	} else if effective >= 0.7 {
-		result.Status = "PARTIALLY_EFFECTIVE"
-	} else {
-		result.Status = "INEFFECTIVE"
-	}
-
+	result := v.validateWithDependencies(*control, make(map[string]bool))
+	v.mu.Lock()
 	v.results = append(v.results, *result)
+	v.mu.Unlock()
 	return result
 }
 
-// validateControlImplementation validates control implementation.
-func (v *ControlValidator) validateControlImplementation(control SecurityControl) float64 {
-	// In production: perform actual validation tests
-	// For demo: return simulated effectiveness
-
-	effective := 0.7 // Default effectiveness
+// ValidateAll validates every control concurrently, then degrades the
+// controls that depend on ineffective ones in dependency order. Results are
+// returned in control order. If ctx is cancelled or the run times out, the
+// controls validated so far are returned with the context error.
+func (v *ControlValidator) ValidateAll(ctx context.Context, opts engine.Options) ([]ControlValidationResult, error) {
+	controls := v.GetControls()
+	validated := make([]*ControlValidationResult, len(controls))
+
+	err := engine.Run(ctx, len(controls), opts, func(ctx context.Context, i, attempt int) bool {
+		if ctx.Err() == nil {
+			validated[i] = v.validateControl(controls[i])
+		}
+		return false
+	})
 
-	// Check if control is implemented
-	if control.Status == StatusImplemented {
-		effective = 0.9
-	} else if control.Status == StatusPartiallyImplemented {
-		effective = 0.6
-	} else if control.Status == StatusNotImplemented {
-		effective = 0.0
+	results := make([]ControlValidationResult, 0, len(controls))
+	for _, result := range validated {
+		if result != nil {
+			results = append(results, *result)
+		}
 	}
+	v.cascade(controls, results)
 
-	return effective
+	v.mu.Lock()
+	v.results = append(v.results, results...)
+	v.mu.Unlock()
+	return results, err
 }
 
-// identifyIssues identifies issues with control.
-func (v *ControlValidator) identifyIssues(control SecurityControl) []string {
-	var issues []string
-
-	// Check if control has evidence
-	if len(control.Evidence) == 0 {
-		issues = append(issues, "No evidence provided for control implementation")
+// implementationFactor scales test-derived effectiveness by how much of
+// the control is actually in place.
+func implementationFactor(status ControlStatus) float64 {
+	switch status {
+	case StatusImplemented:
+		return 1.0
+	case StatusPartiallyImplemented:
+		return 0.6
+	case StatusNotImplemented:
+		return 0.0
+	case StatusDeprecated:
+		return 0.5
+	default:
+		return 0.8
 	}
+}
 
-	// Check if control has recent verification
-	if control.LastVerified.IsZero() || control.LastVerified.Before(time.Now().AddDate(0, -6, 0)) {
-		issues = append(issues, "Control not verified in last 6 months")
-	}
+// summarizeTests summarizes the recorded history of a control's linked tests.
+// Each test scores its latest outcome at 70% and its overall pass rate at 30%.
+func (v *ControlValidator) summarizeTests(control SecurityControl) testSummary {
+	summary := testSummary{linked: len(control.Tests)}
 
-	// Check if control has owner
-	if control.Owner == "" {
-		issues = append(issues, "Control owner not assigned")
-	}
+	total := 0.0
+	for _, id := range control.Tests {
+		history := v.GetTestHistory(id)
+		if len(history) == 0 {
+			continue
+		}
 
-	return issues
-}
+		passes := 0
+		for _, outcome := range history {
+			if outcome.Passed {
+				passes++
+			}
+		}
 
-// calculateConfidence calculates validation confidence.
-func (v *ControlValidator) calculateConfidence(control SecurityControl, issues []string) float64 {
-	confidence := 0.5
+		latest := history[len(history)-1]
+		score := 0.3 * float64(passes) / float64(len(history))
+		if latest.Passed {
+			score += 0.7
+			summary.passed++
+		} else {
+			summary.failed++
+			summary.failing = append(summary.failing, latest)
+		}
 
-	// Adjust confidence based on evidence
-	if len(control.Evidence) > 0 {
-		confidence += 0.2
+		total += score
+		summary.runs += len(history)
 	}
 
-	// Adjust confidence based on issues
-	if len(issues) == 0 {
-		confidence += 0.3
-	} else {
-		confidence -= float64(len(issues)) * 0.1
+	if tested := summary.passed + summary.failed; tested > 0 {
+		summary.score = total / float64(tested)
 	}
+	return summary
+}
 
-	if confidence < 0.0 {
-		confidence = 0.0
+// testIssues identifies issues from a control's linked tests.
+func testIssues(control SecurityControl, tests testSummary) []Issue {
+	var issues []Issue
+	if tests.linked > 0 && tests.runs == 0 {
+		issues = append(issues, NewIssue(IssueTestsNotRun))
 	}
-	if confidence > 1.0 {
-		confidence = 1.0
+	for _, outcome := range tests.failing {
+		issues = append(issues, NewIssue(IssueTestFailed, outcome.TestID))
 	}
+	return issues
+}
 
-	return confidence
+// identifyIssues identifies issues with control using the validator's rules.
+func (v *ControlValidator) identifyIssues(control SecurityControl) []Issue {
+	v.mu.RLock()
+	set := v.rules
+	v.mu.RUnlock()
+	return ruleIssues(set, control, time.Now())
 }
 
-// generateRecommendations generates recommendations for control.
-func (v *ControlValidator) generateRecommendations(control SecurityControl, issues []string) []string {
+// generateRecommendations generates recommendations for control from the
+// remediation of each issue.
+func (v *ControlValidator) generateRecommendations(control SecurityControl, issues []Issue) []string {
 	var recommendations []string
 
 	for _, issue := range issues {
-		switch {
-		case issue == "No evidence provided for control implementation":
-			recommendations = append(recommendations, "Provide evidence of control implementation")
-		case issue == "Control not verified in last 6 months":
-			recommendations = append(recommendations, "Schedule control verification")
-		case issue == "Control owner not assigned":
-			recommendations = append(recommendations, "Assign control owner")
-		default:
-			recommendations = append(recommendations, "Address: "+issue)
+		if issue.Remediation != "" {
+			recommendations = append(recommendations, issue.Remediation)
+		} else {
+			recommendations = append(recommendations, "Address: "+issue.Message)
 		}
 	}
 
 	return recommendations
 }
 
+// AddValidationResults adds earlier validation results, such as those of a
+// recorded run. ValidateControl degrades controls by the latest results of
+// the controls they depend on.
+func (v *ControlValidator) AddValidationResults(results ...ControlValidationResult) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.results = append(v.results, results...)
+}
+
 // GetValidationResults returns all validation results.
 func (v *ControlValidator) GetValidationResults() []ControlValidationResult {
-	return v.results
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]ControlValidationResult(nil), v.results...)
 }
 
 // validateControl validates control.
 func (v *ControlValidator) validateControl(control SecurityControl) *ControlValidationResult {
 	result := &ControlValidationResult{
-		ControlID:   control.ID,
-		ControlName: control.Name,
-		Status:      "VALIDATING",
-		Effectiveness: 0.0,
-		Confidence:  0.0,
-		Issues:      make([]string, 0),
-		Evidence:    make([]string, 0),
+		ControlID:       control.ID,
+		ControlName:     control.Name,
+		Status:          "VALIDATING",
+		Effectiveness:   0.0,
+		Confidence:      0.0,
+		Issues:          make([]Issue, 0),
+		Evidence:        make([]string, 0),
 		Recommendations: make([]string, 0),
-		ValidatedAt: time.Now(),
+		ValidatedAt:     time.Now(),
 	}
 
-	effective := v.validateControlImplementation(control)
-	result.Effectiveness = effective
+	tests := v.summarizeTests(control)
+	result.TestsLinked = tests.linked
+	result.TestsPassed = tests.passed
+	result.TestsFailed = tests.failed
+	result.Untested = tests.runs == 0
 
-	issues := v.identifyIssues(control)
+	issues := make([]Issue, 0)
+	for _, issue := range append(v.identifyIssues(control), testIssues(control, tests)...) {
+		if !v.suppressed(control.ID, issue) {
+			issues = append(issues, issue)
+		}
+	}
 	result.Issues = issues
 
-	confidence := v.calculateConfidence(control, issues)
-	result.Confidence = confidence
+	model := v.ScoringModel()
+	score := model.Score(ScoreInput{
+		Control:     control,
+		Issues:      issues,
+		TestsLinked: tests.linked,
+		TestsPassed: tests.passed,
+		TestsFailed: tests.failed,
+		TestRuns:    tests.runs,
+		TestScore:   tests.score,
+		Now:         result.ValidatedAt,
+	})
+	result.Effectiveness = score.Effectiveness
+	result.Confidence = score.Confidence
+	result.Status = score.Status
+	result.ScoringModel = model.Name()
+	result.ProjectedEffectiveUntil = score.EffectiveUntil
 
 	recommendations := v.generateRecommendations(control, issues)
 	result.Recommendations = recommendations
 
-	if len(issues) == 0 && effective >= 0.9 {
-		result.Status = "EFFECTIVE"
-	} else if effective >= 0.7 {
-		result.Status = "PARTIALLY_EFFECTIVE"
-	} else {
-		result.Status = "INEFFECTIVE"
-	}
-
 	return result
 }
 
@@ -315,96 +465,115 @@ func (v *ControlValidator) getControlByID(id string) *SecurityControl {
 func CreateCommonControls() []SecurityControl {
 	return []SecurityControl{
 		{
-			ID:          "ctrl-001",
-			Name:        "Access Control Policy",
-			Description: "Policy governing access to systems and data",
-			Category:    CategoryPreventive,
-			Type:        TypeAdministrative,
-			SubCategory: "Access Management",
-			RiskReduction: 0.3,
+			ID:             "ctrl-001",
+			Name:           "Access Control Policy",
+			Description:    "Policy governing access to systems and data",
+			Category:       CategoryPreventive,
+			Type:           TypeAdministrative,
+			SubCategory:    "Access Management",
+			RiskReduction:  0.3,
 			Implementation: "Documented access control policy enforced through IAM",
-			Verification: "Review policy documents and access logs",
-			Owner:       "Security Team",
-			Status:      StatusImplemented,
-			LastVerified:  time.Now().AddDate(0, -3, 0),
-			Evidence:    []string{"policy-access-control.pdf", "iam-configuration.json"},
-			References:  []string{"NIST-800-53-AC-1"},
+			Verification:   "Review policy documents and access logs",
+			Owner:          "Security Team",
+			Status:         StatusImplemented,
+			LastVerified:   time.Now().AddDate(0, -3, 0),
+			Evidence:       []string{"policy-access-control.pdf", "iam-configuration.json"},
+			References:     []string{"NIST-800-53-AC-1"},
+			Tests:          []string{"test-001"},
 		},
 		{
-			ID:          "ctrl-002",
-			Name:        "Multi-Factor Authentication",
-			Description: "MFA for all user access to systems",
-			Category:    CategoryPreventive,
-			Type:        TypeTechnical,
-			SubCategory: "Authentication",
-			RiskReduction: 0.4,
+			ID:             "ctrl-002",
+			Name:           "Multi-Factor Authentication",
+			Description:    "MFA for all user access to systems",
+			Category:       CategoryPreventive,
+			Type:           TypeTechnical,
+			SubCategory:    "Authentication",
+			RiskReduction:  0.4,
 			Implementation: "MFA enforced for all user accounts",
-			Verification: "Test MFA enforcement",
-			Owner:       "IT Operations",
-			Status:      StatusImplemented,
-			LastVerified:  time.Now().AddDate(0, -1, 0),
-			Evidence:    []string{"mfa-configuration.json", "audit-log.json"},
-			References:  []string{"NIST-800-53-IA-2"},
+			Verification:   "Test MFA enforcement",
+			Owner:          "IT Operations",
+			Status:         StatusImplemented,
+			LastVerified:   time.Now().AddDate(0, -1, 0),
+			Evidence:       []string{"mfa-configuration.json", "audit-log.json"},
+			References:     []string{"NIST-800-53-IA-2"},
 		},
 		{
-			ID:          "ctrl-003",
-			Name:        "Security Monitoring",
-			Description: "Continuous security monitoring of systems",
-			Category:    CategoryDetective,
-			Type:        TypeTechnical,
-			SubCategory: "Monitoring",
-			RiskReduction: 0.35,
+			ID:             "ctrl-003",
+			Name:           "Security Monitoring",
+			Description:    "Continuous security monitoring of systems",
+			Category:       CategoryDetective,
+			Type:           TypeTechnical,
+			SubCategory:    "Monitoring",
+			RiskReduction:  0.35,
 			Implementation: "SIEM and IDS/IPS deployed",
-			Verification: "Review monitoring dashboards",
-			Owner:       "SOC Team",
-			Status:      StatusImplemented,
-			LastVerified:  time.Now().AddDate(0, -2, 0),
-			Evidence:    []string{"siem-config.json", "monitoring-report.pdf"},
-			References:  []string{"NIST-800-53-AU-6"},
+			Verification:   "Review monitoring dashboards",
+			Owner:          "SOC Team",
+			Status:         StatusImplemented,
+			LastVerified:   time.Now().AddDate(0, -2, 0),
+			Evidence:       []string{"siem-config.json", "monitoring-report.pdf"},
+			References:     []string{"NIST-800-53-AU-6"},
+			Tests:          []string{"test-003"},
 		},
 		{
-			ID:          "ctrl-004",
-			Name:        "Incident Response Plan",
-			Description: "Documented incident response procedures",
-			Category:    CategoryCorrective,
-			Type:        TypeAdministrative,
-			SubCategory: "Incident Response",
-			RiskReduction: 0.25,
+			ID:             "ctrl-004",
+			Name:           "Incident Response Plan",
+			Description:    "Documented incident response procedures",
+			Category:       CategoryCorrective,
+			Type:           TypeAdministrative,
+			SubCategory:    "Incident Response",
+			RiskReduction:  0.25,
 			Implementation: "IR plan documented and tested",
-			Verification: "Review IR plan and test results",
-			Owner:       "Security Team",
-			Status:      StatusImplemented,
-			LastVerified:  time.Now().AddDate(0, -4, 0),
-			Evidence:    []string{"ir-plan.pdf", "test-results.pdf"},
-			References:  []string{"NIST-800-53-IR-1"},
+			Verification:   "Review IR plan and test results",
+			Owner:          "Security Team",
+			Status:         StatusImplemented,
+			LastVerified:   time.Now().AddDate(0, -4, 0),
+			Evidence:       []string{"ir-plan.pdf", "test-results.pdf"},
+			References:     []string{"NIST-800-53-IR-1"},
 		},
 	}
 }
 
 // GenerateReport generates control validation report.
 func GenerateReport(validator *ControlValidator) string {
+	return GenerateResultsReport(validator.GetValidationResults())
+}
+
+// GenerateResultsReport generates a report of validation results.
+func GenerateResultsReport(results []ControlValidationResult) string {
 	var report string
 
 	report += "=== Security Control Validation Report ===\n\n"
 
-	results := validator.GetValidationResults()
 	if len(results) == 0 {
 		report += "No controls validated yet\n"
 		return report
 	}
 
+	report += "Scoring Model: " + results[0].ScoringModel + "\n\n"
 	report += "Validation Results:\n"
 	for i, result := range results {
 		report += "\n[" + fmt.Sprintf("%d", i+1) + "] " + result.ControlName + "\n"
 		report += "    ID: " + result.ControlID + "\n"
 		report += "    Status: " + result.Status + "\n"
 		report += "    Effectiveness: " + fmt.Sprintf("%.1f%%", result.Effectiveness*100) + "\n"
-		report += "    Confidence: " + fmt.Sprintf("%.1f%%", result.Confidence*100) + "\n\n"
+		report += "    Confidence: " + fmt.Sprintf("%.1f%%", result.Confidence*100) + "\n"
+		if !result.ProjectedEffectiveUntil.IsZero() {
+			report += "    Projected Effective Until: " + result.ProjectedEffectiveUntil.Format("2006-01-02")
+			if result.ProjectedEffectiveUntil.Before(result.ValidatedAt) {
+				report += " (lapsed)"
+			}
+			report += "\n"
+		}
+		if result.Untested {
+			report += "    Tests: UNTESTED\n\n"
+		} else {
+			report += "    Tests: " + fmt.Sprintf("%d/%d passed", result.TestsPassed, result.TestsLinked) + "\n\n"
+		}
 
 		if len(result.Issues) > 0 {
 			report += "    Issues:\n"
 			for j, issue := range result.Issues {
-				report += "      [" + fmt.Sprintf("%d", j+1) + "] " + issue + "\n"
+				report += "      [" + fmt.Sprintf("%d", j+1) + "] " + issue.Message + " (" + issue.Code + ", " + string(issue.Severity) + ")\n"
 			}
 			report += "\n"
 		}
@@ -421,12 +590,20 @@ func GenerateReport(validator *ControlValidator) string {
 	return report
 }
 
-// GetControl returns control.
+// GetControl returns a copy of the control with the given ID.
 func GetControl(validator *ControlValidator, id string) *SecurityControl {
-	return validator.getControlByID(id)
+	validator.mu.RLock()
+	defer validator.mu.RUnlock()
+
+	control := validator.getControlByID(id)
+	if control == nil {
+		return nil
+	}
+	copied := *control
+	return &copied
 }
 
 // GetValidationResult returns validation result.
 func GetValidationResult(result *ControlValidationResult) *ControlValidationResult {
 	return result
-}
\ No newline at end of file
+}
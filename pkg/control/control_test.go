@@ -0,0 +1,194 @@
+package control
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/engine"
+)
+
+func TestValidateControlUntested(t *testing.T) {
+	validator := NewControlValidator()
+	for _, ctrl := range CreateCommonControls() {
+		validator.AddControl(ctrl)
+	}
+
+	result := validator.ValidateControl("ctrl-002")
+	if result == nil {
+		t.Fatal("expected result for ctrl-002")
+	}
+	if !result.Untested || result.TestsLinked != 0 {
+		t.Errorf("expected untested control, got %+v", result)
+	}
+	if result.Effectiveness != 0.9 || result.Status != "EFFECTIVE" {
+		t.Errorf("unexpected status-based score: %s %.2f", result.Status, result.Effectiveness)
+	}
+}
+
+func TestValidateControlLinkedTests(t *testing.T) {
+	now := time.Now()
+	validator := NewControlValidator()
+	validator.AddControl(SecurityControl{
+		ID:           "c-1",
+		Name:         "Linked",
+		Owner:        "Team",
+		Status:       StatusImplemented,
+		LastVerified: now,
+		Evidence:     []string{"e"},
+		Tests:        []string{"t-1", "t-2"},
+	})
+
+	validator.RecordTestOutcome(TestOutcome{TestID: "t-1", Passed: true, TestedAt: now.Add(-time.Hour)})
+	validator.RecordTestOutcome(TestOutcome{TestID: "t-2", Passed: true, TestedAt: now.Add(-2 * time.Hour)})
+
+	result := validator.ValidateControl("c-1")
+	if result.Untested || result.TestsPassed != 2 || result.Effectiveness != 1.0 || result.Status != "EFFECTIVE" {
+		t.Fatalf("unexpected passing result: %+v", result)
+	}
+
+	// A later failure of t-2 drops the control below effective
+	validator.RecordTestOutcome(TestOutcome{TestID: "t-2", Passed: false, TestedAt: now})
+
+	result = validator.ValidateControl("c-1")
+	if result.TestsFailed != 1 || result.Status == "EFFECTIVE" {
+		t.Fatalf("expected failing linked test to degrade control: %+v", result)
+	}
+	if len(result.Issues) != 1 || result.Issues[0].Message != "Linked test t-2 failed" || result.Issues[0].Code != IssueTestFailed {
+		t.Errorf("unexpected issues: %v", result.Issues)
+	}
+}
+
+func TestValidateAllLargeCatalog(t *testing.T) {
+	validator := NewControlValidator()
+	for i := 0; i < 5000; i++ {
+		validator.AddControl(SecurityControl{
+			ID:     fmt.Sprintf("c-%04d", i),
+			Status: StatusImplemented,
+			Tests:  []string{fmt.Sprintf("t-%04d", i%100)},
+		})
+	}
+	for i := 0; i < 100; i++ {
+		validator.RecordTestOutcome(TestOutcome{TestID: fmt.Sprintf("t-%04d", i), Passed: i%2 == 0, TestedAt: time.Now()})
+	}
+
+	results, err := validator.ValidateAll(context.Background(), engine.Options{Workers: 8})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 5000 {
+		t.Fatalf("expected 5000 results, got %d", len(results))
+	}
+	for i, result := range results {
+		if want := fmt.Sprintf("c-%04d", i); result.ControlID != want {
+			t.Fatalf("result %d is %s, want %s", i, result.ControlID, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := validator.ValidateAll(ctx, engine.DefaultOptions()); err != context.Canceled {
+		t.Errorf("expected cancellation error, got %v", err)
+	}
+}
+
+func TestIssueDefinitions(t *testing.T) {
+	issue := NewIssue(IssueTestFailed, "t-9")
+	if issue.Message != "Linked test t-9 failed" || issue.Remediation != "Investigate and fix failing test t-9" || issue.Severity != SeverityHigh {
+		t.Errorf("unexpected issue: %+v", issue)
+	}
+
+	if parsed := ParseIssue(issue.String()); parsed.Code != IssueTestFailed || parsed.Remediation != issue.Remediation {
+		t.Errorf("issue did not round trip: %+v", parsed)
+	}
+	if legacy := ParseIssue("Control owner not assigned"); legacy.Code != IssueNoOwner || legacy.Field != "Owner" {
+		t.Errorf("legacy message not matched: %+v", legacy)
+	}
+	if unknown := ParseIssue("Something else"); unknown.Code != "" || unknown.Message != "Something else" {
+		t.Errorf("unexpected unknown issue: %+v", unknown)
+	}
+	if reworded := ResolveIssue(IssueTestFailed, "Test t-9 is failing"); reworded.Severity != SeverityHigh || reworded.Remediation != "" {
+		t.Errorf("unexpected reworded issue: %+v", reworded)
+	}
+
+	issues := []Issue{NewIssue(IssueNoOwner), NewIssue(IssueTestFailed, "t-1"), NewIssue(IssueNoEvidence)}
+	SortIssues(issues)
+	if issues[0].Code != IssueTestFailed || issues[2].Code != IssueNoOwner || MaxSeverity(issues) != SeverityHigh {
+		t.Errorf("issues not sorted by severity: %v", issues)
+	}
+}
+
+func TestSuppressIssues(t *testing.T) {
+	validator := NewControlValidator()
+	validator.AddControl(SecurityControl{ID: "c-1", Status: StatusImplemented, LastVerified: time.Now()})
+	validator.AddControl(SecurityControl{ID: "c-2", Status: StatusImplemented, LastVerified: time.Now()})
+	validator.Suppress(IssueNoOwner)
+	validator.Suppress(IssueNoEvidence, "c-2")
+
+	if issues := validator.ValidateControl("c-1").Issues; len(issues) != 1 || issues[0].Code != IssueNoEvidence {
+		t.Errorf("c-1 issues = %v", issues)
+	}
+	result := validator.ValidateControl("c-2")
+	if len(result.Issues) != 0 || len(result.Recommendations) != 0 {
+		t.Errorf("c-2 issues not suppressed: %+v", result)
+	}
+}
+
+func TestBuiltinRules(t *testing.T) {
+	for _, result := range BuiltinRules().RunTests(time.Now()) {
+		if !result.Passed {
+			t.Errorf("%s: %s failed (fired %t, err %v)", result.RuleID, result.Test, result.Fired, result.Err)
+		}
+	}
+	for _, code := range []string{IssueNoEvidence, IssueNotVerified, IssueNoOwner} {
+		if _, ok := LookupIssue(code); !ok {
+			t.Errorf("%s not registered by the built-in rules", code)
+		}
+	}
+}
+
+func TestCustomRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	data := `
+rules:
+  - id: owner
+    disabled: true
+  - id: detective-evidence
+    when: category == "detective" && len(evidence) < 2
+    issue:
+      code: TEST-EVID-002
+      severity: high
+      field: Evidence
+      message: Detective control has too little evidence
+      remediation: Attach detection samples
+`
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	set, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	validator := NewControlValidator()
+	validator.SetRules(set)
+	validator.AddControl(SecurityControl{ID: "c-1", Category: CategoryDetective, Status: StatusImplemented, LastVerified: time.Now(), Evidence: []string{"e"}})
+
+	result := validator.ValidateControl("c-1")
+	if len(result.Issues) != 1 || result.Issues[0].Code != "TEST-EVID-002" || result.Issues[0].Severity != SeverityHigh {
+		t.Fatalf("issues = %+v", result.Issues)
+	}
+	if len(result.Recommendations) != 1 || result.Recommendations[0] != "Attach detection samples" {
+		t.Errorf("recommendations = %v", result.Recommendations)
+	}
+	if def, ok := LookupIssue("TEST-EVID-002"); !ok || def.Field != "Evidence" {
+		t.Errorf("custom issue not registered: %+v", def)
+	}
+
+	if _, err := LoadRules(path, filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("expected error for missing rules file")
+	}
+}
@@ -0,0 +1,123 @@
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decay describes how a control's effectiveness and confidence wear off
+// after it was last verified. Both halve every half-life once the grace
+// period is over. A control's half-life is the shortest of those set for
+// its category and its type, or HalfLifeDays if neither is set.
+type Decay struct {
+	HalfLifeDays float64                     `yaml:"halfLifeDays"`
+	GraceDays    float64                     `yaml:"graceDays,omitempty"`
+	Categories   map[ControlCategory]float64 `yaml:"categories,omitempty"`
+	Types        map[ControlType]float64     `yaml:"types,omitempty"`
+}
+
+// validate checks the decay settings.
+func (d *Decay) validate() error {
+	if d.HalfLifeDays <= 0 {
+		return errors.New("halfLifeDays must be positive")
+	}
+	if d.GraceDays < 0 {
+		return errors.New("graceDays must not be negative")
+	}
+	for category, days := range d.Categories {
+		if days <= 0 {
+			return fmt.Errorf("half-life of category %s must be positive", category)
+		}
+	}
+	for typ, days := range d.Types {
+		if days <= 0 {
+			return fmt.Errorf("half-life of type %s must be positive", typ)
+		}
+	}
+	return nil
+}
+
+// HalfLife returns the half-life of a control.
+func (d *Decay) HalfLife(control SecurityControl) time.Duration {
+	days := 0.0
+	for _, override := range []float64{d.Categories[control.Category], d.Types[control.Type]} {
+		if override > 0 && (days == 0 || override < days) {
+			days = override
+		}
+	}
+	if days == 0 {
+		days = d.HalfLifeDays
+	}
+	return daysDuration(days)
+}
+
+// Factor returns the share of a control's effectiveness left at now. Controls
+// that were never verified have fully decayed.
+func (d *Decay) Factor(control SecurityControl, now time.Time) float64 {
+	if control.LastVerified.IsZero() {
+		return 0
+	}
+	decaying := now.Sub(control.LastVerified.Add(daysDuration(d.GraceDays)))
+	if decaying <= 0 {
+		return 1
+	}
+	return math.Pow(0.5, decaying.Hours()/d.HalfLife(control).Hours())
+}
+
+// Until returns when an effectiveness decays below a threshold, or zero if
+// it never reaches the threshold or the control was never verified.
+func (d *Decay) Until(control SecurityControl, effectiveness, threshold float64) time.Time {
+	if control.LastVerified.IsZero() || threshold <= 0 || effectiveness < threshold {
+		return time.Time{}
+	}
+	halfLives := math.Log2(effectiveness / threshold)
+	decaying := time.Duration(halfLives * float64(d.HalfLife(control)))
+	return control.LastVerified.Add(daysDuration(d.GraceDays) + decaying)
+}
+
+// daysDuration converts a number of days to a duration.
+func daysDuration(days float64) time.Duration {
+	return time.Duration(days * float64(24*time.Hour))
+}
+
+// WithDecay returns a model whose scores decay with the time since each
+// control was last verified. Scores carry the projected date the control
+// stops being effective, and statuses reflect the decayed effectiveness.
+func WithDecay(model ScoringModel, decay *Decay) ScoringModel {
+	data, _ := yaml.Marshal(decay)
+	sum := sha256.Sum256(data)
+	return &decayModel{model: model, decay: decay, digest: hex.EncodeToString(sum[:])[:12]}
+}
+
+// decayModel applies time decay to the scores of another model.
+type decayModel struct {
+	model  ScoringModel
+	decay  *Decay
+	digest string
+}
+
+func (m *decayModel) Name() string {
+	return m.model.Name() + "+decay@" + m.digest
+}
+
+func (m *decayModel) Thresholds() Thresholds {
+	return m.model.Thresholds()
+}
+
+func (m *decayModel) Score(in ScoreInput) Score {
+	score := m.model.Score(in)
+	thresholds := m.Thresholds()
+	factor := m.decay.Factor(in.Control, in.Now)
+
+	score.EffectiveUntil = m.decay.Until(in.Control, score.Effectiveness, thresholds.Effective)
+	score.Effectiveness *= factor
+	score.Confidence *= factor
+	score.Status = statusFor(score.Effectiveness, len(in.Issues) == 0, thresholds)
+	return score
+}
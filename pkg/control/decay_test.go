@@ -0,0 +1,89 @@
+package control
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecay(t *testing.T) {
+	now := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	decay := &Decay{
+		HalfLifeDays: 100,
+		GraceDays:    10,
+		Categories:   map[ControlCategory]float64{CategoryDetective: 50},
+		Types:        map[ControlType]float64{TypeTechnical: 80},
+	}
+
+	control := SecurityControl{Category: CategoryPreventive, Type: TypeAdministrative, LastVerified: now.AddDate(0, 0, -110)}
+	if f := decay.Factor(control, now); math.Abs(f-0.5) > 1e-9 {
+		t.Errorf("factor after one half-life = %v", f)
+	}
+	control.LastVerified = now.AddDate(0, 0, -5)
+	if f := decay.Factor(control, now); f != 1 {
+		t.Errorf("factor within grace period = %v", f)
+	}
+	if f := decay.Factor(SecurityControl{}, now); f != 0 {
+		t.Errorf("never verified control must have fully decayed, got %v", f)
+	}
+
+	for _, tt := range []struct {
+		category ControlCategory
+		typ      ControlType
+		days     float64
+	}{
+		{CategoryPreventive, TypeAdministrative, 100},
+		{CategoryPreventive, TypeTechnical, 80},
+		{CategoryDetective, TypeTechnical, 50},
+	} {
+		got := decay.HalfLife(SecurityControl{Category: tt.category, Type: tt.typ})
+		if got != daysDuration(tt.days) {
+			t.Errorf("%s/%s half-life = %s, want %v days", tt.category, tt.typ, got, tt.days)
+		}
+	}
+
+	// 1.0 decays to 0.5 one half-life after the grace period
+	control.LastVerified = now
+	want := now.AddDate(0, 0, 110)
+	if got := decay.Until(control, 1.0, 0.5); !got.Equal(want) {
+		t.Errorf("until = %s, want %s", got, want)
+	}
+	if got := decay.Until(control, 0.4, 0.5); !got.IsZero() {
+		t.Errorf("until for effectiveness below threshold = %s", got)
+	}
+}
+
+func TestDecayModel(t *testing.T) {
+	now := time.Now()
+	model, err := ParseScoringModel("decay.yaml", []byte("model: default\ndecay: {halfLifeDays: 30, graceDays: 7}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(model.Name(), "default+decay@") {
+		t.Errorf("name = %q", model.Name())
+	}
+
+	validator := NewControlValidator()
+	validator.SetScoringModel(model)
+	validator.AddControl(SecurityControl{ID: "fresh", Status: StatusImplemented, Owner: "o", Evidence: []string{"e"}, LastVerified: now})
+	validator.AddControl(SecurityControl{ID: "aging", Status: StatusImplemented, Owner: "o", Evidence: []string{"e"}, LastVerified: now.AddDate(0, 0, -40)})
+
+	fresh := validator.ValidateControl("fresh")
+	if fresh.Status != "EFFECTIVE" || !fresh.ProjectedEffectiveUntil.Equal(now.AddDate(0, 0, 7)) {
+		t.Errorf("fresh = %+v", fresh)
+	}
+	aging := validator.ValidateControl("aging")
+	if aging.Effectiveness >= fresh.Effectiveness || aging.Confidence >= fresh.Confidence || aging.Status == "EFFECTIVE" {
+		t.Errorf("aging control must score lower: %+v", aging)
+	}
+	if !aging.ProjectedEffectiveUntil.Before(now) {
+		t.Errorf("aging control's projection must have passed, got %s", aging.ProjectedEffectiveUntil)
+	}
+
+	for _, data := range []string{"decay: {}", "decay: {halfLifeDays: 10, categories: {detective: 0}}", "decay: {halfLifeDays: 10, graceDays: -1}"} {
+		if _, err := ParseScoringModel("bad.yaml", []byte(data)); err == nil {
+			t.Errorf("%s: expected error", data)
+		}
+	}
+}
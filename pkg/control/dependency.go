@@ -0,0 +1,126 @@
+package control
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/graph"
+)
+
+// IssueDependencyIneffective is the code of the issue reported when a
+// control depends on an ineffective control.
+const IssueDependencyIneffective = "SC-DEP-001"
+
+// DependencyPenalty is the share of its effectiveness a control loses for
+// each ineffective control it depends on.
+const DependencyPenalty = 0.5
+
+func init() {
+	RegisterIssue(IssueDefinition{
+		Code:        IssueDependencyIneffective,
+		Title:       "Dependency ineffective",
+		Severity:    SeverityHigh,
+		Field:       "DependsOn",
+		Message:     "Depends on ineffective control %s",
+		Remediation: "Restore the effectiveness of control %s",
+		References:  []string{"NIST SP 800-53 Rev. 5 SA-8", "NIST SP 800-53 Rev. 5 SR-3"},
+	})
+}
+
+// DependencyGraph returns the graph of controls and the controls they
+// depend on, labeled with their IDs and names.
+func DependencyGraph(controls []SecurityControl) *graph.Graph {
+	g := graph.New()
+	for _, c := range controls {
+		label := c.ID
+		if c.Name != "" {
+			label += "\n" + c.Name
+		}
+		g.AddNode(c.ID, label)
+	}
+	for _, c := range controls {
+		for _, id := range c.DependsOn {
+			g.AddEdge(c.ID, id)
+		}
+	}
+	return g
+}
+
+// cascade degrades the results of controls that depend on ineffective
+// controls. Controls are visited in dependency order, so a control that
+// becomes ineffective this way degrades its own dependents in turn.
+// Controls on a dependency cycle, and those depending on them, are left as
+// they are.
+func (v *ControlValidator) cascade(controls []SecurityControl, results []ControlValidationResult) {
+	g := DependencyGraph(controls)
+	order, _ := g.Order()
+	byID := make(map[string]*ControlValidationResult, len(results))
+	for i := range results {
+		byID[results[i].ControlID] = &results[i]
+	}
+	upstream := func(id string) *ControlValidationResult {
+		return byID[id]
+	}
+
+	for _, id := range order {
+		if result := byID[id]; result != nil {
+			v.degrade(result, g.DependsOn(id), upstream)
+		}
+	}
+}
+
+// degrade applies the results of the controls a control depends on to its
+// result. Each ineffective dependency costs DependencyPenalty of the
+// effectiveness and adds an issue, unless the issue is suppressed for the
+// control.
+func (v *ControlValidator) degrade(result *ControlValidationResult, deps []string, upstream func(id string) *ControlValidationResult) {
+	for _, dep := range deps {
+		r := upstream(dep)
+		if r == nil || r.Status != "INEFFECTIVE" {
+			continue
+		}
+		issue := NewIssue(IssueDependencyIneffective, dep)
+		if v.suppressed(result.ControlID, issue) {
+			continue
+		}
+		result.DegradedBy = append(result.DegradedBy, dep)
+		result.Issues = append(result.Issues, issue)
+		result.Recommendations = append(result.Recommendations, issue.Remediation)
+		result.Effectiveness *= 1 - DependencyPenalty
+	}
+	if len(result.DegradedBy) > 0 {
+		result.Status = statusFor(result.Effectiveness, false, v.ScoringModel().Thresholds())
+	}
+}
+
+// dependencyResult returns the latest result of a control a control being
+// validated depends on. A control without a result is validated, along
+// with the controls it depends on, but the result is not kept. visiting
+// holds the controls being validated, so that a cycle ends the walk.
+func (v *ControlValidator) dependencyResult(id string, visiting map[string]bool) *ControlValidationResult {
+	v.mu.RLock()
+	for i := len(v.results) - 1; i >= 0; i-- {
+		if v.results[i].ControlID == id {
+			result := v.results[i]
+			v.mu.RUnlock()
+			return &result
+		}
+	}
+	v.mu.RUnlock()
+
+	control := GetControl(v, id)
+	if control == nil || visiting[id] {
+		return nil
+	}
+	return v.validateWithDependencies(*control, visiting)
+}
+
+// validateWithDependencies validates a control and degrades its result by
+// the latest results of the controls it depends on.
+func (v *ControlValidator) validateWithDependencies(control SecurityControl, visiting map[string]bool) *ControlValidationResult {
+	visiting[control.ID] = true
+	defer delete(visiting, control.ID)
+
+	result := v.validateControl(control)
+	v.degrade(result, control.DependsOn, func(id string) *ControlValidationResult {
+		return v.dependencyResult(id, visiting)
+	})
+	return result
+}
@@ -0,0 +1,96 @@
+package control
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/engine"
+)
+
+// dependentControls returns a validator of controls in which idp is
+// ineffective, mfa depends on it, and sso and logs depend on mfa.
+func dependentControls() *ControlValidator {
+	verified := time.Now().AddDate(0, -1, 0)
+	controls := []SecurityControl{
+		{ID: "sso", Name: "SSO", Status: StatusImplemented, Owner: "it", Evidence: []string{"x"}, LastVerified: verified, DependsOn: []string{"mfa"}},
+		{ID: "mfa", Name: "MFA", Status: StatusImplemented, Owner: "it", Evidence: []string{"x"}, LastVerified: verified, DependsOn: []string{"idp"}},
+		{ID: "idp", Name: "IdP", Status: StatusNotImplemented, Owner: "it", Evidence: []string{"x"}, LastVerified: verified},
+		{ID: "logs", Name: "Logging", Status: StatusImplemented, Owner: "it", Evidence: []string{"x"}, LastVerified: verified, DependsOn: []string{"mfa"}},
+	}
+	validator := NewControlValidator()
+	for _, c := range controls {
+		validator.AddControl(c)
+	}
+	return validator
+}
+
+func TestCascade(t *testing.T) {
+	validator := dependentControls()
+	validator.Suppress(IssueDependencyIneffective, "logs")
+
+	results, err := validator.ValidateAll(context.Background(), engine.Options{Workers: 4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := make(map[string]ControlValidationResult)
+	for _, r := range results {
+		byID[r.ControlID] = r
+	}
+
+	mfa := byID["mfa"]
+	if mfa.Status != "INEFFECTIVE" || mfa.Effectiveness != 0.45 || !reflect.DeepEqual(mfa.DegradedBy, []string{"idp"}) {
+		t.Errorf("mfa = %s %.2f %v", mfa.Status, mfa.Effectiveness, mfa.DegradedBy)
+	}
+	if len(mfa.Issues) != 1 || mfa.Issues[0].Code != IssueDependencyIneffective || mfa.Issues[0].Message != "Depends on ineffective control idp" {
+		t.Errorf("mfa issues = %v", mfa.Issues)
+	}
+
+	// The degradation cascades through mfa
+	sso := byID["sso"]
+	if !reflect.DeepEqual(sso.DegradedBy, []string{"mfa"}) || sso.Effectiveness != 0.45 {
+		t.Errorf("sso = %s %.3f %v", sso.Status, sso.Effectiveness, sso.DegradedBy)
+	}
+
+	// Suppressing the issue keeps the control from being degraded
+	logs := byID["logs"]
+	if logs.Status != "EFFECTIVE" || len(logs.DegradedBy) != 0 {
+		t.Errorf("logs = %s %v", logs.Status, logs.DegradedBy)
+	}
+}
+
+func TestValidateControlCascade(t *testing.T) {
+	// Dependencies without results are validated, but not kept
+	validator := dependentControls()
+	sso := validator.ValidateControl("sso")
+	if sso.Status != "INEFFECTIVE" || sso.Effectiveness != 0.45 || !reflect.DeepEqual(sso.DegradedBy, []string{"mfa"}) {
+		t.Errorf("sso = %s %.2f %v", sso.Status, sso.Effectiveness, sso.DegradedBy)
+	}
+	if results := validator.GetValidationResults(); len(results) != 1 {
+		t.Errorf("kept %d results, want 1", len(results))
+	}
+
+	// The latest result of a dependency is used
+	validator = dependentControls()
+	validator.AddValidationResults(
+		ControlValidationResult{ControlID: "mfa", Status: "INEFFECTIVE"},
+		ControlValidationResult{ControlID: "mfa", Status: "EFFECTIVE"},
+	)
+	if sso := validator.ValidateControl("sso"); sso.Status != "EFFECTIVE" || len(sso.DegradedBy) != 0 {
+		t.Errorf("sso = %s %v", sso.Status, sso.DegradedBy)
+	}
+}
+
+func TestDependencyGraph(t *testing.T) {
+	g := DependencyGraph([]SecurityControl{
+		{ID: "a", Name: "A", DependsOn: []string{"b"}},
+		{ID: "b", DependsOn: []string{"a"}},
+	})
+	if n, _ := g.Node("a"); n.Label != "a\nA" {
+		t.Errorf("label = %q", n.Label)
+	}
+	if cycles := g.Cycles(); !reflect.DeepEqual(cycles, [][]string{{"a", "b"}}) {
+		t.Errorf("cycles = %v", cycles)
+	}
+}
@@ -0,0 +1,252 @@
+package control
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Severity represents the severity of an issue.
+type Severity string
+
+const (
+	SeverityCritical Severity = "critical"
+	SeverityHigh     Severity = "high"
+	SeverityMedium   Severity = "medium"
+	SeverityLow      Severity = "low"
+	SeverityInfo     Severity = "info"
+)
+
+// Rank orders severities from info (1) to critical (5). Unknown severities
+// rank 0.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityCritical:
+		return 5
+	case SeverityHigh:
+		return 4
+	case SeverityMedium:
+		return 3
+	case SeverityLow:
+		return 2
+	case SeverityInfo:
+		return 1
+	}
+	return 0
+}
+
+// ParseSeverity parses a severity name.
+func ParseSeverity(s string) (Severity, error) {
+	severity := Severity(strings.ToLower(s))
+	if severity.Rank() == 0 {
+		return "", fmt.Errorf("unknown severity %q (valid: critical, high, medium, low, info)", s)
+	}
+	return severity, nil
+}
+
+// Codes of the built-in issues. The evidence, verification and owner
+// issues are defined by the built-in rules in rules.yaml.
+const (
+	IssueNoEvidence  = "SC-EVID-001"
+	IssueNotVerified = "SC-VERIF-001"
+	IssueNoOwner     = "SC-OWNER-001"
+	IssueTestsNotRun = "SC-TEST-001"
+	IssueTestFailed  = "SC-TEST-002"
+)
+
+// Issue represents a problem found while validating a control.
+type Issue struct {
+	Code        string
+	Severity    Severity
+	Message     string
+	Field       string
+	Remediation string
+	References  []string
+}
+
+// String returns the issue's code and message.
+func (i Issue) String() string {
+	if i.Code == "" {
+		return i.Message
+	}
+	return i.Code + ": " + i.Message
+}
+
+// IssueDefinition describes an issue code. Message and Remediation are
+// format strings whose %s verbs are filled from the arguments of New.
+type IssueDefinition struct {
+	Code        string
+	Title       string
+	Severity    Severity
+	Field       string
+	Message     string
+	Remediation string
+	References  []string
+
+	pattern *regexp.Regexp
+}
+
+// New creates an issue from the definition.
+func (d IssueDefinition) New(args ...interface{}) Issue {
+	return Issue{
+		Code:        d.Code,
+		Severity:    d.Severity,
+		Message:     fmt.Sprintf(d.Message, args...),
+		Field:       d.Field,
+		Remediation: fmt.Sprintf(d.Remediation, args...),
+		References:  append([]string(nil), d.References...),
+	}
+}
+
+// match returns the arguments a message was formatted with.
+func (d IssueDefinition) match(message string) ([]interface{}, bool) {
+	m := d.pattern.FindStringSubmatch(message)
+	if m == nil {
+		return nil, false
+	}
+	args := make([]interface{}, len(m)-1)
+	for i, s := range m[1:] {
+		args[i] = s
+	}
+	return args, true
+}
+
+var (
+	issuesMu sync.RWMutex
+	issues   = make(map[string]IssueDefinition)
+)
+
+func init() {
+	for _, def := range []IssueDefinition{
+		{
+			Code:        IssueTestsNotRun,
+			Title:       "Linked tests not run",
+			Severity:    SeverityMedium,
+			Field:       "Tests",
+			Message:     "No results recorded for linked tests",
+			Remediation: "Run the control's linked tests",
+			References:  []string{"NIST SP 800-53 Rev. 5 CA-2"},
+		},
+		{
+			Code:        IssueTestFailed,
+			Title:       "Linked test failed",
+			Severity:    SeverityHigh,
+			Field:       "Tests",
+			Message:     "Linked test %s failed",
+			Remediation: "Investigate and fix failing test %s",
+			References:  []string{"NIST SP 800-53 Rev. 5 CA-2", "NIST SP 800-53 Rev. 5 CA-5"},
+		},
+	} {
+		RegisterIssue(def)
+	}
+}
+
+// RegisterIssue registers an issue definition, replacing any definition
+// with the same code. Only %s verbs may be used in its format strings.
+func RegisterIssue(def IssueDefinition) {
+	pattern := regexp.QuoteMeta(def.Message)
+	pattern = strings.ReplaceAll(pattern, "%s", "(.+?)")
+	def.pattern = regexp.MustCompile("^" + pattern + "$")
+
+	issuesMu.Lock()
+	defer issuesMu.Unlock()
+	issues[def.Code] = def
+}
+
+// LookupIssue returns the definition of an issue code.
+func LookupIssue(code string) (IssueDefinition, bool) {
+	issuesMu.RLock()
+	defer issuesMu.RUnlock()
+	def, ok := issues[code]
+	return def, ok
+}
+
+// IssueDefinitions returns every registered definition, sorted by code.
+func IssueDefinitions() []IssueDefinition {
+	issuesMu.RLock()
+	defs := make([]IssueDefinition, 0, len(issues))
+	for _, def := range issues {
+		defs = append(defs, def)
+	}
+	issuesMu.RUnlock()
+
+	sort.Slice(defs, func(i, j int) bool {
+		return defs[i].Code < defs[j].Code
+	})
+	return defs
+}
+
+// NewIssue creates an issue from a registered definition. It panics if the
+// code is not registered.
+func NewIssue(code string, args ...interface{}) Issue {
+	def, ok := LookupIssue(code)
+	if !ok {
+		panic("control: unregistered issue code " + code)
+	}
+	return def.New(args...)
+}
+
+// ResolveIssue restores an issue recorded by its code and message, filling
+// in the remaining fields from the code's definition. The remediation is
+// only restored when the message matches the definition. Unknown codes keep
+// only the code and message.
+func ResolveIssue(code, message string) Issue {
+	def, ok := LookupIssue(code)
+	if !ok {
+		return Issue{Code: code, Message: message}
+	}
+	issue := Issue{
+		Code:       def.Code,
+		Severity:   def.Severity,
+		Message:    message,
+		Field:      def.Field,
+		References: append([]string(nil), def.References...),
+	}
+	if args, ok := def.match(message); ok {
+		issue.Remediation = fmt.Sprintf(def.Remediation, args...)
+	}
+	return issue
+}
+
+// issueCode matches the code prefix written by Issue.String.
+var issueCode = regexp.MustCompile(`^([A-Z][A-Z0-9]*(?:-[A-Z0-9]+)+): (.*)$`)
+
+// ParseIssue parses an issue written by Issue.String. Messages without a
+// code, as recorded before issues had codes, are matched against the
+// registered definitions.
+func ParseIssue(s string) Issue {
+	if m := issueCode.FindStringSubmatch(s); m != nil {
+		return ResolveIssue(m[1], m[2])
+	}
+	for _, def := range IssueDefinitions() {
+		if args, ok := def.match(s); ok {
+			return def.New(args...)
+		}
+	}
+	return Issue{Message: s}
+}
+
+// SortIssues sorts issues by descending severity, then by code. Issues of
+// equal severity and code keep their order.
+func SortIssues(list []Issue) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if a, b := list[i].Severity.Rank(), list[j].Severity.Rank(); a != b {
+			return a > b
+		}
+		return list[i].Code < list[j].Code
+	})
+}
+
+// MaxSeverity returns the highest severity of the issues, or "" if there
+// are none.
+func MaxSeverity(list []Issue) Severity {
+	var max Severity
+	for _, issue := range list {
+		if issue.Severity.Rank() > max.Rank() {
+			max = issue.Severity
+		}
+	}
+	return max
+}
@@ -0,0 +1,167 @@
+package control
+
+import (
+	"sort"
+
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// ResultColumns are the columns of a report section of control validation
+// results.
+var ResultColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "name", Title: "Name", Kind: report.KindString},
+	{Key: "status", Title: "Status", Kind: report.KindString},
+	{Key: "effectiveness", Title: "Effectiveness", Kind: report.KindPercent},
+	{Key: "confidence", Title: "Confidence", Kind: report.KindPercent},
+	{Key: "untested", Title: "Untested", Kind: report.KindBool},
+	{Key: "testsLinked", Title: "Tests Linked", Kind: report.KindNumber},
+	{Key: "testsPassed", Title: "Tests Passed", Kind: report.KindNumber},
+	{Key: "testsFailed", Title: "Tests Failed", Kind: report.KindNumber},
+	{Key: "issues", Title: "Issues", Kind: report.KindList},
+	{Key: "degradedBy", Title: "Degraded By", Kind: report.KindList},
+	{Key: "recommendations", Title: "Recommendations", Kind: report.KindList},
+	{Key: "scoringModel", Title: "Scoring Model", Kind: report.KindString},
+	{Key: "projectedEffectiveUntil", Title: "Projected Effective Until", Kind: report.KindTime},
+	{Key: "validatedAt", Title: "Validated At", Kind: report.KindTime},
+}
+
+// IssueColumns are the columns of a report section of control issues.
+var IssueColumns = []report.Column{
+	{Key: "controlId", Title: "Control", Kind: report.KindString},
+	{Key: "code", Title: "Code", Kind: report.KindString},
+	{Key: "severity", Title: "Severity", Kind: report.KindString},
+	{Key: "field", Title: "Field", Kind: report.KindString},
+	{Key: "message", Title: "Message", Kind: report.KindString},
+	{Key: "remediation", Title: "Remediation", Kind: report.KindString},
+	{Key: "references", Title: "References", Kind: report.KindList},
+}
+
+// ControlColumns are the columns of a report section listing controls.
+var ControlColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "name", Title: "Name", Kind: report.KindString},
+	{Key: "category", Title: "Category", Kind: report.KindString},
+	{Key: "type", Title: "Type", Kind: report.KindString},
+	{Key: "status", Title: "Status", Kind: report.KindString},
+	{Key: "riskReduction", Title: "Risk Reduction", Kind: report.KindPercent},
+	{Key: "owner", Title: "Owner", Kind: report.KindString},
+	{Key: "lastVerified", Title: "Last Verified", Kind: report.KindTime},
+	{Key: "nextReview", Title: "Next Review", Kind: report.KindTime},
+	{Key: "tests", Title: "Tests", Kind: report.KindList},
+	{Key: "dependsOn", Title: "Depends On", Kind: report.KindList},
+}
+
+// BuildReport builds a structured report of the validator's results.
+func BuildReport(validator *ControlValidator) *report.Report {
+	r := report.New("control-validation", "Security Control Validation Report")
+	AddResults(r, validator.GetValidationResults())
+	return r
+}
+
+// AddResults adds a summary, a "controls" section of validation results and
+// an "issues" section listing every issue by descending severity.
+func AddResults(r *report.Report, results []ControlValidationResult) {
+	var issueRows []issueRow
+	counts := make(map[string]int)
+	untested := 0
+	total := 0.0
+	for _, result := range results {
+		counts[result.Status]++
+		total += result.Effectiveness
+		if result.Untested {
+			untested++
+		}
+		for _, issue := range result.Issues {
+			issueRows = append(issueRows, issueRow{controlID: result.ControlID, issue: issue})
+		}
+	}
+	average := 0.0
+	if len(results) > 0 {
+		average = total / float64(len(results))
+	}
+
+	r.AddSummary("controls", len(results))
+	r.AddSummary("effective", counts["EFFECTIVE"])
+	r.AddSummary("partiallyEffective", counts["PARTIALLY_EFFECTIVE"])
+	r.AddSummary("ineffective", counts["INEFFECTIVE"])
+	r.AddSummary("untested", untested)
+	r.AddSummaryKind("averageEffectiveness", average, report.KindPercent)
+	r.AddSummary("issues", len(issueRows))
+	if len(results) > 0 {
+		r.AddSummary("scoringModel", results[0].ScoringModel)
+	}
+
+	section := r.AddSection("controls", "Validation Results", ResultColumns...)
+	for _, result := range results {
+		section.AddRow(
+			result.ControlID,
+			result.ControlName,
+			result.Status,
+			result.Effectiveness,
+			result.Confidence,
+			result.Untested,
+			result.TestsLinked,
+			result.TestsPassed,
+			result.TestsFailed,
+			issueStrings(result.Issues),
+			nonNil(result.DegradedBy),
+			nonNil(result.Recommendations),
+			result.ScoringModel,
+			result.ProjectedEffectiveUntil,
+			result.ValidatedAt,
+		)
+	}
+
+	sort.SliceStable(issueRows, func(i, j int) bool {
+		return issueRows[i].issue.Severity.Rank() > issueRows[j].issue.Severity.Rank()
+	})
+	issues := r.AddSection("issues", "Issues", IssueColumns...)
+	for _, row := range issueRows {
+		issue := row.issue
+		issues.AddRow(row.controlID, issue.Code, string(issue.Severity), issue.Field, issue.Message, issue.Remediation, nonNil(issue.References))
+	}
+}
+
+// issueRow is an issue of a control.
+type issueRow struct {
+	controlID string
+	issue     Issue
+}
+
+// issueStrings returns issues as "CODE: message" strings.
+func issueStrings(issues []Issue) []string {
+	list := make([]string, len(issues))
+	for i, issue := range issues {
+		list[i] = issue.String()
+	}
+	return list
+}
+
+// AddControls adds a "catalog" section listing controls to a report.
+func AddControls(r *report.Report, controls []SecurityControl) {
+	section := r.AddSection("catalog", "Controls", ControlColumns...)
+	for _, ctrl := range controls {
+		section.AddRow(
+			ctrl.ID,
+			ctrl.Name,
+			string(ctrl.Category),
+			string(ctrl.Type),
+			string(ctrl.Status),
+			ctrl.RiskReduction,
+			ctrl.Owner,
+			ctrl.LastVerified,
+			ctrl.NextReview,
+			nonNil(ctrl.Tests),
+			nonNil(ctrl.DependsOn),
+		)
+	}
+}
+
+// nonNil returns an empty list for nil so that it encodes as [].
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
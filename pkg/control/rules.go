@@ -0,0 +1,163 @@
+package control
+
+import (
+	_ "embed"
+	"fmt"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/rules"
+)
+
+//go:embed rules.yaml
+var builtinRulesYAML []byte
+
+// IssueRuleFailed is the code of the issue reported when a rule cannot be
+// evaluated against a control.
+const IssueRuleFailed = "SC-RULE-001"
+
+// RuleSchema lists the control fields available to rule expressions, named
+// as in catalog files.
+var RuleSchema = rules.Schema{
+	"id":             rules.KindString,
+	"name":           rules.KindString,
+	"description":    rules.KindString,
+	"category":       rules.KindString,
+	"type":           rules.KindString,
+	"subCategory":    rules.KindString,
+	"riskReduction":  rules.KindNumber,
+	"implementation": rules.KindString,
+	"verification":   rules.KindString,
+	"maintenance":    rules.KindString,
+	"owner":          rules.KindString,
+	"status":         rules.KindString,
+	"lastVerified":   rules.KindTime,
+	"nextReview":     rules.KindTime,
+	"evidence":       rules.KindList,
+	"references":     rules.KindList,
+	"tests":          rules.KindList,
+	"techniques":     rules.KindList,
+	"dependsOn":      rules.KindList,
+}
+
+// builtinRules are the rules applied when no rules files are loaded.
+var builtinRules *rules.Set
+
+func init() {
+	set, err := rules.Parse("rules.yaml", builtinRulesYAML, RuleSchema)
+	if err != nil {
+		panic("control: built-in rules: " + err.Error())
+	}
+	if err := registerRuleIssues(set); err != nil {
+		panic("control: built-in rules: " + err.Error())
+	}
+	builtinRules = set
+
+	RegisterIssue(IssueDefinition{
+		Code:        IssueRuleFailed,
+		Title:       "Rule evaluation failed",
+		Severity:    SeverityLow,
+		Message:     "Rule %s could not be evaluated: %s",
+		Remediation: "Correct the condition of rule %s (%s)",
+	})
+}
+
+// BuiltinRules returns the built-in issue detection rules.
+func BuiltinRules() *rules.Set {
+	return builtinRules
+}
+
+// LoadRules loads rules files and merges them over the built-in rules, in
+// order. The issue codes of the loaded rules are registered so they can be
+// suppressed and resolved like the built-in ones.
+func LoadRules(paths ...string) (*rules.Set, error) {
+	loaded, err := rules.Load(RuleSchema, paths...)
+	if err != nil {
+		return nil, err
+	}
+	if err := registerRuleIssues(loaded); err != nil {
+		return nil, err
+	}
+	return builtinRules.Merge(loaded), nil
+}
+
+// registerRuleIssues registers an issue definition for each active rule.
+func registerRuleIssues(set *rules.Set) error {
+	var defs []IssueDefinition
+	for _, rule := range set.Active() {
+		severity, err := ParseSeverity(rule.Issue.Severity)
+		if err != nil {
+			return fmt.Errorf("rule %s: %w", rule.ID, err)
+		}
+		defs = append(defs, IssueDefinition{
+			Code:        rule.Issue.Code,
+			Title:       rule.Description,
+			Severity:    severity,
+			Field:       rule.Issue.Field,
+			Message:     rule.Issue.Message,
+			Remediation: rule.Issue.Remediation,
+			References:  rule.Issue.References,
+		})
+	}
+	for _, def := range defs {
+		RegisterIssue(def)
+	}
+	return nil
+}
+
+// RuleEnv returns the fields of a control as a rule environment.
+func RuleEnv(control SecurityControl) rules.Env {
+	return rules.Env{
+		"id":             control.ID,
+		"name":           control.Name,
+		"description":    control.Description,
+		"category":       string(control.Category),
+		"type":           string(control.Type),
+		"subCategory":    control.SubCategory,
+		"riskReduction":  control.RiskReduction,
+		"implementation": control.Implementation,
+		"verification":   control.Verification,
+		"maintenance":    control.Maintenance,
+		"owner":          control.Owner,
+		"status":         string(control.Status),
+		"lastVerified":   control.LastVerified,
+		"nextReview":     control.NextReview,
+		"evidence":       ruleList(control.Evidence),
+		"references":     ruleList(control.References),
+		"tests":          ruleList(control.Tests),
+		"techniques":     ruleList(control.Techniques),
+		"dependsOn":      ruleList(control.DependsOn),
+	}
+}
+
+// ruleList converts a string slice to a rule list value.
+func ruleList(items []string) []interface{} {
+	list := make([]interface{}, len(items))
+	for i, item := range items {
+		list[i] = item
+	}
+	return list
+}
+
+// ruleIssues evaluates rules against a control and returns the issues of
+// the rules that fired. Rules that fail to evaluate are reported as
+// IssueRuleFailed.
+func ruleIssues(set *rules.Set, control SecurityControl, now time.Time) []Issue {
+	var issues []Issue
+	for _, match := range set.Evaluate(RuleEnv(control), now) {
+		if match.Err != nil {
+			issues = append(issues, NewIssue(IssueRuleFailed, match.Rule.ID, match.Err))
+			continue
+		}
+		spec := match.Rule.Issue
+		severity, _ := ParseSeverity(spec.Severity)
+		issues = append(issues, Issue{
+			Code:        spec.Code,
+			Severity:    severity,
+			Message:     spec.Message,
+			Field:       spec.Field,
+			Remediation: spec.Remediation,
+			References:  append([]string(nil), spec.References...),
+		})
+	}
+	return issues
+}
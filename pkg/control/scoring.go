@@ -0,0 +1,390 @@
+package control
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ScoreInput is what a scoring model knows about a control when scoring it.
+type ScoreInput struct {
+	Control SecurityControl
+	// Issues are the control's issues after suppression.
+	Issues      []Issue
+	TestsLinked int
+	TestsPassed int
+	TestsFailed int
+	TestRuns    int
+	// TestScore weighs each tested linked test's latest outcome at 70% and
+	// its pass rate at 30%, averaged over the tested linked tests.
+	TestScore float64
+	Now       time.Time
+}
+
+// Untested reports whether none of the control's linked tests have results.
+func (in ScoreInput) Untested() bool {
+	return in.TestRuns == 0
+}
+
+// Score is the outcome of scoring a control.
+type Score struct {
+	Effectiveness float64
+	Confidence    float64
+	Status        string
+	// EffectiveUntil is when the control is projected to stop being
+	// effective, or zero if the model makes no projection.
+	EffectiveUntil time.Time
+}
+
+// ScoringModel computes a control's effectiveness, confidence and status.
+type ScoringModel interface {
+	// Name identifies the model and its configuration, and is recorded
+	// with each result.
+	Name() string
+	Score(in ScoreInput) Score
+	// Thresholds returns the effectiveness the model requires for each
+	// status.
+	Thresholds() Thresholds
+}
+
+// DefaultScoringModel returns the built-in scoring model.
+func DefaultScoringModel() ScoringModel {
+	return defaultModel{}
+}
+
+// defaultModel scores controls on their declared status and linked test
+// results, discounting confidence for each issue.
+type defaultModel struct{}
+
+func (defaultModel) Name() string {
+	return "default"
+}
+
+func (defaultModel) Thresholds() Thresholds {
+	return Thresholds{Effective: 0.9, Partial: 0.7}
+}
+
+func (m defaultModel) Score(in ScoreInput) Score {
+	// Linked test results take precedence over the declared status
+	effective := statusEffectiveness(in.Control.Status)
+	if !in.Untested() {
+		effective = implementationFactor(in.Control.Status) * in.TestScore
+	}
+
+	// Confidence in a control with linked tests is capped at 70% until the
+	// tests have enough results
+	confidence := m.confidence(in)
+	if in.TestsLinked > 0 {
+		confidence *= 0.7
+	}
+	if !in.Untested() {
+		coverage := float64(in.TestsPassed+in.TestsFailed) / float64(in.TestsLinked)
+		confidence += 0.3 * coverage * math.Min(1.0, float64(in.TestRuns)/3.0)
+	}
+
+	return Score{
+		Effectiveness: effective,
+		Confidence:    confidence,
+		Status:        statusFor(effective, len(in.Issues) == 0, m.Thresholds()),
+	}
+}
+
+// confidence rates confidence from a control's evidence and issues.
+func (defaultModel) confidence(in ScoreInput) float64 {
+	confidence := 0.5
+
+	// Adjust confidence based on evidence
+	if len(in.Control.Evidence) > 0 {
+		confidence += 0.2
+	}
+
+	// Adjust confidence based on issues
+	if len(in.Issues) == 0 {
+		confidence += 0.3
+	} else {
+		confidence -= float64(len(in.Issues)) * 0.1
+	}
+
+	return clamp(confidence)
+}
+
+// statusEffectiveness is the effectiveness of an untested control with the
+// given status.
+func statusEffectiveness(status ControlStatus) float64 {
+	switch status {
+	case StatusImplemented:
+		return 0.9
+	case StatusPartiallyImplemented:
+		return 0.6
+	case StatusNotImplemented:
+		return 0.0
+	}
+	return 0.7
+}
+
+// statusFor returns the status for an effectiveness. Only controls without
+// issues are effective.
+func statusFor(effectiveness float64, clean bool, thresholds Thresholds) string {
+	if clean && effectiveness >= thresholds.Effective {
+		return "EFFECTIVE"
+	} else if effectiveness >= thresholds.Partial {
+		return "PARTIALLY_EFFECTIVE"
+	}
+	return "INEFFECTIVE"
+}
+
+// clamp limits a score to [0, 1].
+func clamp(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
+
+// Weights are the relative weights of the factors of the weighted model.
+type Weights struct {
+	Status       float64 `yaml:"status"`
+	Evidence     float64 `yaml:"evidence"`
+	Tests        float64 `yaml:"tests"`
+	Verification float64 `yaml:"verification"`
+	Issues       float64 `yaml:"issues"`
+}
+
+// Thresholds are the effectiveness a control needs to be rated effective or
+// partially effective.
+type Thresholds struct {
+	Effective float64 `yaml:"effective"`
+	Partial   float64 `yaml:"partial"`
+}
+
+// WeightedModel scores a control as the weighted average of factors in
+// [0, 1]:
+//
+//   - status: the factor for the control's implementation status
+//   - evidence: the evidence count relative to EvidenceTarget
+//   - tests: the linked test score, left out while the control is untested
+//   - verification: one when just verified, falling linearly to zero at
+//     VerificationMaxAgeDays
+//   - issues: one less the sum of the severity penalties of its issues
+//
+// Confidence is the weighted average of the evidence, verification and
+// issues factors and of test coverage, which counts for nothing until the
+// linked tests have three results.
+type WeightedModel struct {
+	ModelName              string               `yaml:"name,omitempty"`
+	Weights                Weights              `yaml:"weights"`
+	StatusFactors          map[string]float64   `yaml:"statusFactors,omitempty"`
+	EvidenceTarget         int                  `yaml:"evidenceTarget,omitempty"`
+	VerificationMaxAgeDays int                  `yaml:"verificationMaxAgeDays,omitempty"`
+	SeverityPenalties      map[Severity]float64 `yaml:"severityPenalties,omitempty"`
+	StatusThresholds       Thresholds           `yaml:"thresholds,omitempty"`
+
+	digest string
+}
+
+// NewWeightedModel returns a weighted model with the given weights and the
+// default factors. Zero weights select the default weights.
+func NewWeightedModel(weights Weights) *WeightedModel {
+	m := &WeightedModel{Weights: weights}
+	m.setDefaults()
+	return m
+}
+
+// setDefaults fills in the settings a model file left out and records the
+// digest of the resulting configuration.
+func (m *WeightedModel) setDefaults() {
+	if m.ModelName == "" {
+		m.ModelName = "weighted"
+	}
+	if m.Weights == (Weights{}) {
+		m.Weights = Weights{Status: 0.3, Evidence: 0.15, Tests: 0.35, Verification: 0.1, Issues: 0.1}
+	}
+	if m.StatusFactors == nil {
+		m.StatusFactors = map[string]float64{
+			string(StatusImplemented):          1.0,
+			string(StatusPartiallyImplemented): 0.6,
+			string(StatusNotImplemented):       0.0,
+			string(StatusDeprecated):           0.5,
+		}
+	}
+	if m.EvidenceTarget == 0 {
+		m.EvidenceTarget = 2
+	}
+	if m.VerificationMaxAgeDays == 0 {
+		m.VerificationMaxAgeDays = 180
+	}
+	if m.SeverityPenalties == nil {
+		m.SeverityPenalties = map[Severity]float64{
+			SeverityCritical: 1.0,
+			SeverityHigh:     0.5,
+			SeverityMedium:   0.25,
+			SeverityLow:      0.1,
+			SeverityInfo:     0.0,
+		}
+	}
+	if m.StatusThresholds == (Thresholds{}) {
+		m.StatusThresholds = Thresholds{Effective: 0.9, Partial: 0.7}
+	}
+
+	// yaml.v3 sorts map keys, so equal configurations share a digest
+	data, _ := yaml.Marshal(m)
+	sum := sha256.Sum256(data)
+	m.digest = hex.EncodeToString(sum[:])[:12]
+}
+
+// validate checks the model's settings.
+func (m *WeightedModel) validate() error {
+	w := m.Weights
+	for name, weight := range map[string]float64{"status": w.Status, "evidence": w.Evidence, "tests": w.Tests, "verification": w.Verification, "issues": w.Issues} {
+		if weight < 0 {
+			return fmt.Errorf("weight %s must not be negative", name)
+		}
+	}
+	for status, factor := range m.StatusFactors {
+		if factor < 0 || factor > 1 {
+			return fmt.Errorf("status factor %s must be between 0 and 1", status)
+		}
+	}
+	for severity := range m.SeverityPenalties {
+		if severity.Rank() == 0 {
+			return fmt.Errorf("unknown severity %q in severity penalties", severity)
+		}
+	}
+	if m.EvidenceTarget < 0 || m.VerificationMaxAgeDays < 0 {
+		return errors.New("evidence target and verification max age must not be negative")
+	}
+	if t := m.StatusThresholds; t.Partial < 0 || t.Effective > 1 || t.Partial > t.Effective {
+		return errors.New("thresholds must satisfy 0 <= partial <= effective <= 1")
+	}
+	return nil
+}
+
+// Name returns the model's name and the digest of its configuration.
+func (m *WeightedModel) Name() string {
+	return m.ModelName + "@" + m.digest
+}
+
+// Thresholds returns the model's status thresholds.
+func (m *WeightedModel) Thresholds() Thresholds {
+	return m.StatusThresholds
+}
+
+// Score scores a control.
+func (m *WeightedModel) Score(in ScoreInput) Score {
+	w := m.Weights
+	evidence := m.evidenceFactor(in)
+	verification := m.verificationFactor(in)
+	issues := m.issuesFactor(in)
+
+	sum := w.Status*m.statusFactor(in) + w.Evidence*evidence + w.Verification*verification + w.Issues*issues
+	total := w.Status + w.Evidence + w.Verification + w.Issues
+	if !in.Untested() {
+		sum += w.Tests * in.TestScore
+		total += w.Tests
+	}
+	effective := 0.0
+	if total > 0 {
+		effective = sum / total
+	}
+
+	coverage := 0.0
+	if !in.Untested() {
+		coverage = float64(in.TestsPassed+in.TestsFailed) / float64(in.TestsLinked) * math.Min(1.0, float64(in.TestRuns)/3.0)
+	}
+	confidence := 0.0
+	if total := w.Evidence + w.Tests + w.Verification + w.Issues; total > 0 {
+		confidence = (w.Evidence*evidence + w.Tests*coverage + w.Verification*verification + w.Issues*issues) / total
+	}
+
+	return Score{
+		Effectiveness: effective,
+		Confidence:    confidence,
+		Status:        statusFor(effective, len(in.Issues) == 0, m.StatusThresholds),
+	}
+}
+
+func (m *WeightedModel) statusFactor(in ScoreInput) float64 {
+	if factor, ok := m.StatusFactors[string(in.Control.Status)]; ok {
+		return factor
+	}
+	return statusEffectiveness(in.Control.Status)
+}
+
+func (m *WeightedModel) evidenceFactor(in ScoreInput) float64 {
+	if m.EvidenceTarget == 0 {
+		return 1
+	}
+	return clamp(float64(len(in.Control.Evidence)) / float64(m.EvidenceTarget))
+}
+
+func (m *WeightedModel) verificationFactor(in ScoreInput) float64 {
+	if in.Control.LastVerified.IsZero() || m.VerificationMaxAgeDays == 0 {
+		return 0
+	}
+	age := in.Now.Sub(in.Control.LastVerified).Hours() / 24
+	return clamp(1 - age/float64(m.VerificationMaxAgeDays))
+}
+
+func (m *WeightedModel) issuesFactor(in ScoreInput) float64 {
+	penalty := 0.0
+	for _, issue := range in.Issues {
+		penalty += m.SeverityPenalties[issue.Severity]
+	}
+	return clamp(1 - penalty)
+}
+
+// scoringFile is the layout of a scoring model file.
+type scoringFile struct {
+	Model         string `yaml:"model"`
+	Decay         *Decay `yaml:"decay,omitempty"`
+	WeightedModel `yaml:",inline"`
+}
+
+// ParseScoringModel parses a scoring model file. The model key selects the
+// "default" model or the "weighted" model, which is the default, and the
+// optional decay section applies time decay to the model's scores.
+func ParseScoringModel(name string, data []byte) (ScoringModel, error) {
+	var file scoringFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	var model ScoringModel
+	switch file.Model {
+	case "default":
+		model = DefaultScoringModel()
+	case "", "weighted":
+		m := file.WeightedModel
+		m.setDefaults()
+		if err := m.validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		model = &m
+	default:
+		return nil, fmt.Errorf("%s: unknown scoring model %q (valid: default, weighted)", name, file.Model)
+	}
+
+	if file.Decay != nil {
+		if err := file.Decay.validate(); err != nil {
+			return nil, fmt.Errorf("%s: decay: %w", name, err)
+		}
+		model = WithDecay(model, file.Decay)
+	}
+	return model, nil
+}
+
+// LoadScoringModel loads a scoring model file.
+func LoadScoringModel(path string) (ScoringModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScoringModel(path, data)
+}
@@ -0,0 +1,155 @@
+package control
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDefaultScoringModel(t *testing.T) {
+	now := time.Now()
+	model := DefaultScoringModel()
+
+	untested := model.Score(ScoreInput{
+		Control: SecurityControl{Status: StatusImplemented, Evidence: []string{"e"}},
+		Now:     now,
+	})
+	if untested.Effectiveness != 0.9 || untested.Status != "EFFECTIVE" || math.Abs(untested.Confidence-1.0) > 1e-9 {
+		t.Errorf("untested score = %+v", untested)
+	}
+
+	// Linked tests without results cap confidence
+	pending := model.Score(ScoreInput{
+		Control:     SecurityControl{Status: StatusImplemented, Evidence: []string{"e"}, Tests: []string{"t-1"}},
+		TestsLinked: 1,
+		Now:         now,
+	})
+	if math.Abs(pending.Confidence-0.7) > 1e-9 {
+		t.Errorf("confidence with unrun linked tests = %.2f, want 0.70", pending.Confidence)
+	}
+
+	tested := model.Score(ScoreInput{
+		Control:     SecurityControl{Status: StatusPartiallyImplemented},
+		Issues:      []Issue{NewIssue(IssueNoEvidence)},
+		TestsLinked: 1,
+		TestsPassed: 1,
+		TestRuns:    3,
+		TestScore:   1,
+		Now:         now,
+	})
+	if tested.Effectiveness != 0.6 || tested.Status != "INEFFECTIVE" || math.Abs(tested.Confidence-(0.4*0.7+0.3)) > 1e-9 {
+		t.Errorf("tested score = %+v", tested)
+	}
+
+	validator := NewControlValidator()
+	validator.AddControl(SecurityControl{ID: "c-1", Status: StatusImplemented, Owner: "o", Evidence: []string{"e"}, LastVerified: now})
+	if result := validator.ValidateControl("c-1"); result.ScoringModel != "default" {
+		t.Errorf("scoring model = %q", result.ScoringModel)
+	}
+}
+
+// TestDefaultScoringModelGolden pins the scores of controls without linked
+// tests to those of releases before scoring models: 0.9, 0.6 and 0.0
+// effectiveness by status, confidence 0.5 plus 0.2 for evidence, plus 0.3
+// without issues or less 0.1 per issue, and 0.9 and 0.7 thresholds.
+func TestDefaultScoringModelGolden(t *testing.T) {
+	issues := []Issue{NewIssue(IssueNoOwner), NewIssue(IssueNoEvidence)}
+	for _, tc := range []struct {
+		status        ControlStatus
+		evidence      bool
+		issues        int
+		effectiveness float64
+		confidence    float64
+		result        string
+	}{
+		{StatusImplemented, true, 0, 0.9, 1.0, "EFFECTIVE"},
+		{StatusImplemented, false, 0, 0.9, 0.8, "EFFECTIVE"},
+		{StatusImplemented, true, 1, 0.9, 0.6, "PARTIALLY_EFFECTIVE"},
+		{StatusImplemented, false, 2, 0.9, 0.3, "PARTIALLY_EFFECTIVE"},
+		{StatusPartiallyImplemented, true, 0, 0.6, 1.0, "INEFFECTIVE"},
+		{StatusPartiallyImplemented, true, 1, 0.6, 0.6, "INEFFECTIVE"},
+		{StatusNotImplemented, true, 0, 0.0, 1.0, "INEFFECTIVE"},
+		{StatusDeprecated, false, 2, 0.7, 0.3, "PARTIALLY_EFFECTIVE"},
+	} {
+		in := ScoreInput{Control: SecurityControl{Status: tc.status}, Issues: issues[:tc.issues], Now: time.Now()}
+		if tc.evidence {
+			in.Control.Evidence = []string{"e"}
+		}
+		score := DefaultScoringModel().Score(in)
+		if score.Effectiveness != tc.effectiveness || math.Abs(score.Confidence-tc.confidence) > 1e-9 || score.Status != tc.result {
+			t.Errorf("%s, evidence %t, %d issue(s): got %.2f %.2f %s, want %.2f %.2f %s", tc.status, tc.evidence, tc.issues,
+				score.Effectiveness, score.Confidence, score.Status, tc.effectiveness, tc.confidence, tc.result)
+		}
+	}
+	if th := DefaultScoringModel().Thresholds(); th.Effective != 0.9 || th.Partial != 0.7 {
+		t.Errorf("thresholds = %+v", th)
+	}
+}
+
+func TestWeightedScoringModel(t *testing.T) {
+	now := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	model, err := ParseScoringModel("scoring.yaml", []byte(`
+name: strict
+weights: {status: 1, evidence: 1, tests: 2, verification: 0, issues: 0}
+evidenceTarget: 4
+thresholds: {effective: 0.8, partial: 0.5}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(model.Name(), "strict@") {
+		t.Errorf("name = %q", model.Name())
+	}
+
+	control := SecurityControl{Status: StatusImplemented, Evidence: []string{"a", "b"}, LastVerified: now.AddDate(0, 0, -90)}
+	untested := model.Score(ScoreInput{Control: control, Now: now})
+	// (1 + 0.5) / 2 with the tests weight left out
+	if math.Abs(untested.Effectiveness-0.75) > 1e-9 || untested.Status != "PARTIALLY_EFFECTIVE" {
+		t.Errorf("untested score = %+v", untested)
+	}
+
+	tested := model.Score(ScoreInput{Control: control, TestsLinked: 1, TestsPassed: 1, TestRuns: 3, TestScore: 1, Now: now})
+	// (1 + 0.5 + 2) / 4
+	if math.Abs(tested.Effectiveness-0.875) > 1e-9 || tested.Status != "EFFECTIVE" {
+		t.Errorf("tested score = %+v", tested)
+	}
+	// (0.5 + 2) / 3
+	if math.Abs(tested.Confidence-2.5/3) > 1e-9 {
+		t.Errorf("confidence = %v", tested.Confidence)
+	}
+
+	withIssues := model.Score(ScoreInput{Control: control, Issues: []Issue{NewIssue(IssueNoOwner)}, TestsLinked: 1, TestsPassed: 1, TestRuns: 3, TestScore: 1, Now: now})
+	if withIssues.Status != "PARTIALLY_EFFECTIVE" {
+		t.Errorf("controls with issues must not be effective, got %+v", withIssues)
+	}
+
+	defaults := NewWeightedModel(Weights{})
+	again, _ := ParseScoringModel("empty.yaml", nil)
+	if defaults.Name() != again.Name() {
+		t.Errorf("equal configurations must share a name: %s, %s", defaults.Name(), again.Name())
+	}
+	verified := defaults.Score(ScoreInput{Control: SecurityControl{Status: StatusImplemented, LastVerified: now}, Now: now})
+	stale := defaults.Score(ScoreInput{Control: SecurityControl{Status: StatusImplemented, LastVerified: now.AddDate(-1, 0, 0)}, Now: now})
+	if verified.Effectiveness <= stale.Effectiveness {
+		t.Errorf("recent verification must score higher: %v <= %v", verified.Effectiveness, stale.Effectiveness)
+	}
+}
+
+func TestParseScoringModelErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"unknown model":   "model: fancy",
+		"unknown key":     "weight: {status: 1}",
+		"negative weight": "weights: {status: -1, tests: 1}",
+		"bad factor":      "statusFactors: {implemented: 2}",
+		"bad severity":    "severityPenalties: {severe: 1}",
+		"bad thresholds":  "thresholds: {effective: 0.5, partial: 0.8}",
+	} {
+		if _, err := ParseScoringModel(name, []byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+	if model, err := ParseScoringModel("default.yaml", []byte("model: default")); err != nil || model.Name() != "default" {
+		t.Errorf("default model = %v, %v", model, err)
+	}
+}
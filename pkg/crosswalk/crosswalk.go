@@ -0,0 +1,330 @@
+// Package crosswalk maps security controls to the requirements of
+// compliance frameworks. Offline requirement lists of NIST SP 800-53,
+// ISO/IEC 27001, CIS Controls, SOC 2 and PCI DSS are bundled. Every
+// framework is mapped to NIST SP 800-53, so a control that references a
+// requirement of one framework is reported against the others. Several
+// versions of a framework may be held side by side, with each version's
+// requirements naming those of the previous version they supersede.
+package crosswalk
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed frameworks/*.yaml
+var bundledFS embed.FS
+
+// Hub is the ID of the framework that the others are mapped through.
+const Hub = "nist-800-53"
+
+// Family is a group of requirements of a framework.
+type Family struct {
+	ID   string `yaml:"id"`
+	Name string `yaml:"name"`
+}
+
+// Requirement is a requirement of a framework. NIST lists the equivalent
+// NIST SP 800-53 controls of requirements of other frameworks.
+// Supersedes lists the requirements of the previous version of the
+// framework that the requirement replaces.
+type Requirement struct {
+	ID         string   `yaml:"id"`
+	Family     string   `yaml:"family"`
+	Title      string   `yaml:"title"`
+	NIST       []string `yaml:"nist,omitempty"`
+	Supersedes []string `yaml:"supersedes,omitempty"`
+}
+
+// Framework is a compliance framework and its requirements.
+type Framework struct {
+	ID      string   `yaml:"id"`
+	Name    string   `yaml:"name"`
+	Version string   `yaml:"version"`
+	Aliases []string `yaml:"aliases,omitempty"`
+	// References are the prefixes of control references to the framework,
+	// such as "NIST-800-53-" in "NIST-800-53-AC-2".
+	References []string `yaml:"references"`
+	// IDPrefix may be left out of references, such as "A." of ISO/IEC
+	// 27001 Annex A requirements.
+	IDPrefix string `yaml:"idPrefix,omitempty"`
+	// Supersedes is the previous version of the framework, if the registry
+	// holds it.
+	Supersedes   string        `yaml:"supersedes,omitempty"`
+	Families     []Family      `yaml:"families"`
+	Requirements []Requirement `yaml:"requirements"`
+
+	index map[string]int
+}
+
+// Title returns the name and version of the framework.
+func (f *Framework) Title() string {
+	return f.Name + " " + f.Version
+}
+
+// Ref returns the ID and version of the framework, as accepted by
+// Registry.Lookup.
+func (f *Framework) Ref() string {
+	return f.ID + "@" + f.Version
+}
+
+// ParseFramework parses a framework from YAML.
+func ParseFramework(data []byte) (*Framework, error) {
+	var f Framework
+	if err := yaml.Unmarshal(data, &f); err != nil {
+		return nil, err
+	}
+	if f.ID == "" {
+		return nil, fmt.Errorf("framework has no id")
+	}
+	if len(f.References) == 0 {
+		return nil, fmt.Errorf("framework %s has no reference prefixes", f.ID)
+	}
+
+	families := make(map[string]bool, len(f.Families))
+	for _, fam := range f.Families {
+		families[fam.ID] = true
+	}
+	f.index = make(map[string]int, len(f.Requirements))
+	for i, req := range f.Requirements {
+		key := normalize(req.ID)
+		if _, ok := f.index[key]; ok || req.ID == "" {
+			return nil, fmt.Errorf("framework %s: duplicate or empty requirement %q", f.ID, req.ID)
+		}
+		if !families[req.Family] {
+			return nil, fmt.Errorf("framework %s: requirement %s has unknown family %q", f.ID, req.ID, req.Family)
+		}
+		if len(req.Supersedes) > 0 && f.Supersedes == "" {
+			return nil, fmt.Errorf("framework %s: requirement %s supersedes requirements of no previous version", f.ID, req.ID)
+		}
+		f.index[key] = i
+	}
+	return &f, nil
+}
+
+// Requirement returns the requirement with the given ID. IDs are matched
+// case-insensitively, with or without the framework's ID prefix. A more
+// specific ID, such as an enhancement "AC-2(1)" or a sub-requirement
+// "8.4.2", resolves to the listed requirement it belongs to.
+func (f *Framework) Requirement(id string) (Requirement, bool) {
+	key := normalize(id)
+	if f.IDPrefix != "" && !strings.HasPrefix(key, normalize(f.IDPrefix)) {
+		key = normalize(f.IDPrefix) + key
+	}
+	for key != "" {
+		if i, ok := f.index[key]; ok {
+			return f.Requirements[i], true
+		}
+		i := strings.LastIndexAny(key, ".(")
+		if i < 0 {
+			break
+		}
+		key = key[:i]
+	}
+	return Requirement{}, false
+}
+
+// Family returns the family with the given ID.
+func (f *Framework) Family(id string) (Family, bool) {
+	for _, fam := range f.Families {
+		if fam.ID == id {
+			return fam, true
+		}
+	}
+	return Family{}, false
+}
+
+// normalize returns the comparison key of a requirement ID.
+func normalize(id string) string {
+	return strings.ToUpper(strings.TrimSpace(id))
+}
+
+// Registry is a set of frameworks mapped through the hub framework. It may
+// hold several versions of a framework side by side.
+type Registry struct {
+	frameworks []*Framework
+}
+
+// bundled is the registry of bundled frameworks.
+var bundled *Registry
+
+func init() {
+	files, err := bundledFS.ReadDir("frameworks")
+	if err != nil {
+		panic(err)
+	}
+	var frameworks []*Framework
+	for _, file := range files {
+		data, err := bundledFS.ReadFile(path.Join("frameworks", file.Name()))
+		if err != nil {
+			panic(err)
+		}
+		f, err := ParseFramework(data)
+		if err != nil {
+			panic(fmt.Sprintf("crosswalk: invalid %s: %v", file.Name(), err))
+		}
+		frameworks = append(frameworks, f)
+	}
+	if bundled, err = NewRegistry(frameworks...); err != nil {
+		panic(fmt.Sprintf("crosswalk: %v", err))
+	}
+}
+
+// Bundled returns the registry of bundled frameworks.
+func Bundled() *Registry {
+	return bundled
+}
+
+// NewRegistry returns a registry of frameworks, which must include the hub
+// framework. The NIST mappings of the other frameworks must name its
+// requirements, and the requirements they supersede must be those of their
+// previous version.
+func NewRegistry(frameworks ...*Framework) (*Registry, error) {
+	r := &Registry{frameworks: append([]*Framework(nil), frameworks...)}
+	sort.SliceStable(r.frameworks, func(i, j int) bool {
+		a, b := r.frameworks[i], r.frameworks[j]
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return compareVersions(a.Version, b.Version) < 0
+	})
+
+	hub, ok := r.Lookup(Hub)
+	if !ok {
+		return nil, fmt.Errorf("registry has no %s framework", Hub)
+	}
+	names := make(map[string]string)
+	for i, f := range r.frameworks {
+		for _, name := range append([]string{f.ID}, f.Aliases...) {
+			if id, ok := names[strings.ToLower(name)]; ok && id != f.ID {
+				return nil, fmt.Errorf("framework name %q used by %s and %s", name, id, f.ID)
+			}
+			names[strings.ToLower(name)] = f.ID
+		}
+		if i > 0 && r.frameworks[i-1].Ref() == f.Ref() {
+			return nil, fmt.Errorf("duplicate framework %s", f.Ref())
+		}
+		for _, req := range f.Requirements {
+			for _, id := range req.NIST {
+				if _, ok := hub.Requirement(id); !ok {
+					return nil, fmt.Errorf("framework %s: requirement %s maps to unknown %s control %q", f.Ref(), req.ID, Hub, id)
+				}
+			}
+		}
+		if f.Supersedes == "" {
+			continue
+		}
+		prev, ok := r.Lookup(f.ID + "@" + f.Supersedes)
+		if !ok {
+			return nil, fmt.Errorf("framework %s supersedes unknown version %s", f.Ref(), f.Supersedes)
+		}
+		for _, req := range f.Requirements {
+			for _, id := range req.Supersedes {
+				if _, ok := prev.index[normalize(id)]; !ok {
+					return nil, fmt.Errorf("framework %s: requirement %s supersedes unknown requirement %q of %s", f.Ref(), req.ID, id, prev.Ref())
+				}
+			}
+		}
+	}
+	return r, nil
+}
+
+// Frameworks returns the registry's frameworks ordered by ID and version.
+func (r *Registry) Frameworks() []*Framework {
+	return r.frameworks
+}
+
+// Versions returns the versions of the framework with the given ID or
+// alias, oldest first.
+func (r *Registry) Versions(name string) []*Framework {
+	var versions []*Framework
+	for _, f := range r.frameworks {
+		for _, n := range append([]string{f.ID}, f.Aliases...) {
+			if strings.EqualFold(n, name) {
+				versions = append(versions, f)
+				break
+			}
+		}
+	}
+	return versions
+}
+
+// Lookup returns the framework with the given ID or alias, ignoring case.
+// A name such as "pci-dss@3.2.1" selects a version; otherwise the latest
+// version is returned.
+func (r *Registry) Lookup(name string) (*Framework, bool) {
+	name, version, versioned := strings.Cut(name, "@")
+	versions := r.Versions(name)
+	if !versioned {
+		if len(versions) == 0 {
+			return nil, false
+		}
+		return versions[len(versions)-1], true
+	}
+	for _, f := range versions {
+		if strings.EqualFold(f.Version, version) {
+			return f, true
+		}
+	}
+	return nil, false
+}
+
+// Resolve returns the framework and requirement a control reference such as
+// "ISO-27001-A.8.5" points to. The longest matching reference prefix wins.
+func (r *Registry) Resolve(ref string) (*Framework, Requirement, bool) {
+	var match *Framework
+	var prefix string
+	for _, f := range r.frameworks {
+		for _, p := range f.References {
+			if len(p) > len(prefix) && len(ref) > len(p) && strings.EqualFold(ref[:len(p)], p) {
+				match, prefix = f, p
+			}
+		}
+	}
+	if match == nil {
+		return nil, Requirement{}, false
+	}
+	req, ok := match.Requirement(ref[len(prefix):])
+	if !ok {
+		return nil, Requirement{}, false
+	}
+	return match, req, true
+}
+
+// compareVersions orders version strings by their dot-separated parts,
+// numerically where both parts are numbers.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, errX := strconv.Atoi(as[i])
+		y, errY := strconv.Atoi(bs[i])
+		switch {
+		case errX == nil && errY == nil && x != y:
+			if x < y {
+				return -1
+			}
+			return 1
+		case (errX != nil || errY != nil) && as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// equivalents returns the hub requirements equivalent to a requirement.
+func equivalents(f *Framework, req Requirement) []string {
+	if f.ID == Hub {
+		return []string{normalize(req.ID)}
+	}
+	ids := make([]string, len(req.NIST))
+	for i, id := range req.NIST {
+		ids[i] = normalize(id)
+	}
+	return ids
+}
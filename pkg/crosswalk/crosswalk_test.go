@@ -0,0 +1,257 @@
+package crosswalk
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+func TestBundled(t *testing.T) {
+	counts := map[string]int{"nist-800-53": 298, "iso27001": 93, "cis": 153, "soc2": 61, "pci": 63}
+	for name, want := range counts {
+		fw, ok := Bundled().Lookup(name)
+		if !ok {
+			t.Errorf("%s not bundled", name)
+			continue
+		}
+		if len(fw.Requirements) != want {
+			t.Errorf("%s has %d requirements, want %d", name, len(fw.Requirements), want)
+		}
+	}
+
+	for ref, want := range map[string]string{
+		"NIST-800-53-AC-2":    "AC-2",
+		"nist-800-53-ac-2(1)": "AC-2",
+		"ISO-27001-A.8.5":     "A.8.5",
+		"ISO-27001-8.5":       "A.8.5",
+		"PCI-DSS-8.4.2":       "8.4",
+		"SOC2-CC6.1":          "CC6.1",
+		"CIS-6.3":             "6.3",
+	} {
+		if _, req, ok := Bundled().Resolve(ref); !ok || req.ID != want {
+			t.Errorf("Resolve(%s) = %s, %v; want %s", ref, req.ID, ok, want)
+		}
+	}
+	for _, ref := range []string{"NIST-800-53-XX-1", "ISO-27001-A.9.1", "OWASP-A01", "https://example.com"} {
+		if _, _, ok := Bundled().Resolve(ref); ok {
+			t.Errorf("Resolve(%s) succeeded", ref)
+		}
+	}
+
+	if _, err := NewRegistry(); err == nil {
+		t.Error("expected error for registry without hub")
+	}
+	if _, err := ParseFramework([]byte("id: x\nreferences: [X-]\nfamilies: [{id: a}]\nrequirements: [{id: '1', family: b}]")); err == nil {
+		t.Error("expected error for unknown family")
+	}
+}
+
+func TestMap(t *testing.T) {
+	controls := []control.SecurityControl{
+		{ID: "mfa", References: []string{"NIST-800-53-IA-2"}},
+		{ID: "iam", References: []string{"ISO-27001-A.8.5", "https://example.com/iam"}},
+		{ID: "logs", References: []string{"PCI-DSS-10.2", "OWASP-A09"}},
+	}
+	results := []control.ControlValidationResult{
+		{ControlID: "mfa", Effectiveness: 0.9},
+		{ControlID: "iam", Effectiveness: 0.5},
+		{ControlID: "logs", Effectiveness: 0.8},
+	}
+
+	iso, _ := Bundled().Lookup("iso27001")
+	m := Bundled().Map(iso, controls, results)
+	byID := make(map[string]RequirementResult)
+	for _, r := range m.Requirements {
+		byID[r.Requirement.ID] = r
+	}
+
+	// A.8.5 maps to IA-2 directly from iam and through NIST from mfa
+	r := byID["A.8.5"]
+	if len(r.Matches) != 2 || math.Abs(r.Effectiveness-0.7) > 1e-9 {
+		t.Errorf("A.8.5 = %+v", r)
+	}
+	for _, match := range r.Matches {
+		if match.Direct != (match.Control == "iam") {
+			t.Errorf("A.8.5 match %+v", match)
+		}
+	}
+	// PCI 10.2 maps to AU-2, AU-3 and AU-12, which A.8.15 shares
+	if r := byID["A.8.15"]; len(r.Controls()) != 1 || r.Controls()[0] != "logs" || len(r.Matches) != 1 {
+		t.Errorf("A.8.15 = %+v", r)
+	}
+	if byID["A.5.1"].Mapped() {
+		t.Error("A.5.1 mapped")
+	}
+	if len(m.Unresolved) != 1 || m.Unresolved[0] != "OWASP-A09" {
+		t.Errorf("unresolved = %v", m.Unresolved)
+	}
+
+	for _, f := range m.Families {
+		if f.Family.ID == "A.8" && (f.Requirements != 34 || f.Mapped != m.Mapped()) {
+			t.Errorf("A.8 = %+v", f)
+		}
+	}
+
+	// Reporting against NIST maps iam through A.8.5 to AC-7, IA-2 and IA-5
+	nist, _ := Bundled().Lookup("nist")
+	m = Bundled().Map(nist, controls, results)
+	for _, r := range m.Requirements {
+		if r.Requirement.ID == "IA-5" && (len(r.Matches) != 1 || r.Matches[0].Control != "iam") {
+			t.Errorf("IA-5 = %+v", r)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	controls := []control.SecurityControl{
+		{ID: "mfa", Status: control.StatusImplemented, References: []string{"NIST-800-53-IA-2"}},
+		{ID: "sso", Status: control.StatusImplemented, References: []string{"NIST-800-53-IA-2"}},
+		{ID: "tokens", Status: control.StatusPartiallyImplemented, References: []string{"NIST-800-53-IA-5"}},
+		{ID: "weak", Status: control.StatusImplemented, References: []string{"NIST-800-53-AC-7"}},
+		{ID: "planned", Status: control.StatusNotImplemented, References: []string{"NIST-800-53-AC-5"}},
+	}
+	results := []control.ControlValidationResult{
+		{ControlID: "mfa", Effectiveness: 0.9},
+		{ControlID: "sso", Effectiveness: 0.8},
+		{ControlID: "tokens", Effectiveness: 0.9},
+		{ControlID: "weak", Effectiveness: 0.4},
+		{ControlID: "planned", Effectiveness: 1},
+	}
+	opts := GapOptions{MinEffectiveness: DefaultMinEffectiveness, MaxControls: 1}
+
+	nist, _ := Bundled().Lookup("nist")
+	g := Analyze(Bundled().Map(nist, controls, results), controls, results, opts)
+	byID := make(map[string]RequirementGap)
+	for _, r := range g.Requirements {
+		byID[r.Requirement.ID] = r
+	}
+	for id, want := range map[string]GapStatus{"IA-2": GapMet, "IA-5": GapPartial, "AC-7": GapPartial, "AC-5": GapUnmet, "AC-1": GapUnmet} {
+		if got := byID[id].Status; got != want {
+			t.Errorf("%s = %s, want %s", id, got, want)
+		}
+	}
+	if over := g.OverCovered(); len(over) != 1 || over[0].Requirement.ID != "IA-2" {
+		t.Errorf("over-covered = %+v", over)
+	}
+
+	for _, f := range g.Families {
+		if f.Family.ID != "IA" {
+			continue
+		}
+		// IA-2 met and IA-5 partial out of the family's 12 requirements
+		if f.Met != 1 || f.Partial != 1 || math.Abs(f.Score()-1.5/12) > 1e-9 {
+			t.Errorf("IA = %+v, %v", f, f.Score())
+		}
+	}
+	if g.Total.Requirements != len(nist.Requirements) || g.Total.Met != 1 || g.Total.Partial != 2 {
+		t.Errorf("total = %+v", g.Total)
+	}
+
+	// Through the crosswalk, ISO A.8.5 maps to AC-7, IA-2 and IA-5
+	iso, _ := Bundled().Lookup("iso27001")
+	g = Analyze(Bundled().Map(iso, controls, results), controls, results, opts)
+	for _, r := range g.Requirements {
+		if r.Requirement.ID == "A.8.5" && (r.Status != GapMet || len(r.Meeting) != 2 || len(r.Weak) != 2) {
+			t.Errorf("A.8.5 = %+v", r)
+		}
+	}
+}
+
+func TestVersions(t *testing.T) {
+	versions := Bundled().Versions("pci")
+	if len(versions) != 2 || versions[0].Version != "3.2.1" || versions[1].Version != "4.0" {
+		t.Fatalf("versions = %v", versions)
+	}
+	if fw, ok := Bundled().Lookup("PCI-DSS"); !ok || fw.Version != "4.0" {
+		t.Errorf("latest = %v", fw)
+	}
+	if fw, ok := Bundled().Lookup("pci@3.2.1"); !ok || len(fw.Requirements) != 79 {
+		t.Errorf("3.2.1 = %v", fw)
+	}
+	if _, ok := Bundled().Lookup("pci-dss@9.9"); ok {
+		t.Error("unknown version found")
+	}
+	if _, req, ok := Bundled().Resolve("PCI-DSS-3.2.1-12.11"); !ok || req.Title != "Service providers perform reviews at least quarterly to confirm personnel are following security policies and procedures" {
+		t.Errorf("Resolve(PCI-DSS-3.2.1-12.11) = %+v, %v", req, ok)
+	}
+
+	for _, tc := range []struct{ a, b string }{{"3.2.1", "4.0"}, {"4.0", "4.0.1"}, {"9", "10"}, {"rev4", "rev5"}} {
+		if compareVersions(tc.a, tc.b) >= 0 || compareVersions(tc.b, tc.a) <= 0 {
+			t.Errorf("compareVersions(%s, %s)", tc.a, tc.b)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	controls := []control.SecurityControl{
+		{ID: "fw", References: []string{"PCI-DSS-1.1", "PCI-DSS-2.6"}},
+		{ID: "mfa", References: []string{"PCI-DSS-3.2.1-8.3.1", "PCI-DSS-3.2.1-12.6", "PCI-DSS-8.1", "PCI-DSS-4.0-8.5", "PCI-DSS-12.7"}},
+	}
+	from, _ := Bundled().Lookup("pci-dss@3.2.1")
+	to, _ := Bundled().Lookup("pci-dss@4.0")
+	m, err := Bundled().Migrate(from, to, controls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[ChangeKind]int{ChangeNew: 3, ChangeRemoved: 1, ChangeSplit: 0, ChangeMerged: 11, ChangeRestructured: 2, ChangeRenumbered: 39, ChangeUnchanged: 6}
+	for kind, n := range want {
+		if got := m.Count(kind); got != n {
+			t.Errorf("%s = %d, want %d", kind, got, n)
+		}
+	}
+	if m.Changes[0].Kind != ChangeNew || m.Changes[len(m.Changes)-1].Kind != ChangeUnchanged {
+		t.Error("changes not ordered by kind")
+	}
+
+	// Each requirement of either version is part of exactly one change
+	for _, version := range []struct {
+		f    *Framework
+		reqs func(Change) []Requirement
+	}{
+		{from, func(c Change) []Requirement { return c.From }},
+		{to, func(c Change) []Requirement { return c.To }},
+	} {
+		changes := make(map[string]int)
+		for _, c := range m.Changes {
+			for _, req := range version.reqs(c) {
+				changes[req.ID]++
+			}
+		}
+		for _, req := range version.f.Requirements {
+			if changes[req.ID] != 1 {
+				t.Errorf("%s %s is part of %d changes", version.f.Ref(), req.ID, changes[req.ID])
+			}
+		}
+	}
+
+	// 12.7 carries over unchanged and 8.5 names the new version
+	remaps := []Remap{
+		{Control: "fw", Reference: "PCI-DSS-1.1", Kind: ChangeRenumbered, Suggested: []string{"PCI-DSS-1.2"}},
+		{Control: "fw", Reference: "PCI-DSS-2.6", Kind: ChangeRemoved},
+		{Control: "mfa", Reference: "PCI-DSS-3.2.1-8.3.1", Kind: ChangeRenumbered, Suggested: []string{"PCI-DSS-8.4"}},
+		{Control: "mfa", Reference: "PCI-DSS-3.2.1-12.6", Kind: ChangeUnchanged, Suggested: []string{"PCI-DSS-12.6"}},
+		{Control: "mfa", Reference: "PCI-DSS-8.1", Kind: ChangeRestructured, Suggested: []string{"PCI-DSS-8.2", "PCI-DSS-8.3"}},
+	}
+	if len(m.Remaps) != len(remaps) {
+		t.Fatalf("remaps = %+v", m.Remaps)
+	}
+	for i, want := range remaps {
+		got := m.Remaps[i]
+		if got.Control != want.Control || got.Reference != want.Reference || got.Kind != want.Kind || fmt.Sprint(got.Suggested) != fmt.Sprint(want.Suggested) {
+			t.Errorf("remap %d = %+v, want %+v", i, got, want)
+		}
+	}
+
+	if _, err := Bundled().Migrate(to, from, controls); err == nil {
+		t.Error("expected error migrating backwards")
+	}
+
+	// Migrating within a version changes nothing
+	m, err = Bundled().Migrate(to, to, nil)
+	if err != nil || m.Count(ChangeUnchanged) != len(to.Requirements) {
+		t.Errorf("identity migration = %v, %v", m, err)
+	}
+}
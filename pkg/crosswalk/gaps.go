@@ -0,0 +1,176 @@
+package crosswalk
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// GapStatus is how completely a requirement is met.
+type GapStatus string
+
+const (
+	// GapMet requirements have an implemented control at or above the
+	// minimum effectiveness.
+	GapMet GapStatus = "met"
+	// GapPartial requirements only have partially implemented or less
+	// effective controls.
+	GapPartial GapStatus = "partial"
+	// GapUnmet requirements have no controls, or only controls that are
+	// not implemented or deprecated.
+	GapUnmet GapStatus = "unmet"
+)
+
+// Default gap analysis thresholds.
+const (
+	DefaultMinEffectiveness = 0.7
+	DefaultMaxControls      = 3
+)
+
+// GapOptions are the thresholds of a gap analysis.
+type GapOptions struct {
+	// MinEffectiveness is the effectiveness a control needs to meet a
+	// requirement.
+	MinEffectiveness float64
+	// MaxControls is the number of controls that meet a requirement above
+	// which it is over-covered.
+	MaxControls int
+}
+
+// RequirementGap is how completely the controls mapped to a requirement
+// meet it.
+type RequirementGap struct {
+	RequirementResult
+	Status GapStatus
+	// Meeting lists the controls that meet the requirement and Weak the
+	// other mapped controls that count toward it.
+	Meeting []string
+	Weak    []string
+	// OverCovered reports whether more controls than needed meet the
+	// requirement.
+	OverCovered bool
+}
+
+// FamilyGaps summarizes the gaps of a family.
+type FamilyGaps struct {
+	Family              Family
+	Requirements        int
+	Met, Partial, Unmet int
+	OverCovered         int
+}
+
+// Score returns the share of the family's requirements that are met,
+// counting partially met requirements as half.
+func (f FamilyGaps) Score() float64 {
+	if f.Requirements == 0 {
+		return 0
+	}
+	return (float64(f.Met) + float64(f.Partial)/2) / float64(f.Requirements)
+}
+
+// Gaps is a gap analysis of a framework.
+type Gaps struct {
+	Framework    *Framework
+	Options      GapOptions
+	Requirements []RequirementGap
+	Families     []FamilyGaps
+	Total        FamilyGaps
+}
+
+// Score returns the share of the framework's requirements that are met,
+// counting partially met requirements as half.
+func (g *Gaps) Score() float64 {
+	return g.Total.Score()
+}
+
+// Status returns the requirements with the given status.
+func (g *Gaps) Status(status GapStatus) []RequirementGap {
+	var gaps []RequirementGap
+	for _, r := range g.Requirements {
+		if r.Status == status {
+			gaps = append(gaps, r)
+		}
+	}
+	return gaps
+}
+
+// OverCovered returns the over-covered requirements.
+func (g *Gaps) OverCovered() []RequirementGap {
+	var gaps []RequirementGap
+	for _, r := range g.Requirements {
+		if r.OverCovered {
+			gaps = append(gaps, r)
+		}
+	}
+	return gaps
+}
+
+// Analyze measures how completely the controls of a mapping meet each
+// requirement of its framework. A control meets a requirement when it is
+// implemented and its validated effectiveness reaches the minimum.
+// Partially implemented controls and less effective ones only partially
+// meet it; controls that are not implemented or deprecated do not count.
+func Analyze(m *Mapping, controls []control.SecurityControl, results []control.ControlValidationResult, opts GapOptions) *Gaps {
+	status := make(map[string]control.ControlStatus, len(controls))
+	for _, c := range controls {
+		status[c.ID] = c.Status
+	}
+	effectiveness := make(map[string]float64, len(results))
+	validated := make(map[string]bool, len(results))
+	for _, r := range results {
+		effectiveness[r.ControlID] = r.Effectiveness
+		validated[r.ControlID] = true
+	}
+
+	g := &Gaps{Framework: m.Framework, Options: opts}
+	for _, req := range m.Requirements {
+		gap := RequirementGap{RequirementResult: req, Status: GapUnmet}
+		for _, id := range req.Controls() {
+			switch status[id] {
+			case control.StatusNotImplemented, control.StatusDeprecated:
+				continue
+			case control.StatusImplemented:
+				if validated[id] && effectiveness[id] >= opts.MinEffectiveness {
+					gap.Meeting = append(gap.Meeting, id)
+					continue
+				}
+			}
+			gap.Weak = append(gap.Weak, id)
+		}
+		switch {
+		case len(gap.Meeting) > 0:
+			gap.Status = GapMet
+		case len(gap.Weak) > 0:
+			gap.Status = GapPartial
+		}
+		gap.OverCovered = opts.MaxControls > 0 && len(gap.Meeting) > opts.MaxControls
+		g.Requirements = append(g.Requirements, gap)
+	}
+
+	for _, fam := range m.Framework.Families {
+		f := FamilyGaps{Family: fam}
+		for _, gap := range g.Requirements {
+			if gap.Requirement.Family == fam.ID {
+				f.add(gap)
+				g.Total.add(gap)
+			}
+		}
+		g.Families = append(g.Families, f)
+	}
+	g.Total.Family = Family{ID: m.Framework.ID, Name: m.Framework.Title()}
+	return g
+}
+
+// add counts a requirement toward the family.
+func (f *FamilyGaps) add(gap RequirementGap) {
+	f.Requirements++
+	switch gap.Status {
+	case GapMet:
+		f.Met++
+	case GapPartial:
+		f.Partial++
+	default:
+		f.Unmet++
+	}
+	if gap.OverCovered {
+		f.OverCovered++
+	}
+}
@@ -0,0 +1,201 @@
+package crosswalk
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// Match is a control mapped to a requirement through one of its
+// references.
+type Match struct {
+	Control   string
+	Reference string
+	// Direct reports whether the reference names the requirement itself
+	// rather than an equivalent requirement of another framework.
+	Direct bool
+}
+
+// RequirementResult is a requirement and the controls mapped to it.
+type RequirementResult struct {
+	Requirement Requirement
+	Matches     []Match
+	// Effectiveness is the mean validated effectiveness of the mapped
+	// controls. Controls without a validation result count as 0.
+	Effectiveness float64
+}
+
+// Controls returns the IDs of the controls mapped to the requirement.
+func (r RequirementResult) Controls() []string {
+	var ids []string
+	for _, m := range r.Matches {
+		if !contains(ids, m.Control) {
+			ids = append(ids, m.Control)
+		}
+	}
+	return ids
+}
+
+// Mapped reports whether any control is mapped to the requirement.
+func (r RequirementResult) Mapped() bool {
+	return len(r.Matches) > 0
+}
+
+// FamilyResult summarizes the requirements of a family.
+type FamilyResult struct {
+	Family       Family
+	Requirements int
+	Mapped       int
+	// Effectiveness is the mean effectiveness of the mapped requirements.
+	Effectiveness float64
+}
+
+// Mapping is a set of controls mapped to the requirements of a framework.
+type Mapping struct {
+	Framework    *Framework
+	Requirements []RequirementResult
+	Families     []FamilyResult
+	// Unresolved lists the control references that name no requirement of
+	// a known framework.
+	Unresolved []string
+}
+
+// Mapped returns the number of requirements controls are mapped to.
+func (m *Mapping) Mapped() int {
+	n := 0
+	for _, r := range m.Requirements {
+		if r.Mapped() {
+			n++
+		}
+	}
+	return n
+}
+
+// Effectiveness returns the mean effectiveness of the mapped requirements.
+func (m *Mapping) Effectiveness() float64 {
+	total, n := 0.0, 0
+	for _, r := range m.Requirements {
+		if r.Mapped() {
+			total += r.Effectiveness
+			n++
+		}
+	}
+	if n == 0 {
+		return 0
+	}
+	return total / float64(n)
+}
+
+// Map maps controls to the requirements of a framework through their
+// references. A reference maps a control to the requirement it names and,
+// through the hub framework, to the requirements of the target framework
+// that share an equivalent NIST SP 800-53 control.
+func (r *Registry) Map(target *Framework, controls []control.SecurityControl, results []control.ControlValidationResult) *Mapping {
+	effectiveness := make(map[string]float64, len(results))
+	for _, res := range results {
+		effectiveness[res.ControlID] = res.Effectiveness
+	}
+
+	// Index the target's requirements by their hub equivalents
+	byHub := make(map[string][]int)
+	for i, req := range target.Requirements {
+		for _, id := range equivalents(target, req) {
+			byHub[id] = append(byHub[id], i)
+		}
+	}
+
+	m := &Mapping{Framework: target, Requirements: make([]RequirementResult, len(target.Requirements))}
+	for i, req := range target.Requirements {
+		m.Requirements[i].Requirement = req
+	}
+	for _, c := range controls {
+		for _, ref := range c.References {
+			f, req, ok := r.Resolve(ref)
+			if !ok {
+				if !isURL(ref) && !contains(m.Unresolved, ref) {
+					m.Unresolved = append(m.Unresolved, ref)
+				}
+				continue
+			}
+			if f == target {
+				i := target.index[normalize(req.ID)]
+				m.Requirements[i].add(Match{Control: c.ID, Reference: ref, Direct: true})
+				continue
+			}
+			for _, id := range equivalents(f, req) {
+				for _, i := range byHub[id] {
+					m.Requirements[i].add(Match{Control: c.ID, Reference: ref})
+				}
+			}
+		}
+	}
+	sort.Strings(m.Unresolved)
+
+	for i := range m.Requirements {
+		req := &m.Requirements[i]
+		ids := req.Controls()
+		for _, id := range ids {
+			req.Effectiveness += clamp(effectiveness[id])
+		}
+		if len(ids) > 0 {
+			req.Effectiveness /= float64(len(ids))
+		}
+	}
+
+	for _, fam := range target.Families {
+		fr := FamilyResult{Family: fam}
+		for _, req := range m.Requirements {
+			if req.Requirement.Family != fam.ID {
+				continue
+			}
+			fr.Requirements++
+			if req.Mapped() {
+				fr.Mapped++
+				fr.Effectiveness += req.Effectiveness
+			}
+		}
+		if fr.Mapped > 0 {
+			fr.Effectiveness /= float64(fr.Mapped)
+		}
+		m.Families = append(m.Families, fr)
+	}
+	return m
+}
+
+// add adds a match unless the control is already mapped through the same
+// reference, as when two equivalents of the reference map to the
+// requirement.
+func (r *RequirementResult) add(m Match) {
+	for _, existing := range r.Matches {
+		if existing.Control == m.Control && existing.Reference == m.Reference {
+			return
+		}
+	}
+	r.Matches = append(r.Matches, m)
+}
+
+// isURL reports whether a reference is a link rather than a requirement.
+func isURL(ref string) bool {
+	return strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://")
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// clamp limits a share to [0, 1].
+func clamp(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
@@ -0,0 +1,300 @@
+package crosswalk
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// ChangeKind is how a requirement changed between framework versions.
+type ChangeKind string
+
+const (
+	// ChangeNew requirements have no predecessor.
+	ChangeNew ChangeKind = "new"
+	// ChangeRemoved requirements have no successor.
+	ChangeRemoved ChangeKind = "removed"
+	// ChangeSplit requirements have several successors.
+	ChangeSplit ChangeKind = "split"
+	// ChangeMerged requirements replace several predecessors.
+	ChangeMerged ChangeKind = "merged"
+	// ChangeRestructured requirements were split and merged at once:
+	// several predecessors relate to several successors.
+	ChangeRestructured ChangeKind = "restructured"
+	// ChangeRenumbered requirements replace a single predecessor under
+	// another ID.
+	ChangeRenumbered ChangeKind = "renumbered"
+	// ChangeUnchanged requirements keep their ID.
+	ChangeUnchanged ChangeKind = "unchanged"
+)
+
+// changeOrder is the order changes are listed in.
+var changeOrder = []ChangeKind{ChangeNew, ChangeRemoved, ChangeSplit, ChangeMerged, ChangeRestructured, ChangeRenumbered, ChangeUnchanged}
+
+// Change relates requirements of two versions of a framework.
+type Change struct {
+	Kind ChangeKind
+	From []Requirement
+	To   []Requirement
+}
+
+// Title returns the title of the change's requirement in the newer
+// version, or of its first requirement in the older version for removed,
+// split and restructured requirements.
+func (c Change) Title() string {
+	if len(c.To) == 1 {
+		return c.To[0].Title
+	}
+	return c.From[0].Title
+}
+
+// Remap is a control reference to a requirement of the old version that
+// needs to be updated for the new version.
+type Remap struct {
+	Control     string
+	Reference   string
+	Requirement Requirement
+	Kind        ChangeKind
+	// Suggested are references to the successors of the requirement.
+	Suggested []string
+}
+
+// Migration is the difference between two versions of a framework and the
+// control references it affects.
+type Migration struct {
+	From, To *Framework
+	Changes  []Change
+	Remaps   []Remap
+}
+
+// Count returns the number of changes of a kind.
+func (m *Migration) Count(kind ChangeKind) int {
+	n := 0
+	for _, c := range m.Changes {
+		if c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Migrate compares two versions of a framework, following the versions
+// each supersedes from the newer back to the older, and finds the control
+// references that need to be re-mapped. References are read as naming
+// requirements of the older version unless their prefix names another
+// version, so "PCI-DSS-8.3" is read as 3.2.1 requirement 8.3 when migrating
+// from PCI DSS 3.2.1. A reference needs re-mapping when its requirement did
+// not carry over unchanged or when its prefix is the older version's only.
+func (r *Registry) Migrate(from, to *Framework, controls []control.SecurityControl) (*Migration, error) {
+	if from.ID != to.ID {
+		return nil, fmt.Errorf("cannot migrate between frameworks %s and %s", from.ID, to.ID)
+	}
+	successors, err := r.successors(from, to)
+	if err != nil {
+		return nil, err
+	}
+	predecessors := make(map[string][]string)
+	for _, old := range from.Requirements {
+		for _, id := range successors[old.ID] {
+			predecessors[id] = append(predecessors[id], old.ID)
+		}
+	}
+
+	// Each group of requirements related by successors, directly or through
+	// a shared successor or predecessor, is one change
+	m := &Migration{From: from, To: to}
+	kinds := make(map[string]ChangeKind, len(from.Requirements))
+	seen := make(map[string]bool)
+	for _, old := range from.Requirements {
+		if seen["from:"+old.ID] {
+			continue
+		}
+		var prev, next []string
+		queue := []string{"from:" + old.ID}
+		seen[queue[0]] = true
+		for len(queue) > 0 {
+			node := queue[0]
+			queue = queue[1:]
+			side, id, _ := strings.Cut(node, ":")
+			related := successors[id]
+			if side == "from" {
+				prev = append(prev, id)
+			} else {
+				next = append(next, id)
+				related = predecessors[id]
+			}
+			other := "to:"
+			if side == "to" {
+				other = "from:"
+			}
+			for _, rid := range related {
+				if !seen[other+rid] {
+					seen[other+rid] = true
+					queue = append(queue, other+rid)
+				}
+			}
+		}
+
+		var kind ChangeKind
+		switch {
+		case len(next) == 0:
+			kind = ChangeRemoved
+		case len(prev) > 1 && len(next) > 1:
+			kind = ChangeRestructured
+		case len(next) > 1:
+			kind = ChangeSplit
+		case len(prev) > 1:
+			kind = ChangeMerged
+		case next[0] != old.ID:
+			kind = ChangeRenumbered
+		default:
+			kind = ChangeUnchanged
+		}
+		for _, id := range prev {
+			kinds[id] = kind
+		}
+		c := Change{Kind: kind, From: inOrder(from, prev)}
+		if len(next) > 0 {
+			c.To = inOrder(to, next)
+		}
+		m.Changes = append(m.Changes, c)
+	}
+	for _, req := range to.Requirements {
+		if len(predecessors[req.ID]) == 0 {
+			m.Changes = append(m.Changes, Change{Kind: ChangeNew, To: []Requirement{req}})
+		}
+	}
+	sort.SliceStable(m.Changes, func(i, j int) bool {
+		return kindRank(m.Changes[i].Kind) < kindRank(m.Changes[j].Kind)
+	})
+
+	for _, c := range controls {
+		for _, ref := range c.References {
+			prefix, req, ok := r.resolveVersion(from, ref)
+			if !ok {
+				continue
+			}
+			kind := kinds[req.ID]
+			current := hasPrefix(to.References, prefix)
+			if kind == ChangeUnchanged && current {
+				continue
+			}
+			if !current {
+				prefix = to.References[0]
+			}
+			remap := Remap{Control: c.ID, Reference: ref, Requirement: req, Kind: kind}
+			for _, id := range successors[req.ID] {
+				remap.Suggested = append(remap.Suggested, prefix+id)
+			}
+			m.Remaps = append(m.Remaps, remap)
+		}
+	}
+	return m, nil
+}
+
+// successors maps each requirement of the older version to the
+// requirements of the newer version that replace it, composing the
+// supersedes relations of any versions in between.
+func (r *Registry) successors(from, to *Framework) (map[string][]string, error) {
+	chain := []*Framework{to}
+	for f := to; f != from; {
+		if f.Supersedes == "" {
+			return nil, fmt.Errorf("no migration path from %s to %s", from.Ref(), to.Ref())
+		}
+		prev, ok := r.Lookup(f.ID + "@" + f.Supersedes)
+		if !ok {
+			return nil, fmt.Errorf("framework %s supersedes unknown version %s", f.Ref(), f.Supersedes)
+		}
+		chain = append(chain, prev)
+		f = prev
+	}
+
+	// Start from the identity on the older version's requirements
+	current := make(map[string][]string, len(from.Requirements))
+	for _, req := range from.Requirements {
+		current[req.ID] = []string{req.ID}
+	}
+	for i := len(chain) - 2; i >= 0; i-- {
+		prev, next := chain[i+1], chain[i]
+		step := make(map[string][]string)
+		for _, req := range next.Requirements {
+			for _, id := range req.Supersedes {
+				old := prev.Requirements[prev.index[normalize(id)]].ID
+				step[old] = append(step[old], req.ID)
+			}
+		}
+		for id, ids := range current {
+			var composed []string
+			for _, mid := range ids {
+				for _, n := range step[mid] {
+					if !contains(composed, n) {
+						composed = append(composed, n)
+					}
+				}
+			}
+			current[id] = composed
+		}
+	}
+	return current, nil
+}
+
+// resolveVersion resolves a reference to a requirement of a framework
+// version using the reference prefixes of any version of the framework,
+// except those naming another version, and returns the matched prefix.
+func (r *Registry) resolveVersion(f *Framework, ref string) (string, Requirement, bool) {
+	var prefix string
+	for _, v := range r.Versions(f.ID) {
+		for _, p := range v.References {
+			if v != f && strings.Contains(p, v.Version) {
+				continue
+			}
+			if len(p) > len(prefix) && len(ref) > len(p) && strings.EqualFold(ref[:len(p)], p) {
+				prefix = p
+			}
+		}
+	}
+	if prefix == "" {
+		return "", Requirement{}, false
+	}
+	req, ok := f.Requirement(ref[len(prefix):])
+	return prefix, req, ok
+}
+
+// inOrder returns the requirements of a framework with the given IDs, in
+// the order the framework lists them.
+func inOrder(f *Framework, ids []string) []Requirement {
+	reqs := requirements(f, ids)
+	sort.SliceStable(reqs, func(i, j int) bool {
+		return f.index[normalize(reqs[i].ID)] < f.index[normalize(reqs[j].ID)]
+	})
+	return reqs
+}
+
+// requirements returns the requirements of a framework with the given IDs.
+func requirements(f *Framework, ids []string) []Requirement {
+	reqs := make([]Requirement, 0, len(ids))
+	for _, id := range ids {
+		reqs = append(reqs, f.Requirements[f.index[normalize(id)]])
+	}
+	return reqs
+}
+
+func kindRank(kind ChangeKind) int {
+	for i, k := range changeOrder {
+		if k == kind {
+			return i
+		}
+	}
+	return len(changeOrder)
+}
+
+func hasPrefix(prefixes []string, prefix string) bool {
+	for _, p := range prefixes {
+		if strings.EqualFold(p, prefix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,151 @@
+package crosswalk
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// RequirementColumns are the columns of a report section of framework
+// requirements.
+var RequirementColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "family", Title: "Family", Kind: report.KindString},
+	{Key: "title", Title: "Requirement", Kind: report.KindString},
+	{Key: "controls", Title: "Controls", Kind: report.KindList},
+	{Key: "references", Title: "Via", Kind: report.KindList},
+	{Key: "effectiveness", Title: "Effectiveness", Kind: report.KindPercent},
+	{Key: "mapped", Title: "Mapped", Kind: report.KindBool},
+}
+
+// FamilyColumns are the columns of a report section of requirement
+// families.
+var FamilyColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "family", Title: "Family", Kind: report.KindString},
+	{Key: "requirements", Title: "Requirements", Kind: report.KindNumber},
+	{Key: "mapped", Title: "Mapped", Kind: report.KindNumber},
+	{Key: "effectiveness", Title: "Effectiveness", Kind: report.KindPercent},
+}
+
+// AddMapping adds the framework and its mapped requirements to a report's
+// summary and the "requirements" and "families" sections.
+func AddMapping(r *report.Report, m *Mapping) {
+	r.AddSummary("complianceFramework", m.Framework.Title())
+	r.AddSummary("requirements", len(m.Requirements))
+	r.AddSummary("mapped", m.Mapped())
+	r.AddSummaryKind("effectiveness", m.Effectiveness(), report.KindPercent)
+
+	requirements := r.AddSection("requirements", m.Framework.Title()+" Requirements", RequirementColumns...)
+	for _, req := range m.Requirements {
+		var refs []string
+		for _, match := range req.Matches {
+			if !contains(refs, match.Reference) {
+				refs = append(refs, match.Reference)
+			}
+		}
+		requirements.AddRow(req.Requirement.ID, req.Requirement.Family, req.Requirement.Title,
+			req.Controls(), refs, req.Effectiveness, req.Mapped())
+	}
+
+	families := r.AddSection("families", "Requirements by Family", FamilyColumns...)
+	for _, f := range m.Families {
+		families.AddRow(f.Family.ID, f.Family.Name, f.Requirements, f.Mapped, f.Effectiveness)
+	}
+}
+
+// GapColumns are the columns of a report section of requirement gaps.
+var GapColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "family", Title: "Family", Kind: report.KindString},
+	{Key: "title", Title: "Requirement", Kind: report.KindString},
+	{Key: "status", Title: "Status", Kind: report.KindString},
+	{Key: "meeting", Title: "Meeting Controls", Kind: report.KindList},
+	{Key: "weak", Title: "Weak Controls", Kind: report.KindList},
+	{Key: "overCovered", Title: "Over-Covered", Kind: report.KindBool},
+}
+
+// FamilyGapColumns are the columns of a report section of gaps by family.
+var FamilyGapColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "family", Title: "Family", Kind: report.KindString},
+	{Key: "requirements", Title: "Requirements", Kind: report.KindNumber},
+	{Key: "met", Title: "Met", Kind: report.KindNumber},
+	{Key: "partial", Title: "Partial", Kind: report.KindNumber},
+	{Key: "unmet", Title: "Unmet", Kind: report.KindNumber},
+	{Key: "overCovered", Title: "Over-Covered", Kind: report.KindNumber},
+	{Key: "coverage", Title: "Coverage", Kind: report.KindPercent},
+}
+
+// AddGaps adds the framework's coverage to a report's summary and the
+// "gaps" and "families" sections. Only the given requirements are listed
+// in the gaps section; the families always cover the whole framework.
+func AddGaps(r *report.Report, g *Gaps, requirements []RequirementGap) {
+	r.AddSummary("complianceFramework", g.Framework.Title())
+	r.AddSummary("requirements", g.Total.Requirements)
+	r.AddSummary("met", g.Total.Met)
+	r.AddSummary("partial", g.Total.Partial)
+	r.AddSummary("unmet", g.Total.Unmet)
+	r.AddSummary("overCovered", g.Total.OverCovered)
+	r.AddSummaryKind("coverage", g.Score(), report.KindPercent)
+
+	gaps := r.AddSection("gaps", g.Framework.Title()+" Gaps", GapColumns...)
+	for _, gap := range requirements {
+		gaps.AddRow(gap.Requirement.ID, gap.Requirement.Family, gap.Requirement.Title,
+			string(gap.Status), gap.Meeting, gap.Weak, gap.OverCovered)
+	}
+
+	families := r.AddSection("families", "Coverage by Family", FamilyGapColumns...)
+	for _, f := range g.Families {
+		families.AddRow(f.Family.ID, f.Family.Name, f.Requirements, f.Met, f.Partial, f.Unmet, f.OverCovered, f.Score())
+	}
+}
+
+// ChangeColumns are the columns of a report section of requirement changes
+// between framework versions.
+var ChangeColumns = []report.Column{
+	{Key: "change", Title: "Change", Kind: report.KindString},
+	{Key: "from", Title: "From", Kind: report.KindList},
+	{Key: "to", Title: "To", Kind: report.KindList},
+	{Key: "title", Title: "Requirement", Kind: report.KindString},
+}
+
+// RemapColumns are the columns of a report section of control references
+// to re-map.
+var RemapColumns = []report.Column{
+	{Key: "controlId", Title: "Control ID", Kind: report.KindString},
+	{Key: "reference", Title: "Reference", Kind: report.KindString},
+	{Key: "change", Title: "Change", Kind: report.KindString},
+	{Key: "suggested", Title: "Suggested", Kind: report.KindList},
+}
+
+// AddMigration adds the change counts to a report's summary and the
+// "changes" and "remaps" sections. Unchanged requirements are only counted.
+func AddMigration(r *report.Report, m *Migration) {
+	r.AddSummary("from", m.From.Title())
+	r.AddSummary("to", m.To.Title())
+	for _, kind := range changeOrder {
+		r.AddSummary(string(kind), m.Count(kind))
+	}
+	r.AddSummary("remaps", len(m.Remaps))
+
+	changes := r.AddSection("changes", "Requirement Changes", ChangeColumns...)
+	for _, c := range m.Changes {
+		if c.Kind == ChangeUnchanged {
+			continue
+		}
+		changes.AddRow(string(c.Kind), ids(c.From), ids(c.To), c.Title())
+	}
+
+	remaps := r.AddSection("remaps", "References to Re-map", RemapColumns...)
+	for _, rm := range m.Remaps {
+		remaps.AddRow(rm.Control, rm.Reference, string(rm.Kind), rm.Suggested)
+	}
+}
+
+// ids returns the IDs of requirements.
+func ids(reqs []Requirement) []string {
+	list := make([]string, len(reqs))
+	for i, req := range reqs {
+		list[i] = req.ID
+	}
+	return list
+}
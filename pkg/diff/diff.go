@@ -0,0 +1,208 @@
+// Package diff compares two sets of control validation results and reports
+// posture regressions and improvements.
+package diff
+
+import (
+	"math"
+	"sort"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// ChangeKind classifies how a control changed between two runs.
+type ChangeKind string
+
+const (
+	KindAdded     ChangeKind = "added"
+	KindRemoved   ChangeKind = "removed"
+	KindRegressed ChangeKind = "regressed"
+	KindImproved  ChangeKind = "improved"
+	KindChanged   ChangeKind = "changed"
+	KindUnchanged ChangeKind = "unchanged"
+)
+
+// epsilon is the smallest score change that is reported.
+const epsilon = 0.0005
+
+// statusRank orders validation statuses from worst to best.
+var statusRank = map[string]int{
+	"INEFFECTIVE":         0,
+	"PARTIALLY_EFFECTIVE": 1,
+	"EFFECTIVE":           2,
+}
+
+// ControlChange describes how one control's result changed.
+type ControlChange struct {
+	ControlID         string
+	ControlName       string
+	Kind              ChangeKind
+	StatusFrom        string
+	StatusTo          string
+	EffectivenessFrom float64
+	EffectivenessTo   float64
+	ConfidenceFrom    float64
+	ConfidenceTo      float64
+	NewIssues         []control.Issue
+	ResolvedIssues    []control.Issue
+}
+
+// EffectivenessDelta returns the change in effectiveness.
+func (c ControlChange) EffectivenessDelta() float64 {
+	return c.EffectivenessTo - c.EffectivenessFrom
+}
+
+// ConfidenceDelta returns the change in confidence.
+func (c ControlChange) ConfidenceDelta() float64 {
+	return c.ConfidenceTo - c.ConfidenceFrom
+}
+
+// StatusChanged reports whether the control's status changed.
+func (c ControlChange) StatusChanged() bool {
+	return c.StatusFrom != c.StatusTo
+}
+
+// Diff is the comparison of two sets of results.
+type Diff struct {
+	From    string
+	To      string
+	Changes []ControlChange
+}
+
+// Compare compares the results of two runs control by control. Changes are
+// ordered by the position of the control in the newer run, followed by
+// removed controls.
+func Compare(from, to []control.ControlValidationResult) *Diff {
+	before := make(map[string]control.ControlValidationResult, len(from))
+	for _, r := range from {
+		before[r.ControlID] = r
+	}
+
+	d := &Diff{Changes: make([]ControlChange, 0, len(to))}
+	seen := make(map[string]bool, len(to))
+	for _, r := range to {
+		seen[r.ControlID] = true
+		old, ok := before[r.ControlID]
+		if !ok {
+			d.Changes = append(d.Changes, ControlChange{
+				ControlID:       r.ControlID,
+				ControlName:     r.ControlName,
+				Kind:            KindAdded,
+				StatusTo:        r.Status,
+				EffectivenessTo: r.Effectiveness,
+				ConfidenceTo:    r.Confidence,
+				NewIssues:       nonNil(r.Issues),
+				ResolvedIssues:  []control.Issue{},
+			})
+			continue
+		}
+		d.Changes = append(d.Changes, compare(old, r))
+	}
+
+	var removed []ControlChange
+	for _, r := range from {
+		if seen[r.ControlID] {
+			continue
+		}
+		removed = append(removed, ControlChange{
+			ControlID:         r.ControlID,
+			ControlName:       r.ControlName,
+			Kind:              KindRemoved,
+			StatusFrom:        r.Status,
+			EffectivenessFrom: r.Effectiveness,
+			ConfidenceFrom:    r.Confidence,
+			NewIssues:         []control.Issue{},
+			ResolvedIssues:    nonNil(r.Issues),
+		})
+	}
+	sort.SliceStable(removed, func(i, j int) bool {
+		return removed[i].ControlID < removed[j].ControlID
+	})
+	d.Changes = append(d.Changes, removed...)
+	return d
+}
+
+// compare compares two results of the same control.
+func compare(from, to control.ControlValidationResult) ControlChange {
+	c := ControlChange{
+		ControlID:         to.ControlID,
+		ControlName:       to.ControlName,
+		StatusFrom:        from.Status,
+		StatusTo:          to.Status,
+		EffectivenessFrom: from.Effectiveness,
+		EffectivenessTo:   to.Effectiveness,
+		ConfidenceFrom:    from.Confidence,
+		ConfidenceTo:      to.Confidence,
+		NewIssues:         subtract(to.Issues, from.Issues),
+		ResolvedIssues:    subtract(from.Issues, to.Issues),
+	}
+
+	rankFrom, rankTo := statusRank[from.Status], statusRank[to.Status]
+	delta := c.EffectivenessDelta()
+	switch {
+	case rankTo < rankFrom:
+		c.Kind = KindRegressed
+	case rankTo > rankFrom:
+		c.Kind = KindImproved
+	case delta <= -epsilon:
+		c.Kind = KindRegressed
+	case delta >= epsilon:
+		c.Kind = KindImproved
+	case len(c.NewIssues) > 0 || len(c.ResolvedIssues) > 0 || math.Abs(c.ConfidenceDelta()) >= epsilon:
+		c.Kind = KindChanged
+	default:
+		c.Kind = KindUnchanged
+	}
+	return c
+}
+
+// Count returns the number of changes of a kind.
+func (d *Diff) Count(kind ChangeKind) int {
+	n := 0
+	for _, c := range d.Changes {
+		if c.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// Regressions returns the controls whose posture got worse. Removed
+// controls are not regressions.
+func (d *Diff) Regressions() []ControlChange {
+	var changes []ControlChange
+	for _, c := range d.Changes {
+		if c.Kind == KindRegressed {
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
+
+// subtract returns the issues of a that are not in b, in order. Issues are
+// matched by code and message, so a change in severity or remediation is
+// not a new issue.
+func subtract(a, b []control.Issue) []control.Issue {
+	in := make(map[string]bool, len(b))
+	for _, issue := range b {
+		in[issueKey(issue)] = true
+	}
+	out := make([]control.Issue, 0)
+	for _, issue := range a {
+		if !in[issueKey(issue)] {
+			out = append(out, issue)
+		}
+	}
+	return out
+}
+
+// issueKey identifies an issue across runs.
+func issueKey(issue control.Issue) string {
+	return issue.Code + "\x00" + issue.Message
+}
+
+func nonNil(list []control.Issue) []control.Issue {
+	if list == nil {
+		return []control.Issue{}
+	}
+	return list
+}
@@ -0,0 +1,124 @@
+package diff
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/history"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+var (
+	before = []control.ControlValidationResult{
+		{ControlID: "c1", ControlName: "MFA", Status: "EFFECTIVE", Effectiveness: 1, Confidence: 0.9},
+		{ControlID: "c2", ControlName: "Logging", Status: "PARTIALLY_EFFECTIVE", Effectiveness: 0.7, Issues: []control.Issue{control.NewIssue(control.IssueNoOwner)}},
+		{ControlID: "c3", ControlName: "Backups", Status: "EFFECTIVE", Effectiveness: 0.95},
+		{ControlID: "c4", ControlName: "Legacy", Status: "INEFFECTIVE"},
+		{ControlID: "c5", ControlName: "Stable", Status: "EFFECTIVE", Effectiveness: 1, Issues: []control.Issue{{Message: "Old"}}},
+	}
+	after = []control.ControlValidationResult{
+		{ControlID: "c1", ControlName: "MFA", Status: "PARTIALLY_EFFECTIVE", Effectiveness: 0.8, Confidence: 0.7, Issues: []control.Issue{control.NewIssue(control.IssueTestFailed, "t1")}},
+		{ControlID: "c2", ControlName: "Logging", Status: "EFFECTIVE", Effectiveness: 0.9},
+		{ControlID: "c3", ControlName: "Backups", Status: "EFFECTIVE", Effectiveness: 0.9},
+		{ControlID: "c5", ControlName: "Stable", Status: "EFFECTIVE", Effectiveness: 1, Issues: []control.Issue{{Message: "Old"}}},
+		{ControlID: "c6", ControlName: "WAF", Status: "EFFECTIVE", Effectiveness: 1},
+	}
+)
+
+func TestCompare(t *testing.T) {
+	d := Compare(before, after)
+
+	want := map[string]ChangeKind{
+		"c1": KindRegressed,
+		"c2": KindImproved,
+		"c3": KindRegressed,
+		"c4": KindRemoved,
+		"c5": KindUnchanged,
+		"c6": KindAdded,
+	}
+	if len(d.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d", len(d.Changes), len(want))
+	}
+	for _, c := range d.Changes {
+		if c.Kind != want[c.ControlID] {
+			t.Errorf("%s: kind %s, want %s", c.ControlID, c.Kind, want[c.ControlID])
+		}
+	}
+	if last := d.Changes[len(d.Changes)-1]; last.ControlID != "c4" {
+		t.Errorf("removed controls must come last, got %s", last.ControlID)
+	}
+
+	c1 := d.Changes[0]
+	if !c1.StatusChanged() || len(c1.NewIssues) != 1 || c1.EffectivenessDelta() > -0.19 {
+		t.Errorf("c1 = %+v", c1)
+	}
+	c2 := d.Changes[1]
+	if len(c2.ResolvedIssues) != 1 || c2.ResolvedIssues[0].Code != control.IssueNoOwner {
+		t.Errorf("c2 resolved issues = %v", c2.ResolvedIssues)
+	}
+	if len(d.Regressions()) != 2 {
+		t.Errorf("regressions = %v", d.Regressions())
+	}
+}
+
+func TestRender(t *testing.T) {
+	d := Compare(before, after)
+	d.From, d.To = "a.json", "b.json"
+
+	text := d.Text()
+	for _, want := range []string{"Regressed: 2", "Status: EFFECTIVE → PARTIALLY_EFFECTIVE", "+ [high] SC-TEST-002: Linked test t1 failed", "- [low] SC-OWNER-001: Control owner not assigned"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("text missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Stable") {
+		t.Error("unchanged controls must not be listed")
+	}
+
+	md := d.Markdown()
+	if !strings.Contains(md, "| MFA (`c1`) | 🔴 regressed | EFFECTIVE → PARTIALLY_EFFECTIVE | 100.0% → 80.0% (-20.0) |") {
+		t.Errorf("markdown:\n%s", md)
+	}
+
+	r := d.Report()
+	if r.Summary.Get("regressed") != 2 || len(r.GetSection("changes").Rows) != 5 {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+func TestParse(t *testing.T) {
+	r := report.New("validate", "Validation")
+	control.AddResults(r, before)
+	var buf bytes.Buffer
+	if err := report.RenderJSON(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := Parse(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snap.Results) != 5 || snap.Results[1].Issues[0].Code != control.IssueNoOwner || snap.Results[2].Effectiveness != 0.95 {
+		t.Errorf("results from report = %+v", snap.Results)
+	}
+
+	run := history.NewRun("validate", "1.0.0", r.GeneratedAt)
+	run.AddControlResults(after)
+	data, err := json.Marshal(run)
+	if err != nil {
+		t.Fatal(err)
+	}
+	snap, err = Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Label != run.ID || len(snap.Results) != 5 {
+		t.Errorf("snapshot from run = %+v", snap)
+	}
+
+	if _, err := Parse([]byte(`{"kind": "other"}`)); err == nil {
+		t.Error("expected error for unknown document")
+	}
+}
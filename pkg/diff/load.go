@@ -0,0 +1,112 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/history"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// Snapshot is a set of control results loaded from a file.
+type Snapshot struct {
+	Label   string
+	Results []control.ControlValidationResult
+}
+
+// Load reads control results from a history run file or from a JSON
+// report written with --format json.
+func Load(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	snap, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if snap.Label == "" {
+		snap.Label = path
+	} else {
+		snap.Label = path + " (" + snap.Label + ")"
+	}
+	return snap, nil
+}
+
+// Parse parses control results from a history run or a JSON report.
+func Parse(data []byte) (*Snapshot, error) {
+	var probe struct {
+		FormatVersion int    `json:"formatVersion"`
+		SchemaVersion string `json:"schemaVersion"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case probe.FormatVersion > 0:
+		var run history.Run
+		if err := json.Unmarshal(data, &run); err != nil {
+			return nil, err
+		}
+		return &Snapshot{Label: run.ID, Results: run.ControlResults()}, nil
+	case probe.SchemaVersion != "":
+		var r report.Report
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		return fromReport(&r)
+	}
+	return nil, fmt.Errorf("not a validation run or JSON report")
+}
+
+// fromReport reads the "controls" section of a report.
+func fromReport(r *report.Report) (*Snapshot, error) {
+	if r.SchemaVersion != report.SchemaVersion {
+		return nil, fmt.Errorf("unsupported report schema version %q", r.SchemaVersion)
+	}
+	section := r.GetSection("controls")
+	if section == nil {
+		return nil, fmt.Errorf("%s report has no control results", r.Kind)
+	}
+
+	snap := &Snapshot{Results: make([]control.ControlValidationResult, 0, len(section.Rows))}
+	if !r.GeneratedAt.IsZero() {
+		snap.Label = r.Kind + " " + r.GeneratedAt.Format(time.RFC3339)
+	}
+	for _, row := range section.Rows {
+		snap.Results = append(snap.Results, control.ControlValidationResult{
+			ControlID:     str(row.Get("id")),
+			ControlName:   str(row.Get("name")),
+			Status:        str(row.Get("status")),
+			Effectiveness: num(row.Get("effectiveness")),
+			Confidence:    num(row.Get("confidence")),
+			Issues:        issues(row.Get("issues")),
+			ScoringModel:  str(row.Get("scoringModel")),
+		})
+	}
+	return snap, nil
+}
+
+func str(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
+
+func num(v interface{}) float64 {
+	f, _ := v.(float64)
+	return f
+}
+
+// issues parses issues listed as "CODE: message" strings.
+func issues(v interface{}) []control.Issue {
+	items, _ := v.([]interface{})
+	out := make([]control.Issue, 0, len(items))
+	for _, item := range items {
+		out = append(out, control.ParseIssue(str(item)))
+	}
+	return out
+}
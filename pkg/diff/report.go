@@ -0,0 +1,183 @@
+package diff
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// ChangeColumns are the columns of a report section of control changes.
+var ChangeColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "name", Title: "Name", Kind: report.KindString},
+	{Key: "change", Title: "Change", Kind: report.KindString},
+	{Key: "statusFrom", Title: "Status Before", Kind: report.KindString},
+	{Key: "statusTo", Title: "Status After", Kind: report.KindString},
+	{Key: "effectivenessFrom", Title: "Effectiveness Before", Kind: report.KindPercent},
+	{Key: "effectivenessTo", Title: "Effectiveness After", Kind: report.KindPercent},
+	{Key: "effectivenessDelta", Title: "Effectiveness Δ", Kind: report.KindPercent},
+	{Key: "confidenceDelta", Title: "Confidence Δ", Kind: report.KindPercent},
+	{Key: "newIssues", Title: "New Issues", Kind: report.KindList},
+	{Key: "resolvedIssues", Title: "Resolved Issues", Kind: report.KindList},
+}
+
+// Report builds a structured report of the diff. Unchanged controls are
+// counted in the summary but not listed.
+func (d *Diff) Report() *report.Report {
+	r := report.New("diff", "Control Posture Diff")
+	r.AddSummary("from", d.From)
+	r.AddSummary("to", d.To)
+	for _, kind := range []ChangeKind{KindRegressed, KindImproved, KindChanged, KindAdded, KindRemoved, KindUnchanged} {
+		r.AddSummary(string(kind), d.Count(kind))
+	}
+
+	section := r.AddSection("changes", "Changes", ChangeColumns...)
+	for _, c := range d.Changes {
+		if c.Kind == KindUnchanged {
+			continue
+		}
+		section.AddRow(
+			c.ControlID,
+			c.ControlName,
+			string(c.Kind),
+			c.StatusFrom,
+			c.StatusTo,
+			c.EffectivenessFrom,
+			c.EffectivenessTo,
+			c.EffectivenessDelta(),
+			c.ConfidenceDelta(),
+			issueStrings(c.NewIssues),
+			issueStrings(c.ResolvedIssues),
+		)
+	}
+	return r
+}
+
+// Text renders the diff for a terminal or a change review comment.
+func (d *Diff) Text() string {
+	var sb strings.Builder
+	sb.WriteString("=== Control Posture Diff ===\n\n")
+	sb.WriteString("From: " + d.From + "\n")
+	sb.WriteString("To:   " + d.To + "\n\n")
+	sb.WriteString(fmt.Sprintf("Regressed: %d  Improved: %d  Changed: %d  Added: %d  Removed: %d  Unchanged: %d\n\n",
+		d.Count(KindRegressed), d.Count(KindImproved), d.Count(KindChanged),
+		d.Count(KindAdded), d.Count(KindRemoved), d.Count(KindUnchanged)))
+
+	listed := 0
+	for _, c := range d.Changes {
+		if c.Kind == KindUnchanged {
+			continue
+		}
+		listed++
+		sb.WriteString(fmt.Sprintf("[%s] %s (%s)\n", strings.ToUpper(string(c.Kind)), c.ControlName, c.ControlID))
+		switch c.Kind {
+		case KindAdded:
+			sb.WriteString(fmt.Sprintf("    Status: %s, %.1f%% effective\n", c.StatusTo, c.EffectivenessTo*100))
+		case KindRemoved:
+			sb.WriteString(fmt.Sprintf("    Was: %s, %.1f%% effective\n", c.StatusFrom, c.EffectivenessFrom*100))
+		default:
+			if c.StatusChanged() {
+				sb.WriteString(fmt.Sprintf("    Status: %s → %s\n", c.StatusFrom, c.StatusTo))
+			}
+			sb.WriteString(fmt.Sprintf("    Effectiveness: %.1f%% → %.1f%% (%+.1f)\n",
+				c.EffectivenessFrom*100, c.EffectivenessTo*100, c.EffectivenessDelta()*100))
+			if c.ConfidenceDelta() <= -epsilon || c.ConfidenceDelta() >= epsilon {
+				sb.WriteString(fmt.Sprintf("    Confidence: %.1f%% → %.1f%% (%+.1f)\n",
+					c.ConfidenceFrom*100, c.ConfidenceTo*100, c.ConfidenceDelta()*100))
+			}
+		}
+		for _, issue := range c.NewIssues {
+			sb.WriteString(fmt.Sprintf("    + [%s] %s\n", issue.Severity, issue))
+		}
+		for _, issue := range c.ResolvedIssues {
+			sb.WriteString(fmt.Sprintf("    - [%s] %s\n", issue.Severity, issue))
+		}
+		sb.WriteString("\n")
+	}
+	if listed == 0 {
+		sb.WriteString("No changes\n")
+	}
+	return sb.String()
+}
+
+// Markdown renders the diff as a Markdown comment for change reviews.
+func (d *Diff) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("## Control Posture Diff\n\n")
+	sb.WriteString(fmt.Sprintf("`%s` → `%s`\n\n", d.From, d.To))
+	sb.WriteString(fmt.Sprintf("**%d regressed**, %d improved, %d changed, %d added, %d removed, %d unchanged\n\n",
+		d.Count(KindRegressed), d.Count(KindImproved), d.Count(KindChanged),
+		d.Count(KindAdded), d.Count(KindRemoved), d.Count(KindUnchanged)))
+
+	rows := 0
+	var table strings.Builder
+	table.WriteString("| Control | Change | Status | Effectiveness | Issues |\n")
+	table.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, c := range d.Changes {
+		if c.Kind == KindUnchanged {
+			continue
+		}
+		rows++
+
+		status := c.StatusTo
+		effectiveness := fmt.Sprintf("%.1f%%", c.EffectivenessTo*100)
+		switch c.Kind {
+		case KindRemoved:
+			status = "~~" + c.StatusFrom + "~~"
+			effectiveness = fmt.Sprintf("~~%.1f%%~~", c.EffectivenessFrom*100)
+		case KindAdded:
+		default:
+			if c.StatusChanged() {
+				status = c.StatusFrom + " → " + c.StatusTo
+			}
+			effectiveness = fmt.Sprintf("%.1f%% → %.1f%% (%+.1f)", c.EffectivenessFrom*100, c.EffectivenessTo*100, c.EffectivenessDelta()*100)
+		}
+
+		var issues []string
+		for _, issue := range c.NewIssues {
+			issues = append(issues, "➕ "+issue.String())
+		}
+		for _, issue := range c.ResolvedIssues {
+			issues = append(issues, "✅ "+issue.String())
+		}
+
+		table.WriteString(fmt.Sprintf("| %s (`%s`) | %s | %s | %s | %s |\n",
+			cell(c.ControlName), c.ControlID, changeLabel(c.Kind), status, effectiveness, cell(strings.Join(issues, "<br>"))))
+	}
+
+	if rows == 0 {
+		sb.WriteString("No changes.\n")
+	} else {
+		sb.WriteString(table.String())
+	}
+	return sb.String()
+}
+
+func changeLabel(kind ChangeKind) string {
+	switch kind {
+	case KindRegressed:
+		return "🔴 regressed"
+	case KindImproved:
+		return "🟢 improved"
+	case KindAdded:
+		return "🆕 added"
+	case KindRemoved:
+		return "🗑️ removed"
+	}
+	return string(kind)
+}
+
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// issueStrings returns issues as "CODE: message" strings.
+func issueStrings(issues []control.Issue) []string {
+	list := make([]string, len(issues))
+	for i, issue := range issues {
+		list[i] = issue.String()
+	}
+	return list
+}
@@ -0,0 +1,105 @@
+// Package engine runs validation work concurrently on a bounded worker pool.
+package engine
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// Options configures a validation run.
+type Options struct {
+	Workers     int
+	Timeout     time.Duration
+	TaskTimeout time.Duration
+	Retries     int
+	Backoff     time.Duration
+}
+
+// DefaultOptions returns options using one worker per CPU, no timeouts and
+// no retries.
+func DefaultOptions() Options {
+	return Options{
+		Workers: runtime.NumCPU(),
+		Backoff: 100 * time.Millisecond,
+	}
+}
+
+// Attempt is called for each try of a task. It returns true when the task
+// should be retried.
+type Attempt func(ctx context.Context, i, attempt int) (retry bool)
+
+// Run calls fn for tasks 0..n-1 on up to opts.Workers goroutines. Each
+// attempt gets its own context bounded by opts.TaskTimeout, and retries wait
+// with exponential backoff starting at opts.Backoff. Run returns the context
+// error if the run was cancelled or exceeded opts.Timeout; tasks that had not
+// started by then are skipped.
+func Run(ctx context.Context, n int, opts Options, fn Attempt) error {
+	if opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Timeout)
+		defer cancel()
+	}
+
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > n {
+		workers = n
+	}
+
+	tasks := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				runTask(ctx, i, opts, fn)
+			}
+		}()
+	}
+
+feed:
+	for i := 0; i < n; i++ {
+		select {
+		case tasks <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(tasks)
+	wg.Wait()
+
+	return ctx.Err()
+}
+
+// runTask runs one task with its retries.
+func runTask(ctx context.Context, i int, opts Options, fn Attempt) {
+	backoff := opts.Backoff
+	for attempt := 1; ; attempt++ {
+		if ctx.Err() != nil {
+			return
+		}
+
+		taskCtx, cancel := ctx, context.CancelFunc(func() {})
+		if opts.TaskTimeout > 0 {
+			taskCtx, cancel = context.WithTimeout(ctx, opts.TaskTimeout)
+		}
+		retry := fn(taskCtx, i, attempt)
+		cancel()
+
+		if !retry || attempt > opts.Retries {
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,78 @@
+package engine
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunBoundsWorkers(t *testing.T) {
+	var running, peak int32
+	done := make([]bool, 50)
+
+	err := Run(context.Background(), len(done), Options{Workers: 4}, func(ctx context.Context, i, attempt int) bool {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		done[i] = true
+		return false
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak > 4 {
+		t.Errorf("peak concurrency %d exceeds 4 workers", peak)
+	}
+	for i, ok := range done {
+		if !ok {
+			t.Errorf("task %d not run", i)
+		}
+	}
+}
+
+func TestRunRetries(t *testing.T) {
+	attempts := make([]int, 3)
+	opts := Options{Workers: 2, Retries: 2, Backoff: time.Millisecond}
+
+	Run(context.Background(), len(attempts), opts, func(ctx context.Context, i, attempt int) bool {
+		attempts[i] = attempt
+		return attempt < i+1
+	})
+
+	for i, want := range []int{1, 2, 3} {
+		if attempts[i] != want {
+			t.Errorf("task %d ran %d attempts, want %d", i, attempts[i], want)
+		}
+	}
+}
+
+func TestRunTimeouts(t *testing.T) {
+	var expired int32
+	opts := Options{Workers: 2, TaskTimeout: 10 * time.Millisecond}
+	Run(context.Background(), 2, opts, func(ctx context.Context, i, attempt int) bool {
+		<-ctx.Done()
+		atomic.AddInt32(&expired, 1)
+		return false
+	})
+	if expired != 2 {
+		t.Errorf("expected both tasks to hit the task timeout, got %d", expired)
+	}
+
+	var started int32
+	opts = Options{Workers: 1, Timeout: 20 * time.Millisecond}
+	err := Run(context.Background(), 100, opts, func(ctx context.Context, i, attempt int) bool {
+		atomic.AddInt32(&started, 1)
+		time.Sleep(5 * time.Millisecond)
+		return false
+	})
+	if err != context.DeadlineExceeded || started >= 100 {
+		t.Errorf("expected global timeout to stop the run early: err=%v started=%d", err, started)
+	}
+}
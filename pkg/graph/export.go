@@ -0,0 +1,91 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// WriteDOT writes the graph as a Graphviz DOT digraph with edges pointing
+// from each node to the nodes it depends on. Nodes of a styled class are
+// filled with its color.
+func (g *Graph) WriteDOT(w io.Writer, name string) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "digraph %s {\n", dotQuote(name))
+	fmt.Fprintln(bw, "  rankdir=LR;")
+	fmt.Fprintln(bw, "  node [shape=box, style=\"rounded,filled\", fillcolor=\"#ffffff\"];")
+	for _, n := range g.nodes {
+		attrs := []string{"label=" + dotQuote(n.Label)}
+		if fill, ok := g.styles[n.Class]; ok {
+			attrs = append(attrs, "fillcolor="+dotQuote(fill))
+		}
+		fmt.Fprintf(bw, "  %s [%s];\n", dotQuote(n.ID), strings.Join(attrs, ", "))
+	}
+	for _, n := range g.nodes {
+		for _, dep := range g.deps[n.ID] {
+			fmt.Fprintf(bw, "  %s -> %s;\n", dotQuote(n.ID), dotQuote(dep))
+		}
+	}
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
+
+// WriteMermaid writes the graph as a Mermaid flowchart with edges pointing
+// from each node to the nodes it depends on. Nodes are given generated IDs
+// so any node ID can be used, and styled classes become class definitions.
+func (g *Graph) WriteMermaid(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "flowchart LR")
+	ids := make(map[string]string, len(g.nodes))
+	for i, n := range g.nodes {
+		ids[n.ID] = fmt.Sprintf("n%d", i)
+		fmt.Fprintf(bw, "  %s[\"%s\"]\n", ids[n.ID], mermaidEscape(n.Label))
+	}
+	for _, n := range g.nodes {
+		for _, dep := range g.deps[n.ID] {
+			fmt.Fprintf(bw, "  %s --> %s\n", ids[n.ID], ids[dep])
+		}
+	}
+
+	classes := make([]string, 0, len(g.styles))
+	for class := range g.styles {
+		classes = append(classes, class)
+	}
+	sort.Strings(classes)
+	for _, class := range classes {
+		var members []string
+		for _, n := range g.nodes {
+			if n.Class == class {
+				members = append(members, ids[n.ID])
+			}
+		}
+		if len(members) == 0 {
+			continue
+		}
+		fmt.Fprintf(bw, "  classDef %s fill:%s\n", mermaidClass(class), g.styles[class])
+		fmt.Fprintf(bw, "  class %s %s\n", strings.Join(members, ","), mermaidClass(class))
+	}
+	return bw.Flush()
+}
+
+// dotQuote quotes a DOT ID.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// mermaidEscape escapes a Mermaid node label.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(`"`, "#quot;", "\n", "<br/>").Replace(s)
+}
+
+// mermaidClass turns a class into a Mermaid class name.
+func mermaidClass(class string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, class)
+}
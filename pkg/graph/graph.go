@@ -0,0 +1,294 @@
+// Package graph holds directed dependency graphs between named nodes. It
+// finds cycles, orders nodes so that every node follows the nodes it
+// depends on, and exports graphs as Graphviz DOT and Mermaid flowcharts.
+package graph
+
+import (
+	"sort"
+	"strings"
+)
+
+// Node is a node of a graph. Class groups nodes that are styled alike when
+// the graph is exported.
+type Node struct {
+	ID    string
+	Label string
+	Class string
+}
+
+// Graph is a directed graph in which an edge from a node to another means
+// the first depends on the second. Nodes keep the order they were added in.
+type Graph struct {
+	nodes     []Node
+	index     map[string]int
+	deps      map[string][]string
+	dependent map[string][]string
+	styles    map[string]string
+}
+
+// New creates an empty graph.
+func New() *Graph {
+	return &Graph{
+		index:     make(map[string]int),
+		deps:      make(map[string][]string),
+		dependent: make(map[string][]string),
+		styles:    make(map[string]string),
+	}
+}
+
+// AddNode adds a node, or updates the label of an existing one.
+func (g *Graph) AddNode(id, label string) {
+	if i, ok := g.index[id]; ok {
+		g.nodes[i].Label = label
+		return
+	}
+	g.index[id] = len(g.nodes)
+	g.nodes = append(g.nodes, Node{ID: id, Label: label})
+}
+
+// AddEdge records that from depends on to, adding either node if needed.
+// Duplicate edges are ignored.
+func (g *Graph) AddEdge(from, to string) {
+	for _, id := range []string{from, to} {
+		if _, ok := g.index[id]; !ok {
+			g.AddNode(id, id)
+		}
+	}
+	for _, id := range g.deps[from] {
+		if id == to {
+			return
+		}
+	}
+	g.deps[from] = append(g.deps[from], to)
+	g.dependent[to] = append(g.dependent[to], from)
+}
+
+// SetClass sets the class of a node.
+func (g *Graph) SetClass(id, class string) {
+	if i, ok := g.index[id]; ok {
+		g.nodes[i].Class = class
+	}
+}
+
+// Style sets the fill color of the nodes of a class, such as "#f8d7da".
+func (g *Graph) Style(class, fill string) {
+	g.styles[class] = fill
+}
+
+// Nodes returns the nodes in the order they were added.
+func (g *Graph) Nodes() []Node {
+	return g.nodes
+}
+
+// Node returns the node with the given ID.
+func (g *Graph) Node(id string) (Node, bool) {
+	i, ok := g.index[id]
+	if !ok {
+		return Node{}, false
+	}
+	return g.nodes[i], true
+}
+
+// DependsOn returns the nodes a node depends on.
+func (g *Graph) DependsOn(id string) []string {
+	return g.deps[id]
+}
+
+// Dependents returns the nodes that depend on a node.
+func (g *Graph) Dependents(id string) []string {
+	return g.dependent[id]
+}
+
+// CycleError reports the cycles that prevent a graph from being ordered.
+type CycleError struct {
+	Cycles [][]string
+}
+
+// Error lists each cycle as "a -> b -> a".
+func (e *CycleError) Error() string {
+	cycles := make([]string, len(e.Cycles))
+	for i, c := range e.Cycles {
+		cycles[i] = strings.Join(append(c, c[0]), " -> ")
+	}
+	return "dependency cycle: " + strings.Join(cycles, "; ")
+}
+
+// Cycles returns the cycles of the graph, each starting at its earliest
+// added node and listing the nodes along its edges. A node that depends on
+// itself is a cycle of one. Nodes on several cycles that share nodes are
+// reported once, as one of those cycles.
+func (g *Graph) Cycles() [][]string {
+	var cycles [][]string
+	for _, component := range g.components() {
+		// Walk from the earliest node along edges within the component
+		// until a node repeats
+		in := make(map[string]bool, len(component))
+		for _, id := range component {
+			in[id] = true
+		}
+		start := component[0]
+		if len(component) == 1 && !g.dependsOnItself(start) {
+			continue
+		}
+		path := []string{start}
+		at := map[string]int{start: 0}
+		for id := start; ; {
+			var next string
+			for _, dep := range g.deps[id] {
+				if in[dep] {
+					next = dep
+					break
+				}
+			}
+			if i, ok := at[next]; ok {
+				cycles = append(cycles, rotate(path[i:], g.index))
+				break
+			}
+			at[next] = len(path)
+			path = append(path, next)
+			id = next
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool {
+		return g.index[cycles[i][0]] < g.index[cycles[j][0]]
+	})
+	return cycles
+}
+
+// Order returns the nodes ordered so that every node follows the nodes it
+// depends on, keeping the order they were added in where the edges allow.
+// When the graph has cycles, the nodes that can be ordered are returned
+// with a CycleError; nodes on a cycle and their dependents are left out.
+func (g *Graph) Order() ([]string, error) {
+	pending := make(map[string]int, len(g.nodes))
+	for _, n := range g.nodes {
+		pending[n.ID] = len(g.deps[n.ID])
+	}
+
+	order := make([]string, 0, len(g.nodes))
+	done := make(map[string]bool, len(g.nodes))
+	for progress := true; progress; {
+		progress = false
+		for _, n := range g.nodes {
+			if done[n.ID] || pending[n.ID] > 0 {
+				continue
+			}
+			done[n.ID] = true
+			order = append(order, n.ID)
+			for _, id := range g.dependent[n.ID] {
+				pending[id]--
+			}
+			// Restart so earlier nodes freed by this one come first
+			progress = true
+			break
+		}
+	}
+	if len(order) < len(g.nodes) {
+		return order, &CycleError{Cycles: g.Cycles()}
+	}
+	return order, nil
+}
+
+// Upstream returns every node a node depends on directly or indirectly,
+// in the order they were added.
+func (g *Graph) Upstream(id string) []string {
+	return g.reach(id, g.deps)
+}
+
+// Downstream returns every node that depends on a node directly or
+// indirectly, in the order they were added.
+func (g *Graph) Downstream(id string) []string {
+	return g.reach(id, g.dependent)
+}
+
+// reach returns the nodes reachable from a node along edges.
+func (g *Graph) reach(id string, edges map[string][]string) []string {
+	seen := map[string]bool{id: true}
+	stack := []string{id}
+	for len(stack) > 0 {
+		next := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, n := range edges[next] {
+			if !seen[n] {
+				seen[n] = true
+				stack = append(stack, n)
+			}
+		}
+	}
+	var ids []string
+	for _, n := range g.nodes {
+		if seen[n.ID] && n.ID != id {
+			ids = append(ids, n.ID)
+		}
+	}
+	return ids
+}
+
+// components returns the strongly connected components of the graph using
+// Tarjan's algorithm, each listed in the order its nodes were added.
+func (g *Graph) components() [][]string {
+	index := make(map[string]int, len(g.nodes))
+	low := make(map[string]int, len(g.nodes))
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+
+	var visit func(id string)
+	visit = func(id string) {
+		index[id] = len(index)
+		low[id] = index[id]
+		stack = append(stack, id)
+		onStack[id] = true
+		for _, dep := range g.deps[id] {
+			if _, ok := index[dep]; !ok {
+				visit(dep)
+				low[id] = min(low[id], low[dep])
+			} else if onStack[dep] {
+				low[id] = min(low[id], index[dep])
+			}
+		}
+		if low[id] != index[id] {
+			return
+		}
+		var component []string
+		for {
+			n := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[n] = false
+			component = append(component, n)
+			if n == id {
+				break
+			}
+		}
+		sort.Slice(component, func(i, j int) bool {
+			return g.index[component[i]] < g.index[component[j]]
+		})
+		components = append(components, component)
+	}
+	for _, n := range g.nodes {
+		if _, ok := index[n.ID]; !ok {
+			visit(n.ID)
+		}
+	}
+	return components
+}
+
+func (g *Graph) dependsOnItself(id string) bool {
+	for _, dep := range g.deps[id] {
+		if dep == id {
+			return true
+		}
+	}
+	return false
+}
+
+// rotate returns a cycle starting at its earliest added node.
+func rotate(cycle []string, index map[string]int) []string {
+	first := 0
+	for i, id := range cycle {
+		if index[id] < index[cycle[first]] {
+			first = i
+		}
+	}
+	return append(append([]string(nil), cycle[first:]...), cycle[:first]...)
+}
@@ -0,0 +1,102 @@
+package graph
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOrder(t *testing.T) {
+	g := New()
+	for _, id := range []string{"sso", "mfa", "idp", "logging"} {
+		g.AddNode(id, strings.ToUpper(id))
+	}
+	g.AddEdge("sso", "mfa")
+	g.AddEdge("mfa", "idp")
+	g.AddEdge("sso", "idp")
+	g.AddEdge("sso", "idp")
+
+	order, err := g.Order()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"idp", "mfa", "sso", "logging"}; !reflect.DeepEqual(order, want) {
+		t.Errorf("order = %v, want %v", order, want)
+	}
+	if deps := g.DependsOn("sso"); !reflect.DeepEqual(deps, []string{"mfa", "idp"}) {
+		t.Errorf("DependsOn(sso) = %v", deps)
+	}
+	if down := g.Downstream("idp"); !reflect.DeepEqual(down, []string{"sso", "mfa"}) {
+		t.Errorf("Downstream(idp) = %v", down)
+	}
+	if up := g.Upstream("sso"); !reflect.DeepEqual(up, []string{"mfa", "idp"}) {
+		t.Errorf("Upstream(sso) = %v", up)
+	}
+	if len(g.Cycles()) != 0 {
+		t.Errorf("acyclic graph has cycles %v", g.Cycles())
+	}
+}
+
+func TestCycles(t *testing.T) {
+	g := New()
+	g.AddEdge("a", "b")
+	g.AddEdge("b", "c")
+	g.AddEdge("c", "b")
+	g.AddEdge("d", "d")
+	g.AddEdge("e", "a")
+
+	if want := [][]string{{"b", "c"}, {"d"}}; !reflect.DeepEqual(g.Cycles(), want) {
+		t.Errorf("cycles = %v, want %v", g.Cycles(), want)
+	}
+
+	order, err := g.Order()
+	var cycleErr *CycleError
+	if !errors.As(err, &cycleErr) || len(cycleErr.Cycles) != 2 {
+		t.Fatalf("Order error = %v", err)
+	}
+	if err.Error() != "dependency cycle: b -> c -> b; d -> d" {
+		t.Errorf("error = %q", err)
+	}
+	if len(order) != 0 {
+		t.Errorf("order = %v, want nothing ordered", order)
+	}
+}
+
+func TestExport(t *testing.T) {
+	g := New()
+	g.AddNode("c-1", "c-1\nSingle \"Sign-On\"")
+	g.AddEdge("c-1", "c-2")
+	g.Style("ineffective", "#f8d7da")
+	g.SetClass("c-2", "ineffective")
+
+	var dot bytes.Buffer
+	if err := g.WriteDOT(&dot, "Test"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`digraph "Test" {`,
+		`"c-1" [label="c-1\nSingle \"Sign-On\""];`,
+		`"c-2" [label="c-2", fillcolor="#f8d7da"];`,
+		`"c-1" -> "c-2";`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("DOT output lacks %q:\n%s", want, dot.String())
+		}
+	}
+
+	var mermaid bytes.Buffer
+	if err := g.WriteMermaid(&mermaid); err != nil {
+		t.Fatal(err)
+	}
+	want := "flowchart LR\n" +
+		"  n0[\"c-1<br/>Single #quot;Sign-On#quot;\"]\n" +
+		"  n1[\"c-2\"]\n" +
+		"  n0 --> n1\n" +
+		"  classDef ineffective fill:#f8d7da\n" +
+		"  class n1 ineffective\n"
+	if mermaid.String() != want {
+		t.Errorf("Mermaid output:\n%s\nwant:\n%s", mermaid.String(), want)
+	}
+}
@@ -0,0 +1,441 @@
+// Package history stores validation runs on disk so that results outlive a
+// single CLI invocation.
+//
+// A store is a directory holding one JSON file per run under runs/ and an
+// append-only index, index.jsonl, with one summary line per run. Runs are
+// never modified once written; Prune is the only operation that removes
+// data, and it rewrites the index atomically.
+package history
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// DefaultDir is the default location of the history store.
+const DefaultDir = ".securitycontrol/history"
+
+// FormatVersion is the version of the stored run format.
+const FormatVersion = 1
+
+const indexFile = "index.jsonl"
+
+// ErrNotFound is returned when no run matches an ID.
+var ErrNotFound = errors.New("run not found")
+
+// ErrNoHistory is returned by OpenReadOnly when the store does not exist.
+var ErrNoHistory = errors.New("no history")
+
+// Run is a stored validation run.
+type Run struct {
+	FormatVersion int             `json:"formatVersion"`
+	ID            string          `json:"id"`
+	Command       string          `json:"command"`
+	ToolVersion   string          `json:"toolVersion"`
+	CatalogHash   string          `json:"catalogHash"`
+	CatalogFiles  []string        `json:"catalogFiles"`
+	Framework     string          `json:"framework"`
+	StartedAt     time.Time       `json:"startedAt"`
+	FinishedAt    time.Time       `json:"finishedAt"`
+	Controls      []ControlRecord `json:"controls"`
+	Tests         []TestRecord    `json:"tests"`
+}
+
+// Entry is the index summary of a run.
+type Entry struct {
+	ID                 string    `json:"id"`
+	Command            string    `json:"command"`
+	ToolVersion        string    `json:"toolVersion"`
+	CatalogHash        string    `json:"catalogHash"`
+	Framework          string    `json:"framework"`
+	StartedAt          time.Time `json:"startedAt"`
+	Controls           int       `json:"controls"`
+	Effective          int       `json:"effective"`
+	PartiallyEffective int       `json:"partiallyEffective"`
+	Ineffective        int       `json:"ineffective"`
+	Tests              int       `json:"tests"`
+	TestsPassed        int       `json:"testsPassed"`
+	File               string    `json:"file"`
+}
+
+// Retention selects the runs kept by Prune. A zero field does not limit.
+type Retention struct {
+	KeepLast int
+	MaxAge   time.Duration
+}
+
+// Store is a file-based history of validation runs.
+type Store struct {
+	mu  sync.Mutex
+	dir string
+}
+
+// Open opens the store in dir for recording and pruning runs, creating
+// the directory if needed.
+func Open(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "runs"), 0o755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+// OpenReadOnly opens the store in dir for reading. Unlike Open it does not
+// create the directory, and returns ErrNoHistory if it does not exist.
+func OpenReadOnly(dir string) (*Store, error) {
+	info, err := os.Stat(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w in %s", ErrNoHistory, dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// Dir returns the store's directory.
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// NewRun creates a run with a new ID started at the given time.
+func NewRun(command, toolVersion string, startedAt time.Time) *Run {
+	return &Run{
+		FormatVersion: FormatVersion,
+		ID:            newID(startedAt),
+		Command:       command,
+		ToolVersion:   toolVersion,
+		StartedAt:     startedAt,
+		Controls:      make([]ControlRecord, 0),
+		Tests:         make([]TestRecord, 0),
+	}
+}
+
+// AddControlResults adds control validation results to the run.
+func (r *Run) AddControlResults(results []control.ControlValidationResult) {
+	for _, result := range results {
+		r.Controls = append(r.Controls, NewControlRecord(result))
+	}
+}
+
+// AddTestResults adds test validation results to the run.
+func (r *Run) AddTestResults(results []validate.ValidationResult) {
+	for _, result := range results {
+		r.Tests = append(r.Tests, NewTestRecord(result))
+	}
+}
+
+// ControlResults returns the run's control validation results.
+func (r *Run) ControlResults() []control.ControlValidationResult {
+	results := make([]control.ControlValidationResult, len(r.Controls))
+	for i, record := range r.Controls {
+		results[i] = record.Result()
+	}
+	return results
+}
+
+// TestResults returns the run's test validation results.
+func (r *Run) TestResults() []validate.ValidationResult {
+	results := make([]validate.ValidationResult, len(r.Tests))
+	for i, record := range r.Tests {
+		results[i] = record.Result()
+	}
+	return results
+}
+
+// Entry returns the index summary of the run.
+func (r *Run) Entry() Entry {
+	e := Entry{
+		ID:          r.ID,
+		Command:     r.Command,
+		ToolVersion: r.ToolVersion,
+		CatalogHash: r.CatalogHash,
+		Framework:   r.Framework,
+		StartedAt:   r.StartedAt,
+		Controls:    len(r.Controls),
+		Tests:       len(r.Tests),
+		File:        filepath.ToSlash(filepath.Join("runs", r.ID+".json")),
+	}
+	for _, c := range r.Controls {
+		switch c.Status {
+		case "EFFECTIVE":
+			e.Effective++
+		case "PARTIALLY_EFFECTIVE":
+			e.PartiallyEffective++
+		case "INEFFECTIVE":
+			e.Ineffective++
+		}
+	}
+	for _, t := range r.Tests {
+		if t.Passed {
+			e.TestsPassed++
+		}
+	}
+	return e
+}
+
+// Append writes a run and adds it to the index. The run file is written
+// first, so a crash leaves at worst an unindexed run file.
+func (s *Store) Append(run *Run) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if run.FinishedAt.IsZero() {
+		run.FinishedAt = time.Now()
+	}
+	data, err := json.MarshalIndent(run, "", "  ")
+	if err != nil {
+		return err
+	}
+	entry := run.Entry()
+	if err := writeNew(filepath.Join(s.dir, filepath.FromSlash(entry.File)), data); err != nil {
+		return err
+	}
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(s.dir, indexFile), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// List returns the indexed runs, oldest first.
+func (s *Store) List() ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.list()
+}
+
+func (s *Store) list() ([]Entry, error) {
+	f, err := os.Open(filepath.Join(s.dir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := make([]Entry, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", indexFile, n, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].StartedAt.Before(entries[j].StartedAt)
+	})
+	return entries, nil
+}
+
+// Get returns the run with the given ID. The ID may be "latest" or a
+// unique prefix of a run ID.
+func (s *Store) Get(id string) (*Run, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	entry, err := find(entries, id)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, filepath.FromSlash(entry.File)))
+	if err != nil {
+		return nil, err
+	}
+	var run Run
+	if err := json.Unmarshal(data, &run); err != nil {
+		return nil, fmt.Errorf("%s: %w", entry.File, err)
+	}
+	if run.FormatVersion > FormatVersion {
+		return nil, fmt.Errorf("%s: unsupported run format version %d", entry.File, run.FormatVersion)
+	}
+	return &run, nil
+}
+
+// Latest returns the most recent run, or ErrNotFound if there is none.
+func (s *Store) Latest() (*Run, error) {
+	return s.Get("latest")
+}
+
+// find looks up an index entry by ID, "latest" or unique prefix.
+func find(entries []Entry, id string) (Entry, error) {
+	if len(entries) == 0 {
+		return Entry{}, ErrNotFound
+	}
+	if id == "latest" {
+		return entries[len(entries)-1], nil
+	}
+
+	var matches []Entry
+	for _, e := range entries {
+		if e.ID == id {
+			return e, nil
+		}
+		if strings.HasPrefix(e.ID, id) {
+			matches = append(matches, e)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return Entry{}, fmt.Errorf("%w: %s", ErrNotFound, id)
+	case 1:
+		return matches[0], nil
+	}
+	return Entry{}, fmt.Errorf("run ID %q is ambiguous (%d matches)", id, len(matches))
+}
+
+// Plan returns the runs that Prune would remove.
+func (s *Store) Plan(retention Retention, now time.Time) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+	_, removed := retention.split(entries, now)
+	return removed, nil
+}
+
+// Prune removes runs outside the retention policy and returns the removed
+// entries. Runs are kept if they are among the KeepLast most recent and no
+// older than MaxAge.
+func (s *Store) Prune(retention Retention, now time.Time) ([]Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+
+	kept, removed := retention.split(entries, now)
+	if len(removed) == 0 {
+		return removed, nil
+	}
+
+	// Rewrite the index before removing files so that no indexed run is
+	// ever missing its file
+	var buf bytes.Buffer
+	for _, e := range kept {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(append(line, '\n'))
+	}
+	if err := writeAtomic(filepath.Join(s.dir, indexFile), buf.Bytes()); err != nil {
+		return nil, err
+	}
+	for _, e := range removed {
+		if err := os.Remove(filepath.Join(s.dir, filepath.FromSlash(e.File))); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// split divides entries, oldest first, into those kept and removed.
+func (r Retention) split(entries []Entry, now time.Time) (kept, removed []Entry) {
+	kept = make([]Entry, 0, len(entries))
+	removed = make([]Entry, 0)
+	for i, e := range entries {
+		tooMany := r.KeepLast > 0 && len(entries)-i > r.KeepLast
+		tooOld := r.MaxAge > 0 && now.Sub(e.StartedAt) > r.MaxAge
+		if tooMany || tooOld {
+			removed = append(removed, e)
+		} else {
+			kept = append(kept, e)
+		}
+	}
+	return kept, removed
+}
+
+// newID returns a sortable run ID such as 20240601T120000Z-1a2b3c4d.
+func newID(t time.Time) string {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	return t.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix)
+}
+
+// writeNew writes a file that must not already exist.
+func writeNew(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeAtomic replaces a file by writing a temporary file and renaming it.
+func writeAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,209 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+func appendRun(t *testing.T, store *Store, startedAt time.Time) *Run {
+	t.Helper()
+	run := NewRun("validate", "1.0.0", startedAt)
+	run.CatalogHash = "abc"
+	run.AddControlResults([]control.ControlValidationResult{
+		{ControlID: "c1", ControlName: "MFA", Status: "EFFECTIVE", Effectiveness: 1, Issues: []control.Issue{}},
+		{ControlID: "c2", ControlName: "Logging", Status: "INEFFECTIVE", Issues: []control.Issue{control.NewIssue(control.IssueNoOwner)}},
+	})
+	run.AddTestResults([]validate.ValidationResult{{
+		TestID:           "t1",
+		ControlName:      "MFA check",
+		TestPassed:       true,
+		ValidationResult: "PASS",
+		Duration:         1500 * time.Millisecond,
+		Evidence:         []validate.Evidence{validate.NewEvidence("stdout", "ok")},
+	}})
+	if err := store.Append(run); err != nil {
+		t.Fatal(err)
+	}
+	return run
+}
+
+func TestAppendAndGet(t *testing.T) {
+	store, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := store.Latest(); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Latest on empty store = %v, want ErrNotFound", err)
+	}
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	first := appendRun(t, store, now)
+	second := appendRun(t, store, now.Add(time.Hour))
+
+	entries, err := store.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].ID != first.ID || entries[1].Effective != 1 || entries[1].Ineffective != 1 || entries[1].TestsPassed != 1 {
+		t.Fatalf("entries = %+v", entries)
+	}
+
+	latest, err := store.Latest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.ID != second.ID || latest.CatalogHash != "abc" || latest.ToolVersion != "1.0.0" {
+		t.Errorf("latest = %+v", latest)
+	}
+
+	controls := latest.ControlResults()
+	if len(controls) != 2 || controls[1].Status != "INEFFECTIVE" || controls[1].Issues[0].Code != control.IssueNoOwner {
+		t.Errorf("controls = %+v", controls)
+	}
+	tests := latest.TestResults()
+	if len(tests) != 1 || tests[0].Duration != 1500*time.Millisecond || tests[0].Evidence[0].Content != "ok" {
+		t.Errorf("tests = %+v", tests)
+	}
+
+	byPrefix, err := store.Get(first.ID[:len(first.ID)-2])
+	if err != nil || byPrefix.ID != first.ID {
+		t.Errorf("Get by prefix = %v, %v", byPrefix, err)
+	}
+	if _, err := store.Get("2024"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("Get ambiguous prefix error = %v", err)
+	}
+}
+
+func TestAppendIsAppendOnly(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := appendRun(t, store, time.Now())
+
+	before, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Append(run); err == nil {
+		t.Error("appending a run twice must fail")
+	}
+	appendRun(t, store, time.Now())
+	after, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(string(after), string(before)) {
+		t.Error("index was rewritten by Append")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	var runs []*Run
+	for _, days := range []int{100, 40, 10, 1} {
+		runs = append(runs, appendRun(t, store, now.AddDate(0, 0, -days)))
+	}
+
+	planned, err := store.Plan(Retention{MaxAge: 30 * 24 * time.Hour}, now)
+	if err != nil || len(planned) != 2 {
+		t.Fatalf("Plan = %v, %v", planned, err)
+	}
+	if entries, _ := store.List(); len(entries) != 4 {
+		t.Fatal("Plan removed runs")
+	}
+
+	removed, err := store.Prune(Retention{KeepLast: 3, MaxAge: 60 * 24 * time.Hour}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].ID != runs[0].ID {
+		t.Fatalf("removed = %+v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "runs", runs[0].ID+".json")); !os.IsNotExist(err) {
+		t.Errorf("run file not removed: %v", err)
+	}
+
+	removed, err = store.Prune(Retention{KeepLast: 1}, now)
+	if err != nil || len(removed) != 2 {
+		t.Fatalf("Prune = %v, %v", removed, err)
+	}
+	entries, err := store.List()
+	if err != nil || len(entries) != 1 || entries[0].ID != runs[3].ID {
+		t.Errorf("entries after prune = %+v, %v", entries, err)
+	}
+}
+
+func TestIssueRecordLegacy(t *testing.T) {
+	var record ControlRecord
+	if err := json.Unmarshal([]byte(`{"controlId":"c1","issues":["Linked test t-1 failed",{"code":"X-1","message":"custom"}]}`), &record); err != nil {
+		t.Fatal(err)
+	}
+	issues := record.Result().Issues
+	if issues[0].Code != control.IssueTestFailed || issues[0].Remediation != "Investigate and fix failing test t-1" {
+		t.Errorf("legacy issue not parsed: %+v", issues[0])
+	}
+	if issues[1].Code != "X-1" || issues[1].Message != "custom" {
+		t.Errorf("unexpected issue: %+v", issues[1])
+	}
+}
+
+func TestControlRecordRoundTrip(t *testing.T) {
+	result := control.ControlValidationResult{
+		ControlID:     "sso",
+		Status:        "INEFFECTIVE",
+		Effectiveness: 0.45,
+		Issues:        []control.Issue{control.NewIssue(control.IssueDependencyIneffective, "idp")},
+		DegradedBy:    []string{"idp"},
+		ValidatedAt:   time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+	data, err := json.Marshal(NewControlRecord(result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var record ControlRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		t.Fatal(err)
+	}
+	got := record.Result()
+	if len(got.DegradedBy) != 1 || got.DegradedBy[0] != "idp" || got.Issues[0].Code != control.IssueDependencyIneffective {
+		t.Errorf("result after round trip = %+v", got)
+	}
+}
+
+func TestOpenReadOnly(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "history")
+	if _, err := OpenReadOnly(dir); !errors.Is(err, ErrNoHistory) {
+		t.Fatalf("OpenReadOnly on missing store = %v, want ErrNoHistory", err)
+	}
+	if _, err := os.Stat(dir); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("OpenReadOnly created %s", dir)
+	}
+
+	store, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := appendRun(t, store, time.Now())
+	readOnly, err := OpenReadOnly(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest, err := readOnly.Latest(); err != nil || latest.ID != run.ID {
+		t.Errorf("Latest = %v, %v", latest, err)
+	}
+}
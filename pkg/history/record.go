@@ -0,0 +1,214 @@
+package history
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// ControlRecord is the stored form of a control validation result.
+type ControlRecord struct {
+	ControlID       string        `json:"controlId"`
+	ControlName     string        `json:"controlName"`
+	Status          string        `json:"status"`
+	Effectiveness   float64       `json:"effectiveness"`
+	Confidence      float64       `json:"confidence"`
+	Issues          []IssueRecord `json:"issues"`
+	Evidence        []string      `json:"evidence"`
+	Recommendations []string      `json:"recommendations"`
+	TestsLinked     int           `json:"testsLinked"`
+	TestsPassed     int           `json:"testsPassed"`
+	TestsFailed     int           `json:"testsFailed"`
+	Untested        bool          `json:"untested"`
+	ScoringModel    string        `json:"scoringModel,omitempty"`
+	EffectiveUntil  *time.Time    `json:"projectedEffectiveUntil,omitempty"`
+	DegradedBy      []string      `json:"degradedBy,omitempty"`
+	ValidatedAt     time.Time     `json:"validatedAt"`
+}
+
+// TestRecord is the stored form of a test validation result.
+type TestRecord struct {
+	ID               string           `json:"id"`
+	TestID           string           `json:"testId"`
+	TestName         string           `json:"testName"`
+	Passed           bool             `json:"passed"`
+	ValidationResult string           `json:"result"`
+	Effectiveness    float64          `json:"effectiveness"`
+	RiskRemaining    float64          `json:"riskRemaining"`
+	Recommendations  []string         `json:"recommendations"`
+	ActualResult     string           `json:"actualResult,omitempty"`
+	Evidence         []EvidenceRecord `json:"evidence,omitempty"`
+	DurationMs       float64          `json:"durationMs"`
+	Attempts         int              `json:"attempts"`
+	Error            string           `json:"error,omitempty"`
+	ValidatedAt      time.Time        `json:"validatedAt"`
+}
+
+// IssueRecord is the stored form of a control issue.
+type IssueRecord struct {
+	Code        string   `json:"code,omitempty"`
+	Severity    string   `json:"severity,omitempty"`
+	Message     string   `json:"message"`
+	Field       string   `json:"field,omitempty"`
+	Remediation string   `json:"remediation,omitempty"`
+	References  []string `json:"references,omitempty"`
+}
+
+// UnmarshalJSON decodes an issue record. Runs recorded before issues had
+// codes stored each issue as its message.
+func (r *IssueRecord) UnmarshalJSON(data []byte) error {
+	var message string
+	if err := json.Unmarshal(data, &message); err == nil {
+		issue := control.ParseIssue(message)
+		*r = newIssueRecord(issue)
+		return nil
+	}
+
+	type plain IssueRecord
+	return json.Unmarshal(data, (*plain)(r))
+}
+
+// newIssueRecord converts an issue for storage.
+func newIssueRecord(issue control.Issue) IssueRecord {
+	return IssueRecord{
+		Code:        issue.Code,
+		Severity:    string(issue.Severity),
+		Message:     issue.Message,
+		Field:       issue.Field,
+		Remediation: issue.Remediation,
+		References:  issue.References,
+	}
+}
+
+// Issue converts the record back to an issue.
+func (r IssueRecord) Issue() control.Issue {
+	return control.Issue{
+		Code:        r.Code,
+		Severity:    control.Severity(r.Severity),
+		Message:     r.Message,
+		Field:       r.Field,
+		Remediation: r.Remediation,
+		References:  r.References,
+	}
+}
+
+// EvidenceRecord is the stored form of test evidence.
+type EvidenceRecord struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+	SHA256  string `json:"sha256"`
+}
+
+// NewControlRecord converts a control validation result for storage.
+func NewControlRecord(r control.ControlValidationResult) ControlRecord {
+	issues := make([]IssueRecord, len(r.Issues))
+	for i, issue := range r.Issues {
+		issues[i] = newIssueRecord(issue)
+	}
+	return ControlRecord{
+		ControlID:       r.ControlID,
+		ControlName:     r.ControlName,
+		Status:          r.Status,
+		Effectiveness:   r.Effectiveness,
+		Confidence:      r.Confidence,
+		Issues:          issues,
+		Evidence:        r.Evidence,
+		Recommendations: r.Recommendations,
+		TestsLinked:     r.TestsLinked,
+		TestsPassed:     r.TestsPassed,
+		TestsFailed:     r.TestsFailed,
+		Untested:        r.Untested,
+		ScoringModel:    r.ScoringModel,
+		EffectiveUntil:  optionalTime(r.ProjectedEffectiveUntil),
+		DegradedBy:      r.DegradedBy,
+		ValidatedAt:     r.ValidatedAt,
+	}
+}
+
+// optionalTime returns a pointer to t, or nil if t is zero.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// Result converts the record back to a control validation result.
+func (r ControlRecord) Result() control.ControlValidationResult {
+	issues := make([]control.Issue, len(r.Issues))
+	for i, issue := range r.Issues {
+		issues[i] = issue.Issue()
+	}
+	result := control.ControlValidationResult{
+		ControlID:       r.ControlID,
+		ControlName:     r.ControlName,
+		Status:          r.Status,
+		Effectiveness:   r.Effectiveness,
+		Confidence:      r.Confidence,
+		Issues:          issues,
+		Evidence:        r.Evidence,
+		Recommendations: r.Recommendations,
+		TestsLinked:     r.TestsLinked,
+		TestsPassed:     r.TestsPassed,
+		TestsFailed:     r.TestsFailed,
+		Untested:        r.Untested,
+		ScoringModel:    r.ScoringModel,
+		DegradedBy:      r.DegradedBy,
+		ValidatedAt:     r.ValidatedAt,
+	}
+	if r.EffectiveUntil != nil {
+		result.ProjectedEffectiveUntil = *r.EffectiveUntil
+	}
+	return result
+}
+
+// NewTestRecord converts a test validation result for storage.
+func NewTestRecord(r validate.ValidationResult) TestRecord {
+	evidence := make([]EvidenceRecord, len(r.Evidence))
+	for i, e := range r.Evidence {
+		evidence[i] = EvidenceRecord{Name: e.Name, Content: e.Content, SHA256: e.SHA256}
+	}
+	return TestRecord{
+		ID:               r.ID,
+		TestID:           r.TestID,
+		TestName:         r.ControlName,
+		Passed:           r.TestPassed,
+		ValidationResult: r.ValidationResult,
+		Effectiveness:    r.Effectiveness,
+		RiskRemaining:    r.RiskRemaining,
+		Recommendations:  r.Recommendations,
+		ActualResult:     r.ActualResult,
+		Evidence:         evidence,
+		DurationMs:       float64(r.Duration) / float64(time.Millisecond),
+		Attempts:         r.Attempts,
+		Error:            r.Error,
+		ValidatedAt:      r.ValidatedAt,
+	}
+}
+
+// Result converts the record back to a test validation result.
+func (r TestRecord) Result() validate.ValidationResult {
+	evidence := make([]validate.Evidence, len(r.Evidence))
+	for i, e := range r.Evidence {
+		evidence[i] = validate.Evidence{Name: e.Name, Content: e.Content, SHA256: e.SHA256}
+	}
+	return validate.ValidationResult{
+		ID:               r.ID,
+		TestID:           r.TestID,
+		ControlID:        r.TestID,
+		ControlName:      r.TestName,
+		TestPassed:       r.Passed,
+		ValidationResult: r.ValidationResult,
+		Effectiveness:    r.Effectiveness,
+		RiskRemaining:    r.RiskRemaining,
+		Recommendations:  r.Recommendations,
+		ActualResult:     r.ActualResult,
+		Evidence:         evidence,
+		Duration:         time.Duration(r.DurationMs * float64(time.Millisecond)),
+		Attempts:         r.Attempts,
+		Error:            r.Error,
+		ValidatedAt:      r.ValidatedAt,
+	}
+}
@@ -0,0 +1,53 @@
+package history
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// EntryColumns are the columns of a report section listing runs.
+var EntryColumns = []report.Column{
+	{Key: "id", Title: "Run ID", Kind: report.KindString},
+	{Key: "startedAt", Title: "Started", Kind: report.KindTime},
+	{Key: "command", Title: "Command", Kind: report.KindString},
+	{Key: "framework", Title: "Framework", Kind: report.KindString},
+	{Key: "controls", Title: "Controls", Kind: report.KindNumber},
+	{Key: "effective", Title: "Effective", Kind: report.KindNumber},
+	{Key: "partiallyEffective", Title: "Partial", Kind: report.KindNumber},
+	{Key: "ineffective", Title: "Ineffective", Kind: report.KindNumber},
+	{Key: "tests", Title: "Tests", Kind: report.KindNumber},
+	{Key: "testsPassed", Title: "Tests Passed", Kind: report.KindNumber},
+	{Key: "toolVersion", Title: "Tool Version", Kind: report.KindString},
+	{Key: "catalogHash", Title: "Catalog Hash", Kind: report.KindString},
+}
+
+// AddEntries adds a "runs" section listing runs to a report.
+func AddEntries(r *report.Report, entries []Entry) {
+	section := r.AddSection("runs", "Runs", EntryColumns...)
+	for _, e := range entries {
+		section.AddRow(
+			e.ID,
+			e.StartedAt,
+			e.Command,
+			e.Framework,
+			e.Controls,
+			e.Effective,
+			e.PartiallyEffective,
+			e.Ineffective,
+			e.Tests,
+			e.TestsPassed,
+			e.ToolVersion,
+			e.CatalogHash,
+		)
+	}
+}
+
+// AddRun adds a run's metadata to a report's summary.
+func AddRun(r *report.Report, run *Run) {
+	r.AddSummary("runId", run.ID)
+	r.AddSummary("command", run.Command)
+	r.AddSummary("startedAt", run.StartedAt)
+	r.AddSummary("finishedAt", run.FinishedAt)
+	r.AddSummary("toolVersion", run.ToolVersion)
+	r.AddSummary("catalogHash", run.CatalogHash)
+	r.AddSummary("framework", run.Framework)
+}
@@ -0,0 +1,84 @@
+package lint
+
+import (
+	"bytes"
+	"os"
+	"sort"
+)
+
+// Fixable returns the findings that can be fixed automatically.
+func Fixable(findings []Finding) []Finding {
+	var fixable []Finding
+	for _, f := range findings {
+		if f.Fix != nil {
+			fixable = append(fixable, f)
+		}
+	}
+	return fixable
+}
+
+// Apply fixes the fixable findings in place, rewriting each affected file
+// once, and returns the number of findings fixed. A fix is skipped when the
+// file no longer holds the old value at the finding's position.
+func Apply(findings []Finding) (int, error) {
+	byFile := make(map[string][]Finding)
+	var files []string
+	for _, f := range Fixable(findings) {
+		if _, ok := byFile[f.File]; !ok {
+			files = append(files, f.File)
+		}
+		byFile[f.File] = append(byFile[f.File], f)
+	}
+
+	fixed := 0
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fixed, err
+		}
+		out, n := FixData(data, byFile[file])
+		if n == 0 {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return fixed, err
+		}
+		if err := os.WriteFile(file, out, info.Mode().Perm()); err != nil {
+			return fixed, err
+		}
+		fixed += n
+	}
+	return fixed, nil
+}
+
+// FixData applies the fixes of findings to the contents of a file and
+// returns the result and the number of findings fixed.
+func FixData(data []byte, findings []Finding) ([]byte, int) {
+	fixes := Fixable(findings)
+	// Apply from the end so earlier positions stay valid
+	sort.Slice(fixes, func(i, j int) bool {
+		if fixes[i].Line != fixes[j].Line {
+			return fixes[i].Line > fixes[j].Line
+		}
+		return fixes[i].Column > fixes[j].Column
+	})
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	fixed := 0
+	for _, f := range fixes {
+		if f.Line < 1 || f.Line > len(lines) {
+			continue
+		}
+		// Columns count characters, not bytes
+		line := []rune(string(lines[f.Line-1]))
+		start := f.Column - 1
+		end := start + len([]rune(f.Fix.Old))
+		if start < 0 || end > len(line) || string(line[start:end]) != f.Fix.Old {
+			continue
+		}
+		lines[f.Line-1] = []byte(string(line[:start]) + f.Fix.New + string(line[end:]))
+		fixed++
+	}
+	return bytes.Join(lines, nil), fixed
+}
@@ -0,0 +1,483 @@
+// Package lint checks catalog files for mistakes that load without error
+// or that the loader reports one at a time, such as controls without an
+// owner, reviews scheduled before the last verification and references to
+// unknown tests or framework requirements. Trivially fixable problems, such
+// as a category written in the wrong case, can be fixed in place.
+package lint
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hallucinaut/securitycontrol/pkg/catalog"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/crosswalk"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// Severity is how serious a finding is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+)
+
+// Rank orders severities from info (0) to error (2).
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityError:
+		return 2
+	case SeverityWarning:
+		return 1
+	}
+	return 0
+}
+
+// ParseSeverity parses a severity name.
+func ParseSeverity(s string) (Severity, error) {
+	switch Severity(s) {
+	case SeverityError, SeverityWarning, SeverityInfo:
+		return Severity(s), nil
+	}
+	return "", fmt.Errorf("invalid severity %q (want error, warning or info)", s)
+}
+
+// Rule is a check applied to catalog files.
+type Rule struct {
+	ID          string
+	Severity    Severity
+	Description string
+	// Fixable rules offer a fix for some or all of their findings.
+	Fixable bool
+}
+
+// Rule IDs.
+const (
+	RuleLoad                 = "load"
+	RuleDuplicateID          = "duplicate-id"
+	RuleMissingOwner         = "missing-owner"
+	RuleRiskReductionRange   = "risk-reduction-range"
+	RuleUnknownCategory      = "unknown-category"
+	RuleUnknownType          = "unknown-type"
+	RuleUnknownStatus        = "unknown-status"
+	RuleUnknownMethod        = "unknown-method"
+	RuleReviewBeforeVerified = "review-before-verified"
+	RuleUnknownTest          = "unknown-test"
+	RuleUnknownRequirement   = "unknown-requirement"
+	RuleUnknownReference     = "unknown-reference"
+)
+
+// Rules are the lint rules, in the order they are listed.
+var Rules = []Rule{
+	{ID: RuleLoad, Severity: SeverityError, Description: "Catalog file does not load"},
+	{ID: RuleDuplicateID, Severity: SeverityError, Description: "Control, test or risk ID is defined more than once"},
+	{ID: RuleMissingOwner, Severity: SeverityWarning, Description: "Control has no owner"},
+	{ID: RuleRiskReductionRange, Severity: SeverityError, Description: "Control riskReduction is outside 0..1"},
+	{ID: RuleUnknownCategory, Severity: SeverityError, Description: "Control category is unknown", Fixable: true},
+	{ID: RuleUnknownType, Severity: SeverityError, Description: "Control type is unknown", Fixable: true},
+	{ID: RuleUnknownStatus, Severity: SeverityError, Description: "Control status is unknown", Fixable: true},
+	{ID: RuleUnknownMethod, Severity: SeverityError, Description: "Test method is unknown", Fixable: true},
+	{ID: RuleReviewBeforeVerified, Severity: SeverityWarning, Description: "Control nextReview is before lastVerified"},
+	{ID: RuleUnknownTest, Severity: SeverityError, Description: "Control lists a test that is not in the catalog"},
+	{ID: RuleUnknownRequirement, Severity: SeverityWarning, Description: "Reference names no requirement of a known framework"},
+	{ID: RuleUnknownReference, Severity: SeverityInfo, Description: "Reference names no known framework and is not a link"},
+}
+
+// LookupRule returns the rule with the given ID.
+func LookupRule(id string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// Fix replaces a scalar value at a finding's position.
+type Fix struct {
+	// Old and New are the value as written in the file, including any
+	// quotes, and its replacement.
+	Old, New string
+}
+
+// Finding is a problem found in a catalog file.
+type Finding struct {
+	Rule     string
+	Severity Severity
+	File     string
+	Line     int
+	Column   int
+	// Subject is the ID of the control, test or risk the finding is about.
+	Subject string
+	Message string
+	// Fix is nil when the finding cannot be fixed automatically.
+	Fix *Fix
+}
+
+// String returns the finding in file:line:column form.
+func (f Finding) String() string {
+	return fmt.Sprintf("%s:%d:%d: %s: %s [%s]", f.File, f.Line, f.Column, f.Severity, f.Message, f.Rule)
+}
+
+var categories = map[string]bool{
+	string(control.CategoryPreventive): true,
+	string(control.CategoryDetective):  true,
+	string(control.CategoryCorrective): true,
+	string(control.CategoryDeterrent):  true,
+	string(control.CategoryRecovery):   true,
+}
+
+var types = map[string]bool{
+	string(control.TypeTechnical):      true,
+	string(control.TypeAdministrative): true,
+	string(control.TypePhysical):       true,
+}
+
+var statuses = map[string]bool{
+	string(control.StatusImplemented):          true,
+	string(control.StatusPartiallyImplemented): true,
+	string(control.StatusNotImplemented):       true,
+	string(control.StatusDeprecated):           true,
+}
+
+var methods = map[string]bool{
+	string(validate.MethodDocumentation): true,
+	string(validate.MethodInterview):     true,
+	string(validate.MethodObservation):   true,
+	string(validate.MethodTesting):       true,
+	string(validate.MethodAutomation):    true,
+}
+
+// Linter checks catalog files against the lint rules.
+type Linter struct {
+	now      time.Time
+	registry *crosswalk.Registry
+	disabled map[string]bool
+}
+
+// NewLinter creates a linter applying every rule, with references checked
+// against the bundled compliance frameworks.
+func NewLinter() *Linter {
+	return &Linter{now: time.Now(), registry: crosswalk.Bundled(), disabled: make(map[string]bool)}
+}
+
+// SetNow sets the reference time used to resolve relative dates.
+func (l *Linter) SetNow(now time.Time) {
+	l.now = now
+}
+
+// Disable turns rules off.
+func (l *Linter) Disable(ids ...string) error {
+	for _, id := range ids {
+		if _, ok := LookupRule(id); !ok {
+			return fmt.Errorf("unknown lint rule %q", id)
+		}
+		l.disabled[id] = true
+	}
+	return nil
+}
+
+// document is a parsed catalog file.
+type document struct {
+	file string
+	root *yaml.Node
+}
+
+// lintState carries state across the files of a single lint.
+type lintState struct {
+	*Linter
+	findings []Finding
+	// seen maps "kind\x00id" to where the ID was first defined
+	seen  map[string]catalog.Location
+	tests map[string]bool
+}
+
+// Lint checks catalog files and directories and returns the findings
+// ordered by file and position. The error is only set when a path cannot
+// be read; problems within files are findings.
+func (l *Linter) Lint(paths ...string) ([]Finding, error) {
+	files, err := catalog.Files(paths...)
+	if err != nil {
+		return nil, err
+	}
+	loader := catalog.NewLoader()
+	loader.SetNow(l.now)
+	var loadErrs catalog.ErrorList
+	if _, err := loader.Load(files...); err != nil {
+		errs, ok := err.(catalog.ErrorList)
+		if !ok {
+			return nil, err
+		}
+		loadErrs = errs
+	}
+
+	// Walk the files ourselves, since the loader returns no catalog when
+	// any file has errors
+	st := &lintState{Linter: l, seen: make(map[string]catalog.Location), tests: make(map[string]bool)}
+	var docs []document
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var doc yaml.Node
+		if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		docs = append(docs, document{file: file, root: doc.Content[0]})
+	}
+	for _, doc := range docs {
+		for _, node := range items(doc.root, "tests") {
+			if id := scalar(node, "id"); id != nil && id.Value != "" {
+				st.tests[id.Value] = true
+			}
+		}
+	}
+	for _, doc := range docs {
+		st.document(doc)
+	}
+
+	// Report the loader's errors that no rule already covers
+	at := make(map[string]bool, len(st.findings))
+	for _, f := range st.findings {
+		at[fmt.Sprintf("%s:%d:%d", f.File, f.Line, f.Column)] = true
+	}
+	for _, e := range loadErrs {
+		if e.Line > 0 && at[fmt.Sprintf("%s:%d:%d", e.File, e.Line, e.Column)] {
+			continue
+		}
+		st.report(RuleLoad, e.File, e.Line, e.Column, "", nil, "%s", e.Msg)
+	}
+
+	sort.SliceStable(st.findings, func(i, j int) bool {
+		a, b := st.findings[i], st.findings[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+	return st.findings, nil
+}
+
+// report adds a finding unless its rule is disabled.
+func (st *lintState) report(rule, file string, line, column int, subject string, fix *Fix, format string, args ...interface{}) {
+	if st.disabled[rule] {
+		return
+	}
+	r, _ := LookupRule(rule)
+	st.findings = append(st.findings, Finding{
+		Rule:     rule,
+		Severity: r.Severity,
+		File:     file,
+		Line:     line,
+		Column:   column,
+		Subject:  subject,
+		Message:  fmt.Sprintf(format, args...),
+		Fix:      fix,
+	})
+}
+
+// reportAt adds a finding at a node.
+func (st *lintState) reportAt(rule, file string, node *yaml.Node, subject string, fix *Fix, format string, args ...interface{}) {
+	st.report(rule, file, node.Line, node.Column, subject, fix, format, args...)
+}
+
+// document checks the controls, tests and risks of a file.
+func (st *lintState) document(doc document) {
+	for _, node := range items(doc.root, "controls") {
+		st.control(doc.file, node)
+	}
+	for _, node := range items(doc.root, "tests") {
+		id := st.id(doc.file, node, "test")
+		if v := scalar(node, "method"); v != nil {
+			st.enum(doc.file, v, RuleUnknownMethod, "test", id, "method", methods)
+		}
+	}
+	for _, node := range items(doc.root, "risks") {
+		st.id(doc.file, node, "risk")
+	}
+}
+
+// control checks a control entry.
+func (st *lintState) control(file string, node *yaml.Node) {
+	id := st.id(file, node, "control")
+
+	if owner := scalar(node, "owner"); owner == nil || strings.TrimSpace(owner.Value) == "" {
+		at := node
+		if owner != nil {
+			at = owner
+		}
+		st.reportAt(RuleMissingOwner, file, at, id, nil, "control %q has no owner", id)
+	}
+	if v := scalar(node, "riskReduction"); v != nil {
+		if r, err := strconv.ParseFloat(v.Value, 64); err == nil && (r < 0 || r > 1) {
+			st.reportAt(RuleRiskReductionRange, file, v, id, nil, "control %q riskReduction %v must be between 0 and 1", id, r)
+		}
+	}
+	for _, field := range []struct {
+		key, rule string
+		values    map[string]bool
+	}{
+		{"category", RuleUnknownCategory, categories},
+		{"type", RuleUnknownType, types},
+		{"status", RuleUnknownStatus, statuses},
+	} {
+		v := scalar(node, field.key)
+		if v == nil {
+			v = &yaml.Node{Line: node.Line, Column: node.Column}
+		}
+		st.enum(file, v, field.rule, "control", id, field.key, field.values)
+	}
+
+	last, next := scalar(node, "lastVerified"), scalar(node, "nextReview")
+	if last != nil && next != nil {
+		lastVerified, err1 := catalog.ParseDate(last.Value, st.now)
+		nextReview, err2 := catalog.ParseDate(next.Value, st.now)
+		if err1 == nil && err2 == nil && !lastVerified.IsZero() && !nextReview.IsZero() && nextReview.Before(lastVerified) {
+			st.reportAt(RuleReviewBeforeVerified, file, next, id, nil, "control %q nextReview %s is before lastVerified %s",
+				id, nextReview.Format("2006-01-02"), lastVerified.Format("2006-01-02"))
+		}
+	}
+
+	for _, test := range items(node, "tests") {
+		if test.Kind == yaml.ScalarNode && !st.tests[test.Value] {
+			st.reportAt(RuleUnknownTest, file, test, id, nil, "control %q lists unknown test %q", id, test.Value)
+		}
+	}
+	for _, ref := range items(node, "references") {
+		if ref.Kind == yaml.ScalarNode {
+			st.reference(file, ref, id)
+		}
+	}
+}
+
+// reference checks that a control reference names a requirement of a
+// known framework, or is a link.
+func (st *lintState) reference(file string, node *yaml.Node, id string) {
+	ref := strings.TrimSpace(node.Value)
+	if strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://") {
+		return
+	}
+	if _, _, ok := st.registry.Resolve(ref); ok {
+		return
+	}
+	if f := st.framework(ref); f != nil {
+		st.reportAt(RuleUnknownRequirement, file, node, id, nil, "control %q reference %q names no requirement of %s", id, ref, f.Title())
+		return
+	}
+	st.reportAt(RuleUnknownReference, file, node, id, nil, "control %q reference %q names no known framework", id, ref)
+}
+
+// framework returns the framework whose reference prefix, the longest
+// matching one, a reference starts with.
+func (st *lintState) framework(ref string) *crosswalk.Framework {
+	var match *crosswalk.Framework
+	var prefix string
+	for _, f := range st.registry.Frameworks() {
+		for _, p := range f.References {
+			if len(p) > len(prefix) && len(ref) > len(p) && strings.EqualFold(ref[:len(p)], p) {
+				match, prefix = f, p
+			}
+		}
+	}
+	return match
+}
+
+// id returns the ID of an entry and reports it if an entry of the same
+// kind defined it earlier.
+func (st *lintState) id(file string, node *yaml.Node, kind string) string {
+	v := scalar(node, "id")
+	if v == nil || v.Value == "" {
+		return ""
+	}
+	key := kind + "\x00" + v.Value
+	if prev, ok := st.seen[key]; ok {
+		st.reportAt(RuleDuplicateID, file, v, v.Value, nil, "duplicate %s ID %q (first defined at %s:%d)", kind, v.Value, prev.File, prev.Line)
+		return v.Value
+	}
+	st.seen[key] = catalog.Location{File: file, Line: node.Line}
+	return v.Value
+}
+
+// enum reports a value that is not one of the allowed values, offering a
+// fix when it only differs from one in case or separators.
+func (st *lintState) enum(file string, node *yaml.Node, rule, kind, id, field string, values map[string]bool) {
+	if values[node.Value] {
+		return
+	}
+	if fixed := normalizeEnum(node.Value); values[fixed] && node.Kind == yaml.ScalarNode {
+		fix := &Fix{Old: quote(node, node.Value), New: quote(node, fixed)}
+		st.reportAt(rule, file, node, id, fix, "%s %q has unknown %s %q (did you mean %q?)", kind, id, field, node.Value, fixed)
+		return
+	}
+	st.reportAt(rule, file, node, id, nil, "%s %q has unknown %s %q (want %s)", kind, id, field, node.Value, names(values))
+}
+
+// normalizeEnum lowercases a value and joins its words with underscores,
+// so that "Partially Implemented" becomes "partially_implemented".
+func normalizeEnum(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "_")
+}
+
+// quote returns a value as the node writes it.
+func quote(node *yaml.Node, value string) string {
+	switch node.Style {
+	case yaml.DoubleQuotedStyle:
+		return `"` + value + `"`
+	case yaml.SingleQuotedStyle:
+		return "'" + value + "'"
+	}
+	return value
+}
+
+// scalar returns the scalar value of a key in a mapping node, or nil.
+func scalar(node *yaml.Node, key string) *yaml.Node {
+	if v := value(node, key); v != nil && v.Kind == yaml.ScalarNode {
+		return v
+	}
+	return nil
+}
+
+// items returns the items of a sequence under a key in a mapping node.
+func items(node *yaml.Node, key string) []*yaml.Node {
+	if v := value(node, key); v != nil && v.Kind == yaml.SequenceNode {
+		return v.Content
+	}
+	return nil
+}
+
+// value returns the value node of a key in a mapping node, or nil.
+func value(node *yaml.Node, key string) *yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// names lists the allowed values of an enum.
+func names(values map[string]bool) string {
+	list := make([]string, 0, len(values))
+	for v := range values {
+		list = append(list, v)
+	}
+	sort.Strings(list)
+	return strings.Join(list, ", ")
+}
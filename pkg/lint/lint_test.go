@@ -0,0 +1,132 @@
+package lint
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const catalogYAML = `controls:
+  - id: c1
+    name: One
+    category: Preventive
+    type: "Technical"
+    status: implemented
+    riskReduction: 1.5
+    lastVerified: 2024-05-01
+    nextReview: 2024-01-01
+    references: [NIST-800-53-AC-2, ISO-27001-A.99.1, internal-std-4, https://example.com]
+    tests: [t1, t9]
+  - id: c1
+    name: Dup
+    category: bogus
+    type: technical
+    status: implemented
+    owner: Security
+tests:
+  - id: t1
+    name: T
+    method: testing
+`
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "controls.yaml")
+	if err := os.WriteFile(file, []byte(catalogYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	linter := NewLinter()
+	linter.SetNow(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC))
+	findings, err := linter.Lint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range findings {
+		got = append(got, f.Rule)
+	}
+	want := []string{
+		RuleMissingOwner, RuleUnknownCategory, RuleUnknownType, RuleRiskReductionRange, RuleReviewBeforeVerified,
+		RuleUnknownRequirement, RuleUnknownReference, RuleUnknownTest, RuleDuplicateID, RuleUnknownCategory,
+	}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Fatalf("rules = %v, want %v", got, want)
+	}
+
+	first := findings[1]
+	if first.File != file || first.Line != 4 || first.Column != 15 || first.Severity != SeverityError || first.Fix == nil {
+		t.Errorf("first finding = %+v", first)
+	}
+	if findings[9].Fix != nil {
+		t.Error("unknown category bogus offered a fix")
+	}
+	if n := Count(findings, SeverityWarning); n != 3 {
+		t.Errorf("%d warnings, want 3", n)
+	}
+
+	if err := linter.Disable(RuleUnknownReference, RuleMissingOwner); err != nil {
+		t.Fatal(err)
+	}
+	if findings, _ = linter.Lint(file); len(findings) != 8 {
+		t.Errorf("got %d findings with rules disabled, want 8", len(findings))
+	}
+	if err := linter.Disable("no-such-rule"); err == nil {
+		t.Error("disabling an unknown rule succeeded")
+	}
+}
+
+func TestApply(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "controls.yaml")
+	if err := os.WriteFile(file, []byte(catalogYAML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	linter := NewLinter()
+	findings, err := linter.Lint(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fixed, err := Apply(findings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fixed != 2 {
+		t.Errorf("fixed %d findings, want 2", fixed)
+	}
+	data, _ := os.ReadFile(file)
+	if !strings.Contains(string(data), "    category: preventive\n    type: \"technical\"\n") {
+		t.Errorf("fixed file:\n%s", data)
+	}
+
+	after, _ := linter.Lint(file)
+	if len(Fixable(after)) != 0 || len(after) != len(findings)-2 {
+		t.Errorf("after fixing: %v", after)
+	}
+	// Stale fixes are skipped
+	if _, n := FixData(data, findings); n != 0 {
+		t.Errorf("applied %d stale fixes", n)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "controls.yaml")
+	data := "controls:\n  - id: c1\n    name: One\n    colour: red\n    category: preventive\n    type: technical\n    status: implemented\n    owner: Security\n"
+	if err := os.WriteFile(file, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	findings, err := NewLinter().Lint(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(findings) != 1 || findings[0].Rule != RuleLoad || findings[0].Line != 4 {
+		t.Errorf("findings = %v", findings)
+	}
+
+	if _, err := NewLinter().Lint(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("linting a missing file succeeded")
+	}
+}
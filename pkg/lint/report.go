@@ -0,0 +1,94 @@
+package lint
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/report/sarif"
+)
+
+// FindingColumns are the columns of a report section of lint findings.
+var FindingColumns = []report.Column{
+	{Key: "file", Title: "File", Kind: report.KindString},
+	{Key: "line", Title: "Line", Kind: report.KindNumber},
+	{Key: "column", Title: "Column", Kind: report.KindNumber},
+	{Key: "severity", Title: "Severity", Kind: report.KindString},
+	{Key: "rule", Title: "Rule", Kind: report.KindString},
+	{Key: "subject", Title: "Subject", Kind: report.KindString},
+	{Key: "message", Title: "Message", Kind: report.KindString},
+	{Key: "fixable", Title: "Fixable", Kind: report.KindBool},
+}
+
+// Count returns the number of findings of a severity.
+func Count(findings []Finding, severity Severity) int {
+	n := 0
+	for _, f := range findings {
+		if f.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+// AddFindings adds finding counts to a report's summary and a "findings"
+// section.
+func AddFindings(r *report.Report, findings []Finding) {
+	r.AddSummary("errors", Count(findings, SeverityError))
+	r.AddSummary("warnings", Count(findings, SeverityWarning))
+	r.AddSummary("infos", Count(findings, SeverityInfo))
+	r.AddSummary("fixable", len(Fixable(findings)))
+
+	section := r.AddSection("findings", "Findings", FindingColumns...)
+	for _, f := range findings {
+		section.AddRow(f.File, f.Line, f.Column, string(f.Severity), f.Rule, f.Subject, f.Message, f.Fix != nil)
+	}
+}
+
+// SARIF builds a SARIF log with a rule for every lint rule and a result for
+// every finding.
+func SARIF(findings []Finding, toolVersion string) *sarif.Log {
+	driver := sarif.Driver{
+		Name:           "securitycontrol",
+		Version:        toolVersion,
+		InformationURI: sarif.InformationURI,
+		Rules:          make([]sarif.Rule, 0, len(Rules)),
+	}
+	index := make(map[string]int, len(Rules))
+	for i, rule := range Rules {
+		index[rule.ID] = i
+		driver.Rules = append(driver.Rules, sarif.Rule{
+			ID:                   rule.ID,
+			ShortDescription:     &sarif.Message{Text: rule.Description},
+			DefaultConfiguration: &sarif.Configuration{Level: level(rule.Severity)},
+			Properties:           &sarif.RuleProperties{Tags: []string{"catalog", "lint"}},
+		})
+	}
+
+	run := sarif.Run{Tool: sarif.Tool{Driver: driver}, Results: make([]sarif.Result, 0, len(findings))}
+	for _, f := range findings {
+		loc := &sarif.PhysicalLocation{ArtifactLocation: sarif.ArtifactLocation{URI: filepath.ToSlash(f.File)}}
+		if f.Line > 0 {
+			loc.Region = &sarif.Region{StartLine: f.Line, StartColumn: f.Column}
+		}
+		sum := sha256.Sum256([]byte(f.Rule + "\x00" + f.Subject + "\x00" + f.Message))
+		run.Results = append(run.Results, sarif.Result{
+			RuleID:              f.Rule,
+			RuleIndex:           index[f.Rule],
+			Level:               level(f.Severity),
+			Message:             sarif.Message{Text: f.Message},
+			Locations:           []sarif.Location{{PhysicalLocation: loc}},
+			PartialFingerprints: map[string]string{"securitycontrol/v1": hex.EncodeToString(sum[:16])},
+		})
+	}
+	return &sarif.Log{Schema: sarif.SchemaURI, Version: sarif.Version, Runs: []sarif.Run{run}}
+}
+
+// level maps a severity to a SARIF level.
+func level(s Severity) string {
+	if s == SeverityInfo {
+		return "note"
+	}
+	return string(s)
+}
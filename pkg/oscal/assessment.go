@@ -0,0 +1,437 @@
+package oscal
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// AssessmentResults is an OSCAL assessment-results document.
+type AssessmentResults struct {
+	UUID     string   `json:"uuid"`
+	Metadata Metadata `json:"metadata"`
+	ImportAP ImportAP `json:"import-ap"`
+	Results  []Result `json:"results"`
+}
+
+// ImportAP references the assessment plan the results belong to.
+type ImportAP struct {
+	Href string `json:"href"`
+}
+
+// Result is a single assessment run.
+type Result struct {
+	UUID             string           `json:"uuid"`
+	Title            string           `json:"title"`
+	Description      string           `json:"description"`
+	Start            time.Time        `json:"start"`
+	End              *time.Time       `json:"end,omitempty"`
+	ReviewedControls ReviewedControls `json:"reviewed-controls"`
+	Observations     []Observation    `json:"observations,omitempty"`
+	Findings         []Finding        `json:"findings,omitempty"`
+}
+
+// ReviewedControls lists the controls covered by a result.
+type ReviewedControls struct {
+	ControlSelections []ControlSelection `json:"control-selections"`
+}
+
+// ControlSelection selects assessed controls.
+type ControlSelection struct {
+	IncludeControls []SelectControl `json:"include-controls,omitempty"`
+}
+
+// SelectControl references a single control.
+type SelectControl struct {
+	ControlID string `json:"control-id"`
+}
+
+// Observation records how a control was assessed.
+type Observation struct {
+	UUID             string             `json:"uuid"`
+	Title            string             `json:"title,omitempty"`
+	Description      string             `json:"description"`
+	Props            []Property         `json:"props,omitempty"`
+	Methods          []string           `json:"methods"`
+	Types            []string           `json:"types,omitempty"`
+	RelevantEvidence []RelevantEvidence `json:"relevant-evidence,omitempty"`
+	Collected        time.Time          `json:"collected"`
+}
+
+// RelevantEvidence links evidence to an observation.
+type RelevantEvidence struct {
+	Href        string `json:"href,omitempty"`
+	Description string `json:"description"`
+}
+
+// Finding records the assessed state of a control.
+type Finding struct {
+	UUID                string               `json:"uuid"`
+	Title               string               `json:"title"`
+	Description         string               `json:"description"`
+	Props               []Property           `json:"props,omitempty"`
+	Target              FindingTarget        `json:"target"`
+	RelatedObservations []RelatedObservation `json:"related-observations,omitempty"`
+	RelatedRisks        []RelatedRisk        `json:"related-risks,omitempty"`
+}
+
+// FindingTarget identifies the control objective a finding is about.
+type FindingTarget struct {
+	Type     string          `json:"type"`
+	TargetID string          `json:"target-id"`
+	Status   ObjectiveStatus `json:"status"`
+}
+
+// ObjectiveStatus is satisfied or not-satisfied.
+type ObjectiveStatus struct {
+	State  string `json:"state"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// RelatedObservation references an observation by UUID.
+type RelatedObservation struct {
+	ObservationUUID string `json:"observation-uuid"`
+}
+
+// RelatedRisk references a risk by UUID.
+type RelatedRisk struct {
+	RiskUUID string `json:"risk-uuid"`
+}
+
+// PlanOfActionAndMilestones is an OSCAL POA&M document.
+type PlanOfActionAndMilestones struct {
+	UUID         string        `json:"uuid"`
+	Metadata     Metadata      `json:"metadata"`
+	SystemID     *SystemID     `json:"system-id,omitempty"`
+	Observations []Observation `json:"observations,omitempty"`
+	Risks        []Risk        `json:"risks,omitempty"`
+	PoamItems    []PoamItem    `json:"poam-items"`
+}
+
+// SystemID identifies the system a POA&M applies to.
+type SystemID struct {
+	IdentifierType string `json:"identifier-type,omitempty"`
+	ID             string `json:"id"`
+}
+
+// Risk is an identified risk in a POA&M.
+type Risk struct {
+	UUID        string     `json:"uuid"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	Statement   string     `json:"statement"`
+	Props       []Property `json:"props,omitempty"`
+	Status      string     `json:"status"`
+}
+
+// PoamItem is a planned remediation action.
+type PoamItem struct {
+	UUID                string               `json:"uuid"`
+	Title               string               `json:"title"`
+	Description         string               `json:"description"`
+	Props               []Property           `json:"props,omitempty"`
+	RelatedObservations []RelatedObservation `json:"related-observations,omitempty"`
+	RelatedRisks        []RelatedRisk        `json:"related-risks,omitempty"`
+}
+
+// Extension property names used on findings and observations.
+const (
+	propResultStatus   = "result-status"
+	propEffectiveness  = "effectiveness"
+	propConfidence     = "confidence"
+	propIssue          = "issue"
+	propRecommendation = "recommendation"
+	propTestID         = "test-id"
+	propTestPassed     = "test-passed"
+	propRiskRemaining  = "risk-remaining"
+)
+
+// methods maps validation methods to OSCAL assessment methods.
+var methods = map[validate.ValidationMethod]string{
+	validate.MethodDocumentation: "EXAMINE",
+	validate.MethodInterview:     "INTERVIEW",
+	validate.MethodObservation:   "EXAMINE",
+	validate.MethodTesting:       "TEST",
+	validate.MethodAutomation:    "TEST",
+}
+
+// Exporter exports validation results as OSCAL documents.
+type Exporter struct {
+	title    string
+	planHref string
+	systemID string
+	now      func() time.Time
+	uuid     func() string
+	methods  map[string]validate.ValidationMethod
+}
+
+// NewExporter creates a new OSCAL exporter.
+func NewExporter(title string) *Exporter {
+	return &Exporter{
+		title:    title,
+		planHref: "#assessment-plan",
+		now:      func() time.Time { return time.Now().UTC() },
+		uuid:     newUUID,
+		methods:  make(map[string]validate.ValidationMethod),
+	}
+}
+
+// SetAssessmentPlan sets the href of the assessment plan results refer to.
+func (e *Exporter) SetAssessmentPlan(href string) {
+	e.planHref = href
+}
+
+// SetSystemID sets the system identifier written to POA&M documents.
+func (e *Exporter) SetSystemID(id string) {
+	e.systemID = id
+}
+
+// AddControlTests records the methods of control tests so that exported
+// observations carry the matching OSCAL method.
+func (e *Exporter) AddControlTests(tests []validate.ControlTest) {
+	for _, t := range tests {
+		e.methods[t.ID] = t.Method
+	}
+}
+
+// AssessmentResults exports control and test validation results as a
+// single OSCAL assessment result.
+func (e *Exporter) AssessmentResults(controls []control.ControlValidationResult, tests []validate.ValidationResult) *AssessmentResults {
+	now := e.now()
+	result := Result{
+		UUID:        e.uuid(),
+		Title:       e.title,
+		Description: "Security control validation results",
+		Start:       now,
+	}
+
+	for _, t := range tests {
+		method := "TEST"
+		if m, ok := methods[e.methods[t.ControlID]]; ok {
+			method = m
+		}
+		result.Observations = append(result.Observations, Observation{
+			UUID:        e.uuid(),
+			Title:       t.ControlName,
+			Description: t.ValidationResult,
+			Methods:     []string{method},
+			Props: []Property{
+				nsProp(propTestID, t.ControlID),
+				nsProp(propTestPassed, strconv.FormatBool(t.TestPassed)),
+				nsProp(propEffectiveness, formatFloat(t.Effectiveness)),
+				nsProp(propRiskRemaining, formatFloat(t.RiskRemaining)),
+			},
+			Collected: t.ValidatedAt,
+		})
+	}
+
+	selection := ControlSelection{}
+	for _, r := range controls {
+		selection.IncludeControls = append(selection.IncludeControls, SelectControl{ControlID: r.ControlID})
+
+		obs := Observation{
+			UUID:        e.uuid(),
+			Title:       r.ControlName,
+			Description: "Control validation for " + r.ControlID,
+			Methods:     []string{"EXAMINE"},
+			Collected:   r.ValidatedAt,
+		}
+		for _, ev := range r.Evidence {
+			obs.RelevantEvidence = append(obs.RelevantEvidence, RelevantEvidence{Href: ev, Description: ev})
+		}
+		result.Observations = append(result.Observations, obs)
+
+		result.Findings = append(result.Findings, e.finding(r, obs.UUID))
+		if !r.ValidatedAt.IsZero() && r.ValidatedAt.Before(result.Start) {
+			result.Start = r.ValidatedAt
+		}
+	}
+	result.ReviewedControls.ControlSelections = []ControlSelection{selection}
+	result.End = &now
+
+	return &AssessmentResults{
+		UUID:     e.uuid(),
+		Metadata: e.metadata(now),
+		ImportAP: ImportAP{Href: e.planHref},
+		Results:  []Result{result},
+	}
+}
+
+// PlanOfActionAndMilestones exports a POA&M with one item per control that
+// is not effective.
+func (e *Exporter) PlanOfActionAndMilestones(controls []control.ControlValidationResult) *PlanOfActionAndMilestones {
+	now := e.now()
+	poam := &PlanOfActionAndMilestones{
+		UUID:      e.uuid(),
+		Metadata:  e.metadata(now),
+		PoamItems: make([]PoamItem, 0),
+	}
+	if e.systemID != "" {
+		poam.SystemID = &SystemID{IdentifierType: Namespace, ID: e.systemID}
+	}
+
+	for _, r := range controls {
+		if r.Status == "EFFECTIVE" {
+			continue
+		}
+
+		obs := Observation{
+			UUID:        e.uuid(),
+			Title:       r.ControlName,
+			Description: "Control validation for " + r.ControlID,
+			Methods:     []string{"EXAMINE"},
+			Collected:   r.ValidatedAt,
+		}
+		risk := Risk{
+			UUID:        e.uuid(),
+			Title:       r.ControlName + " is " + strings.ToLower(strings.ReplaceAll(r.Status, "_", " ")),
+			Description: strings.Join(issueMessages(r.Issues), "\n"),
+			Statement:   "Control " + r.ControlID + " is not operating effectively",
+			Props: []Property{
+				nsProp("control-id", r.ControlID),
+				nsProp(propEffectiveness, formatFloat(r.Effectiveness)),
+			},
+			Status: "open",
+		}
+		if risk.Description == "" {
+			risk.Description = risk.Title
+		}
+
+		item := PoamItem{
+			UUID:                e.uuid(),
+			Title:               "Remediate " + r.ControlName,
+			Description:         strings.Join(r.Recommendations, "\n"),
+			Props:               []Property{nsProp("control-id", r.ControlID), nsProp(propResultStatus, r.Status)},
+			RelatedObservations: []RelatedObservation{{ObservationUUID: obs.UUID}},
+			RelatedRisks:        []RelatedRisk{{RiskUUID: risk.UUID}},
+		}
+		if item.Description == "" {
+			item.Description = "Improve effectiveness of control " + r.ControlID
+		}
+
+		poam.Observations = append(poam.Observations, obs)
+		poam.Risks = append(poam.Risks, risk)
+		poam.PoamItems = append(poam.PoamItems, item)
+	}
+	return poam
+}
+
+// ImportAssessmentResults reads control validation results back from
+// findings written by AssessmentResults.
+func ImportAssessmentResults(ar *AssessmentResults) []control.ControlValidationResult {
+	var results []control.ControlValidationResult
+	for _, res := range ar.Results {
+		collected := make(map[string]Observation)
+		for _, obs := range res.Observations {
+			collected[obs.UUID] = obs
+		}
+
+		for _, f := range res.Findings {
+			r := control.ControlValidationResult{
+				ControlID:       f.Target.TargetID,
+				ControlName:     f.Title,
+				Issues:          issueProps(f.Props),
+				Evidence:        make([]string, 0),
+				Recommendations: props(f.Props, propRecommendation),
+			}
+			r.Status, _ = prop(f.Props, propResultStatus)
+			if r.Status == "" {
+				r.Status = "INEFFECTIVE"
+				if f.Target.Status.State == "satisfied" {
+					r.Status = "EFFECTIVE"
+				}
+			}
+			if v, ok := prop(f.Props, propEffectiveness); ok {
+				r.Effectiveness, _ = strconv.ParseFloat(v, 64)
+			}
+			if v, ok := prop(f.Props, propConfidence); ok {
+				r.Confidence, _ = strconv.ParseFloat(v, 64)
+			}
+			for _, rel := range f.RelatedObservations {
+				obs := collected[rel.ObservationUUID]
+				r.ValidatedAt = obs.Collected
+				for _, ev := range obs.RelevantEvidence {
+					r.Evidence = append(r.Evidence, ev.Href)
+				}
+			}
+			results = append(results, r)
+		}
+	}
+	return results
+}
+
+func (e *Exporter) finding(r control.ControlValidationResult, observation string) Finding {
+	state := "not-satisfied"
+	if r.Status == "EFFECTIVE" {
+		state = "satisfied"
+	}
+
+	f := Finding{
+		UUID:        e.uuid(),
+		Title:       r.ControlName,
+		Description: "Control " + r.ControlID + " assessed as " + r.Status,
+		Props: []Property{
+			nsProp(propResultStatus, r.Status),
+			nsProp(propEffectiveness, formatFloat(r.Effectiveness)),
+			nsProp(propConfidence, formatFloat(r.Confidence)),
+		},
+		Target: FindingTarget{
+			Type:     "objective-id",
+			TargetID: r.ControlID,
+			Status:   ObjectiveStatus{State: state},
+		},
+		RelatedObservations: []RelatedObservation{{ObservationUUID: observation}},
+	}
+	for _, issue := range r.Issues {
+		p := nsProp(propIssue, issue.Message)
+		p.Class = issue.Code
+		f.Props = append(f.Props, p)
+	}
+	for _, rec := range r.Recommendations {
+		f.Props = append(f.Props, nsProp(propRecommendation, rec))
+	}
+	return f
+}
+
+func (e *Exporter) metadata(now time.Time) Metadata {
+	return Metadata{
+		Title:        e.title,
+		LastModified: now,
+		Version:      "1.0",
+		OSCALVersion: Version,
+	}
+}
+
+// issueProps reads issues from their properties. The class of each
+// property holds the issue code; properties without one are parsed from
+// their message.
+func issueProps(list []Property) []control.Issue {
+	var issues []control.Issue
+	for _, p := range list {
+		if p.Name != propIssue || p.NS != Namespace {
+			continue
+		}
+		if p.Class == "" {
+			issues = append(issues, control.ParseIssue(p.Value))
+		} else {
+			issues = append(issues, control.ResolveIssue(p.Class, p.Value))
+		}
+	}
+	return issues
+}
+
+// issueMessages returns the messages of issues.
+func issueMessages(issues []control.Issue) []string {
+	messages := make([]string, len(issues))
+	for i, issue := range issues {
+		messages[i] = issue.Message
+	}
+	return messages
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
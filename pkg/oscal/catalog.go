@@ -0,0 +1,353 @@
+package oscal
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// Catalog is an OSCAL catalog.
+type Catalog struct {
+	UUID     string      `json:"uuid"`
+	Metadata Metadata    `json:"metadata"`
+	Params   []Parameter `json:"params,omitempty"`
+	Controls []Control   `json:"controls,omitempty"`
+	Groups   []Group     `json:"groups,omitempty"`
+}
+
+// Group is an OSCAL control group such as a NIST 800-53 family.
+type Group struct {
+	ID       string      `json:"id,omitempty"`
+	Class    string      `json:"class,omitempty"`
+	Title    string      `json:"title"`
+	Params   []Parameter `json:"params,omitempty"`
+	Props    []Property  `json:"props,omitempty"`
+	Parts    []Part      `json:"parts,omitempty"`
+	Groups   []Group     `json:"groups,omitempty"`
+	Controls []Control   `json:"controls,omitempty"`
+}
+
+// Control is an OSCAL control.
+type Control struct {
+	ID       string      `json:"id"`
+	Class    string      `json:"class,omitempty"`
+	Title    string      `json:"title"`
+	Params   []Parameter `json:"params,omitempty"`
+	Props    []Property  `json:"props,omitempty"`
+	Links    []Link      `json:"links,omitempty"`
+	Parts    []Part      `json:"parts,omitempty"`
+	Controls []Control   `json:"controls,omitempty"`
+}
+
+// Extension property names used to carry SecurityControl fields.
+const (
+	propCategory       = "category"
+	propType           = "type"
+	propStatus         = "status"
+	propOwner          = "owner"
+	propRiskReduction  = "risk-reduction"
+	propLastVerified   = "last-verified"
+	propNextReview     = "next-review"
+	propEvidence       = "evidence"
+	propReference      = "reference"
+	propTechnique      = "attack-technique"
+	propTest           = "test"
+	propDependsOn      = "depends-on"
+	propImplementation = "implementation"
+	propVerification   = "verification"
+	propMaintenance    = "maintenance"
+)
+
+var insertParam = regexp.MustCompile(`\{\{\s*insert:\s*param,\s*([^\s}]+)\s*\}\}`)
+
+// Defaults are the category, type and status given to imported controls
+// that do not carry them as properties, as controls of standard OSCAL
+// catalogs do not.
+type Defaults struct {
+	Category control.ControlCategory
+	Type     control.ControlType
+	Status   control.ControlStatus
+}
+
+// ImportDefaults are the defaults of the oscal import command. Imported
+// controls are not implemented until the catalog says otherwise.
+var ImportDefaults = Defaults{
+	Category: control.CategoryPreventive,
+	Type:     control.TypeAdministrative,
+	Status:   control.StatusNotImplemented,
+}
+
+// ImportCatalog converts an OSCAL catalog into a control framework.
+// Nested controls such as enhancements are flattened and the enclosing
+// group title is used as the sub-category.
+func ImportCatalog(cat *Catalog, defaults Defaults) control.ControlFramework {
+	fw := control.ControlFramework{
+		Name:        cat.Metadata.Title,
+		Version:     cat.Metadata.Version,
+		LastUpdated: cat.Metadata.LastModified,
+		Controls:    make([]control.SecurityControl, 0),
+	}
+	if remarks := cat.Metadata.Remarks; remarks != "" {
+		fw.Description = remarks
+	}
+
+	params := make(map[string]Parameter)
+	collectParams(params, cat.Params)
+	for _, g := range cat.Groups {
+		collectGroupParams(params, g)
+	}
+	for _, c := range cat.Controls {
+		collectControlParams(params, c)
+	}
+
+	for _, c := range cat.Controls {
+		fw.Controls = importControl(fw.Controls, c, "", params)
+	}
+	for _, g := range cat.Groups {
+		fw.Controls = importGroup(fw.Controls, g, params)
+	}
+	for i := range fw.Controls {
+		c := &fw.Controls[i]
+		if c.Category == "" {
+			c.Category = defaults.Category
+		}
+		if c.Type == "" {
+			c.Type = defaults.Type
+		}
+		if c.Status == "" {
+			c.Status = defaults.Status
+		}
+	}
+	return fw
+}
+
+// ExportCatalog converts a control framework into an OSCAL catalog. Fields
+// without an OSCAL equivalent are written as namespaced properties so that
+// ImportCatalog restores them.
+func ExportCatalog(fw control.ControlFramework) *Catalog {
+	lastModified := fw.LastUpdated
+	if lastModified.IsZero() {
+		lastModified = time.Now().UTC()
+	}
+
+	cat := &Catalog{
+		UUID: newUUID(),
+		Metadata: Metadata{
+			Title:        fw.Name,
+			LastModified: lastModified,
+			Version:      fw.Version,
+			OSCALVersion: Version,
+			Remarks:      fw.Description,
+		},
+	}
+
+	groups := make(map[string]int)
+	for _, ctrl := range fw.Controls {
+		oc := exportControl(ctrl)
+		if ctrl.SubCategory == "" {
+			cat.Controls = append(cat.Controls, oc)
+			continue
+		}
+		i, ok := groups[ctrl.SubCategory]
+		if !ok {
+			i = len(cat.Groups)
+			groups[ctrl.SubCategory] = i
+			cat.Groups = append(cat.Groups, Group{Title: ctrl.SubCategory})
+		}
+		cat.Groups[i].Controls = append(cat.Groups[i].Controls, oc)
+	}
+	return cat
+}
+
+func importGroup(controls []control.SecurityControl, g Group, params map[string]Parameter) []control.SecurityControl {
+	for _, c := range g.Controls {
+		controls = importControl(controls, c, g.Title, params)
+	}
+	for _, sub := range g.Groups {
+		controls = importGroup(controls, sub, params)
+	}
+	return controls
+}
+
+func importControl(controls []control.SecurityControl, c Control, group string, params map[string]Parameter) []control.SecurityControl {
+	ctrl := control.SecurityControl{
+		ID:          c.ID,
+		Name:        c.Title,
+		Description: statementProse(c.Parts, params),
+		SubCategory: group,
+		Evidence:    props(c.Props, propEvidence),
+		References:  props(c.Props, propReference),
+		Techniques:  props(c.Props, propTechnique),
+		Tests:       props(c.Props, propTest),
+		DependsOn:   props(c.Props, propDependsOn),
+	}
+
+	if v, ok := prop(c.Props, propCategory); ok {
+		ctrl.Category = control.ControlCategory(v)
+	}
+	if v, ok := prop(c.Props, propType); ok {
+		ctrl.Type = control.ControlType(v)
+	}
+	if v, ok := prop(c.Props, propStatus); ok {
+		ctrl.Status = control.ControlStatus(v)
+	}
+	if v, ok := prop(c.Props, propRiskReduction); ok {
+		ctrl.RiskReduction, _ = strconv.ParseFloat(v, 64)
+	}
+	if v, ok := prop(c.Props, propLastVerified); ok {
+		ctrl.LastVerified, _ = time.Parse(time.RFC3339, v)
+	}
+	if v, ok := prop(c.Props, propNextReview); ok {
+		ctrl.NextReview, _ = time.Parse(time.RFC3339, v)
+	}
+	ctrl.Owner, _ = prop(c.Props, propOwner)
+	ctrl.Implementation, _ = prop(c.Props, propImplementation)
+	ctrl.Verification, _ = prop(c.Props, propVerification)
+	ctrl.Maintenance, _ = prop(c.Props, propMaintenance)
+
+	for _, link := range c.Links {
+		if link.Rel == "reference" && !contains(ctrl.References, link.Href) {
+			ctrl.References = append(ctrl.References, link.Href)
+		}
+	}
+
+	controls = append(controls, ctrl)
+	for _, sub := range c.Controls {
+		controls = importControl(controls, sub, group, params)
+	}
+	return controls
+}
+
+func exportControl(ctrl control.SecurityControl) Control {
+	c := Control{
+		ID:    ctrl.ID,
+		Title: ctrl.Name,
+	}
+	if ctrl.Description != "" {
+		c.Parts = []Part{{ID: ctrl.ID + "_smt", Name: "statement", Prose: ctrl.Description}}
+	}
+
+	add := func(name, value string) {
+		if value != "" {
+			c.Props = append(c.Props, nsProp(name, value))
+		}
+	}
+	add(propCategory, string(ctrl.Category))
+	add(propType, string(ctrl.Type))
+	add(propStatus, string(ctrl.Status))
+	add(propOwner, ctrl.Owner)
+	if ctrl.RiskReduction != 0 {
+		add(propRiskReduction, strconv.FormatFloat(ctrl.RiskReduction, 'f', -1, 64))
+	}
+	if !ctrl.LastVerified.IsZero() {
+		add(propLastVerified, ctrl.LastVerified.Format(time.RFC3339))
+	}
+	if !ctrl.NextReview.IsZero() {
+		add(propNextReview, ctrl.NextReview.Format(time.RFC3339))
+	}
+	add(propImplementation, ctrl.Implementation)
+	add(propVerification, ctrl.Verification)
+	add(propMaintenance, ctrl.Maintenance)
+	for _, e := range ctrl.Evidence {
+		add(propEvidence, e)
+	}
+	for _, r := range ctrl.References {
+		add(propReference, r)
+	}
+	for _, t := range ctrl.Techniques {
+		add(propTechnique, t)
+	}
+	for _, t := range ctrl.Tests {
+		add(propTest, t)
+	}
+	for _, d := range ctrl.DependsOn {
+		add(propDependsOn, d)
+	}
+	return c
+}
+
+// statementProse flattens the statement part of a control into text,
+// substituting parameter values.
+func statementProse(parts []Part, params map[string]Parameter) string {
+	var lines []string
+	var walk func(p Part)
+	walk = func(p Part) {
+		text := p.Prose
+		if label := partLabel(p); label != "" && text != "" {
+			text = label + " " + text
+		}
+		if text != "" {
+			lines = append(lines, substituteParams(text, params))
+		}
+		for _, sub := range p.Parts {
+			walk(sub)
+		}
+	}
+	for _, p := range parts {
+		if p.Name == "statement" {
+			walk(p)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func partLabel(p Part) string {
+	for _, pr := range p.Props {
+		if pr.Name == "label" && pr.NS == "" {
+			return pr.Value
+		}
+	}
+	return ""
+}
+
+// substituteParams replaces parameter insertion points with the parameter
+// values, or with an assignment placeholder when the parameter is unset.
+func substituteParams(text string, params map[string]Parameter) string {
+	return insertParam.ReplaceAllStringFunc(text, func(m string) string {
+		id := insertParam.FindStringSubmatch(m)[1]
+		p, ok := params[id]
+		if !ok {
+			return m
+		}
+		if len(p.Values) > 0 {
+			return strings.Join(p.Values, ", ")
+		}
+		return fmt.Sprintf("[Assignment: %s]", p.Label)
+	})
+}
+
+func collectParams(params map[string]Parameter, list []Parameter) {
+	for _, p := range list {
+		params[p.ID] = p
+	}
+}
+
+func collectGroupParams(params map[string]Parameter, g Group) {
+	collectParams(params, g.Params)
+	for _, sub := range g.Groups {
+		collectGroupParams(params, sub)
+	}
+	for _, c := range g.Controls {
+		collectControlParams(params, c)
+	}
+}
+
+func collectControlParams(params map[string]Parameter, c Control) {
+	collectParams(params, c.Params)
+	for _, sub := range c.Controls {
+		collectControlParams(params, sub)
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+// Package oscal imports and exports NIST OSCAL documents.
+package oscal
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Version is the OSCAL version written to exported documents.
+const Version = "1.1.2"
+
+// Namespace is the property namespace used for securitycontrol extensions.
+const Namespace = "https://github.com/hallucinaut/securitycontrol/ns/oscal"
+
+// Document is the root of an OSCAL JSON file. Exactly one model is set.
+type Document struct {
+	Catalog                   *Catalog                   `json:"catalog,omitempty"`
+	Profile                   *Profile                   `json:"profile,omitempty"`
+	AssessmentResults         *AssessmentResults         `json:"assessment-results,omitempty"`
+	PlanOfActionAndMilestones *PlanOfActionAndMilestones `json:"plan-of-action-and-milestones,omitempty"`
+}
+
+// Metadata is the metadata common to all OSCAL models.
+type Metadata struct {
+	Title        string    `json:"title"`
+	LastModified time.Time `json:"last-modified"`
+	Version      string    `json:"version"`
+	OSCALVersion string    `json:"oscal-version"`
+	Remarks      string    `json:"remarks,omitempty"`
+}
+
+// Property is an OSCAL name/value property.
+type Property struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+	NS    string `json:"ns,omitempty"`
+	Class string `json:"class,omitempty"`
+}
+
+// Link is an OSCAL link.
+type Link struct {
+	Href string `json:"href"`
+	Rel  string `json:"rel,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+// Part is an OSCAL part such as a statement or guidance.
+type Part struct {
+	ID    string     `json:"id,omitempty"`
+	Name  string     `json:"name"`
+	Title string     `json:"title,omitempty"`
+	Props []Property `json:"props,omitempty"`
+	Prose string     `json:"prose,omitempty"`
+	Parts []Part     `json:"parts,omitempty"`
+}
+
+// Parameter is an OSCAL control parameter.
+type Parameter struct {
+	ID     string     `json:"id"`
+	Label  string     `json:"label,omitempty"`
+	Values []string   `json:"values,omitempty"`
+	Props  []Property `json:"props,omitempty"`
+}
+
+// Decode reads an OSCAL JSON document.
+func Decode(r io.Reader) (*Document, error) {
+	var doc Document
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("oscal: %w", err)
+	}
+	if doc.Catalog == nil && doc.Profile == nil && doc.AssessmentResults == nil && doc.PlanOfActionAndMilestones == nil {
+		return nil, fmt.Errorf("oscal: document contains no supported model")
+	}
+	return &doc, nil
+}
+
+// Encode writes an OSCAL JSON document.
+func Encode(w io.Writer, doc *Document) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(doc)
+}
+
+// prop returns the value of a property in the securitycontrol namespace.
+func prop(props []Property, name string) (string, bool) {
+	for _, p := range props {
+		if p.Name == name && p.NS == Namespace {
+			return p.Value, true
+		}
+	}
+	return "", false
+}
+
+// props returns every value of a property in the securitycontrol namespace.
+func props(list []Property, name string) []string {
+	var values []string
+	for _, p := range list {
+		if p.Name == name && p.NS == Namespace {
+			values = append(values, p.Value)
+		}
+	}
+	return values
+}
+
+// nsProp creates a property in the securitycontrol namespace.
+func nsProp(name, value string) Property {
+	return Property{Name: name, Value: value, NS: Namespace}
+}
+
+// newUUID generates a random version 4 UUID.
+func newUUID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
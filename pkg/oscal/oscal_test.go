@@ -0,0 +1,168 @@
+package oscal
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/catalog"
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+func readDocument(t *testing.T, name string) *Document {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	doc, err := Decode(f)
+	if err != nil {
+		t.Fatalf("Decode(%s): %v", name, err)
+	}
+	return doc
+}
+
+func roundTrip(t *testing.T, doc *Document) *Document {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := Encode(&buf, doc); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	out, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	return out
+}
+
+func TestImportCatalog(t *testing.T) {
+	fw := ImportCatalog(readDocument(t, "testdata/catalog.json").Catalog, ImportDefaults)
+
+	if fw.Name != "Sample Access Control Catalog" || fw.Version != "5.1.1" {
+		t.Errorf("unexpected framework metadata: %q %q", fw.Name, fw.Version)
+	}
+
+	var ids []string
+	for _, c := range fw.Controls {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"ac-1", "ac-2", "ac-2.1", "ia-2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("controls = %v, want %v", ids, want)
+	}
+
+	ac2 := fw.Controls[1]
+	if ac2.SubCategory != "Access Control" || !strings.HasPrefix(ac2.Description, "a. Define") {
+		t.Errorf("unexpected ac-2: %+v", ac2)
+	}
+
+	ia2 := fw.Controls[3]
+	if ia2.Category != control.CategoryPreventive || ia2.RiskReduction != 0.4 || ia2.Owner != "IT Operations" {
+		t.Errorf("extension properties not imported: %+v", ia2)
+	}
+}
+
+func TestImportLoads(t *testing.T) {
+	fw := ImportCatalog(readDocument(t, "testdata/catalog.json").Catalog, ImportDefaults)
+	data, err := catalog.Marshal(fw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(t.TempDir(), "catalog.yaml")
+	if err := os.WriteFile(file, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cat, err := catalog.Load(file)
+	if err != nil {
+		t.Fatalf("loading the imported catalog: %v", err)
+	}
+	if len(cat.Controls) != 4 {
+		t.Fatalf("loaded %d controls, want 4", len(cat.Controls))
+	}
+	ac1 := cat.Controls[0]
+	if ac1.Category != control.CategoryPreventive || ac1.Type != control.TypeAdministrative || ac1.Status != control.StatusNotImplemented {
+		t.Errorf("defaults not applied: %+v", ac1)
+	}
+	if ia2 := cat.Controls[3]; ia2.Type != control.TypeTechnical {
+		t.Errorf("type property overridden by default: %q", ia2.Type)
+	}
+}
+
+func TestCatalogRoundTrip(t *testing.T) {
+	fw := ImportCatalog(readDocument(t, "testdata/catalog.json").Catalog, ImportDefaults)
+	ia2 := fw.Controls[len(fw.Controls)-1]
+	if !reflect.DeepEqual(ia2.Tests, []string{"test-ia-2-mfa"}) {
+		t.Errorf("linked tests not imported: %v", ia2.Tests)
+	}
+	if !reflect.DeepEqual(ia2.DependsOn, []string{"ac-2"}) {
+		t.Errorf("dependencies not imported: %v", ia2.DependsOn)
+	}
+
+	doc := roundTrip(t, &Document{Catalog: ExportCatalog(fw)})
+	got := ImportCatalog(doc.Catalog, ImportDefaults)
+
+	if !reflect.DeepEqual(got, fw) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, fw)
+	}
+}
+
+func TestResolveProfile(t *testing.T) {
+	cat, err := ResolveProfileFile("testdata/profile.json")
+	if err != nil {
+		t.Fatalf("ResolveProfileFile: %v", err)
+	}
+	fw := ImportCatalog(cat, ImportDefaults)
+
+	var ids []string
+	for _, c := range fw.Controls {
+		ids = append(ids, c.ID)
+	}
+	if want := []string{"ac-1", "ac-2", "ac-2.1"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("controls = %v, want %v", ids, want)
+	}
+
+	want := "to the security team an access control policy, reviewed every year."
+	if !strings.Contains(fw.Controls[0].Description, want) {
+		t.Errorf("parameters not applied: %q", fw.Controls[0].Description)
+	}
+}
+
+func TestAssessmentResultsRoundTrip(t *testing.T) {
+	ar := readDocument(t, "testdata/assessment-results.json").AssessmentResults
+	results := ImportAssessmentResults(ar)
+	if len(results) != 2 || results[1].Status != "PARTIALLY_EFFECTIVE" || len(results[1].Issues) != 1 {
+		t.Fatalf("unexpected import: %+v", results)
+	}
+	if issue := results[1].Issues[0]; issue.Code != control.IssueNoEvidence || issue.Remediation == "" {
+		t.Errorf("issue not resolved from its message: %+v", issue)
+	}
+
+	exported := NewExporter("Quarterly Control Validation").AssessmentResults(results, nil)
+	doc := roundTrip(t, &Document{AssessmentResults: exported})
+
+	if got := ImportAssessmentResults(doc.AssessmentResults); !reflect.DeepEqual(got, results) {
+		t.Errorf("round trip mismatch:\n got %+v\nwant %+v", got, results)
+	}
+}
+
+func TestPlanOfActionAndMilestones(t *testing.T) {
+	ar := readDocument(t, "testdata/assessment-results.json").AssessmentResults
+	poam := NewExporter("POA&M").PlanOfActionAndMilestones(ImportAssessmentResults(ar))
+
+	if len(poam.PoamItems) != 1 || len(poam.Risks) != 1 {
+		t.Fatalf("expected one POA&M item, got %d", len(poam.PoamItems))
+	}
+	if id, _ := prop(poam.PoamItems[0].Props, "control-id"); id != "ctrl-002" {
+		t.Errorf("POA&M item for %q, want ctrl-002", id)
+	}
+
+	doc := roundTrip(t, &Document{PlanOfActionAndMilestones: poam})
+	if !reflect.DeepEqual(doc.PlanOfActionAndMilestones, poam) {
+		t.Error("POA&M did not survive encoding")
+	}
+}
@@ -0,0 +1,290 @@
+package oscal
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxImportDepth bounds profile-of-profile resolution.
+const maxImportDepth = 16
+
+// Profile is an OSCAL profile selecting and tailoring catalog controls.
+type Profile struct {
+	UUID     string   `json:"uuid"`
+	Metadata Metadata `json:"metadata"`
+	Imports  []Import `json:"imports"`
+	Modify   *Modify  `json:"modify,omitempty"`
+}
+
+// Import selects controls from a catalog or another profile.
+type Import struct {
+	Href            string      `json:"href"`
+	IncludeAll      *struct{}   `json:"include-all,omitempty"`
+	IncludeControls []Selection `json:"include-controls,omitempty"`
+	ExcludeControls []Selection `json:"exclude-controls,omitempty"`
+}
+
+// Selection identifies controls by ID or pattern.
+type Selection struct {
+	WithChildControls string     `json:"with-child-controls,omitempty"`
+	WithIDs           []string   `json:"with-ids,omitempty"`
+	Matching          []Matching `json:"matching,omitempty"`
+}
+
+// Matching selects controls whose ID matches a glob pattern.
+type Matching struct {
+	Pattern string `json:"pattern"`
+}
+
+// Modify tailors the selected controls.
+type Modify struct {
+	SetParameters []SetParameter `json:"set-parameters,omitempty"`
+}
+
+// SetParameter overrides a parameter of the selected controls.
+type SetParameter struct {
+	ParamID string   `json:"param-id"`
+	Label   string   `json:"label,omitempty"`
+	Values  []string `json:"values,omitempty"`
+}
+
+// Resolver loads the document referenced by an import href.
+type Resolver func(href string) (*Document, error)
+
+// FileResolver resolves hrefs relative to a base directory.
+func FileResolver(dir string) Resolver {
+	return func(href string) (*Document, error) {
+		if strings.HasPrefix(href, "#") || strings.Contains(href, "://") {
+			return nil, fmt.Errorf("oscal: unsupported import href %q", href)
+		}
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(href)))
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return Decode(f)
+	}
+}
+
+// ResolveProfileFile loads a profile and resolves its imports relative to
+// the profile's directory.
+func ResolveProfileFile(name string) (*Catalog, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	doc, err := Decode(f)
+	if err != nil {
+		return nil, err
+	}
+	if doc.Profile == nil {
+		return nil, fmt.Errorf("oscal: %s is not a profile", name)
+	}
+	return ResolveProfile(doc.Profile, FileResolver(filepath.Dir(name)))
+}
+
+// ResolveProfile resolves a profile into a catalog containing only the
+// selected controls, with parameter settings applied.
+func ResolveProfile(p *Profile, resolve Resolver) (*Catalog, error) {
+	return resolveProfile(p, resolve, 0)
+}
+
+func resolveProfile(p *Profile, resolve Resolver, depth int) (*Catalog, error) {
+	if depth > maxImportDepth {
+		return nil, fmt.Errorf("oscal: profile imports nested deeper than %d", maxImportDepth)
+	}
+
+	resolved := &Catalog{
+		UUID:     newUUID(),
+		Metadata: p.Metadata,
+	}
+	resolved.Metadata.OSCALVersion = Version
+
+	for _, imp := range p.Imports {
+		doc, err := resolve(imp.Href)
+		if err != nil {
+			return nil, err
+		}
+
+		source := doc.Catalog
+		if doc.Profile != nil {
+			source, err = resolveProfile(doc.Profile, resolve, depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if source == nil {
+			return nil, fmt.Errorf("oscal: import %q is not a catalog or profile", imp.Href)
+		}
+
+		selected := selectControls(source, imp)
+		resolved.Params = append(resolved.Params, source.Params...)
+		for _, c := range source.Controls {
+			resolved.Controls = append(resolved.Controls, filterControl(c, selected)...)
+		}
+		for _, g := range source.Groups {
+			if fg, ok := filterGroup(g, selected); ok {
+				resolved.Groups = append(resolved.Groups, fg)
+			}
+		}
+	}
+
+	if p.Modify != nil {
+		setParameters(resolved, p.Modify.SetParameters)
+	}
+	return resolved, nil
+}
+
+// selectControls returns the IDs of the controls an import selects.
+func selectControls(cat *Catalog, imp Import) map[string]bool {
+	var all []Control
+	var walk func(cs []Control, parent string)
+	parents := make(map[string]string)
+	walk = func(cs []Control, parent string) {
+		for _, c := range cs {
+			all = append(all, c)
+			parents[c.ID] = parent
+			walk(c.Controls, c.ID)
+		}
+	}
+	var walkGroup func(g Group)
+	walkGroup = func(g Group) {
+		walk(g.Controls, "")
+		for _, sub := range g.Groups {
+			walkGroup(sub)
+		}
+	}
+	walk(cat.Controls, "")
+	for _, g := range cat.Groups {
+		walkGroup(g)
+	}
+
+	selected := make(map[string]bool)
+	if imp.IncludeAll != nil {
+		for _, c := range all {
+			selected[c.ID] = true
+		}
+	}
+	apply(selected, all, parents, imp.IncludeControls, true)
+	apply(selected, all, parents, imp.ExcludeControls, false)
+	return selected
+}
+
+// apply includes or excludes the controls matched by selections.
+func apply(selected map[string]bool, all []Control, parents map[string]string, sels []Selection, include bool) {
+	for _, sel := range sels {
+		for _, c := range all {
+			if !matches(c.ID, sel) && !(sel.WithChildControls == "yes" && ancestorMatches(c.ID, parents, sel)) {
+				continue
+			}
+			if include {
+				selected[c.ID] = true
+			} else {
+				delete(selected, c.ID)
+			}
+		}
+	}
+}
+
+func matches(id string, sel Selection) bool {
+	for _, w := range sel.WithIDs {
+		if w == id {
+			return true
+		}
+	}
+	for _, m := range sel.Matching {
+		if ok, _ := path.Match(m.Pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func ancestorMatches(id string, parents map[string]string, sel Selection) bool {
+	for p := parents[id]; p != ""; p = parents[p] {
+		if matches(p, sel) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterControl keeps a control if selected. Selected children of an
+// unselected control are promoted in its place.
+func filterControl(c Control, selected map[string]bool) []Control {
+	var children []Control
+	for _, sub := range c.Controls {
+		children = append(children, filterControl(sub, selected)...)
+	}
+	if !selected[c.ID] {
+		return children
+	}
+	c.Controls = children
+	return []Control{c}
+}
+
+func filterGroup(g Group, selected map[string]bool) (Group, bool) {
+	var controls []Control
+	for _, c := range g.Controls {
+		controls = append(controls, filterControl(c, selected)...)
+	}
+	var groups []Group
+	for _, sub := range g.Groups {
+		if fg, ok := filterGroup(sub, selected); ok {
+			groups = append(groups, fg)
+		}
+	}
+	g.Controls = controls
+	g.Groups = groups
+	return g, len(controls) > 0 || len(groups) > 0
+}
+
+// setParameters applies profile parameter settings throughout a catalog.
+func setParameters(cat *Catalog, settings []SetParameter) {
+	if len(settings) == 0 {
+		return
+	}
+	byID := make(map[string]SetParameter)
+	for _, s := range settings {
+		byID[s.ParamID] = s
+	}
+
+	update := func(params []Parameter) {
+		for i := range params {
+			s, ok := byID[params[i].ID]
+			if !ok {
+				continue
+			}
+			if s.Label != "" {
+				params[i].Label = s.Label
+			}
+			if len(s.Values) > 0 {
+				params[i].Values = s.Values
+			}
+		}
+	}
+	var walk func(cs []Control)
+	walk = func(cs []Control) {
+		for i := range cs {
+			update(cs[i].Params)
+			walk(cs[i].Controls)
+		}
+	}
+	var walkGroups func(gs []Group)
+	walkGroups = func(gs []Group) {
+		for i := range gs {
+			update(gs[i].Params)
+			walk(gs[i].Controls)
+			walkGroups(gs[i].Groups)
+		}
+	}
+
+	update(cat.Params)
+	walk(cat.Controls)
+	walkGroups(cat.Groups)
+}
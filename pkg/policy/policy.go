@@ -0,0 +1,241 @@
+// Package policy evaluates control validation results against thresholds
+// so that CI pipelines can gate on control posture.
+package policy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// FailOn is the validation status at or below which a control fails.
+type FailOn string
+
+const (
+	FailOnNone        FailOn = "none"
+	FailOnIneffective FailOn = "ineffective"
+	FailOnPartial     FailOn = "partial"
+)
+
+// Thresholds are the limits a control's validation result must meet. Nil
+// fields are not set and inherit from a less specific level.
+type Thresholds struct {
+	FailOn           FailOn   `yaml:"failOn,omitempty"`
+	MinEffectiveness *float64 `yaml:"minEffectiveness,omitempty"`
+	MinConfidence    *float64 `yaml:"minConfidence,omitempty"`
+}
+
+// Policy holds default thresholds and overrides for frameworks and control
+// categories. Category thresholds take precedence over framework
+// thresholds, which take precedence over the defaults.
+type Policy struct {
+	Thresholds `yaml:",inline"`
+	Frameworks map[string]Thresholds                  `yaml:"frameworks,omitempty"`
+	Categories map[control.ControlCategory]Thresholds `yaml:"categories,omitempty"`
+}
+
+// Violation is a control result that does not meet its thresholds.
+type Violation struct {
+	ControlID   string
+	ControlName string
+	Rule        string
+	Actual      string
+	Threshold   string
+	Message     string
+}
+
+// New creates an empty policy that passes every result.
+func New() *Policy {
+	return &Policy{
+		Frameworks: make(map[string]Thresholds),
+		Categories: make(map[control.ControlCategory]Thresholds),
+	}
+}
+
+// Load reads a policy from a YAML file.
+func Load(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// Parse parses a policy from YAML. Unknown keys are rejected.
+func Parse(data []byte) (*Policy, error) {
+	p := New()
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(p); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if p.Frameworks == nil {
+		p.Frameworks = make(map[string]Thresholds)
+	}
+	if p.Categories == nil {
+		p.Categories = make(map[control.ControlCategory]Thresholds)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Validate checks that every threshold is well formed.
+func (p *Policy) Validate() error {
+	if err := p.Thresholds.validate(); err != nil {
+		return err
+	}
+	for name, t := range p.Frameworks {
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("framework %q: %w", name, err)
+		}
+	}
+	for category, t := range p.Categories {
+		switch category {
+		case control.CategoryPreventive, control.CategoryDetective, control.CategoryCorrective,
+			control.CategoryDeterrent, control.CategoryRecovery:
+		default:
+			return fmt.Errorf("unknown category %q", category)
+		}
+		if err := t.validate(); err != nil {
+			return fmt.Errorf("category %q: %w", category, err)
+		}
+	}
+	return nil
+}
+
+// ParseFailOn parses a --fail-on value.
+func ParseFailOn(s string) (FailOn, error) {
+	switch FailOn(s) {
+	case "", FailOnNone:
+		return FailOnNone, nil
+	case FailOnIneffective, FailOnPartial:
+		return FailOn(s), nil
+	}
+	return "", fmt.Errorf("invalid fail-on %q (want ineffective, partial or none)", s)
+}
+
+func (t Thresholds) validate() error {
+	if t.FailOn != "" {
+		if _, err := ParseFailOn(string(t.FailOn)); err != nil {
+			return err
+		}
+	}
+	if t.MinEffectiveness != nil && (*t.MinEffectiveness < 0 || *t.MinEffectiveness > 1) {
+		return fmt.Errorf("minEffectiveness %v must be between 0 and 1", *t.MinEffectiveness)
+	}
+	if t.MinConfidence != nil && (*t.MinConfidence < 0 || *t.MinConfidence > 1) {
+		return fmt.Errorf("minConfidence %v must be between 0 and 1", *t.MinConfidence)
+	}
+	return nil
+}
+
+// Merge returns t with the fields set in override replaced.
+func (t Thresholds) Merge(override Thresholds) Thresholds {
+	if override.FailOn != "" {
+		t.FailOn = override.FailOn
+	}
+	if override.MinEffectiveness != nil {
+		t.MinEffectiveness = override.MinEffectiveness
+	}
+	if override.MinConfidence != nil {
+		t.MinConfidence = override.MinConfidence
+	}
+	return t
+}
+
+// Active reports whether the policy can fail any result.
+func (p *Policy) Active() bool {
+	active := func(t Thresholds) bool {
+		return (t.FailOn != "" && t.FailOn != FailOnNone) || t.MinEffectiveness != nil || t.MinConfidence != nil
+	}
+	if active(p.Thresholds) {
+		return true
+	}
+	for _, t := range p.Frameworks {
+		if active(t) {
+			return true
+		}
+	}
+	for _, t := range p.Categories {
+		if active(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// For returns the thresholds that apply to a control of a framework.
+func (p *Policy) For(framework string, category control.ControlCategory) Thresholds {
+	t := p.Thresholds
+	if override, ok := p.Frameworks[framework]; ok {
+		t = t.Merge(override)
+	}
+	if override, ok := p.Categories[category]; ok {
+		t = t.Merge(override)
+	}
+	return t
+}
+
+// Evaluate checks results against the policy and returns every violation
+// in result order. Controls are looked up by ID to find their category.
+func (p *Policy) Evaluate(framework string, controls []control.SecurityControl, results []control.ControlValidationResult) []Violation {
+	categories := make(map[string]control.ControlCategory)
+	for _, ctrl := range controls {
+		categories[ctrl.ID] = ctrl.Category
+	}
+
+	violations := make([]Violation, 0)
+	for _, result := range results {
+		t := p.For(framework, categories[result.ControlID])
+		add := func(rule, actual, threshold, format string, args ...interface{}) {
+			violations = append(violations, Violation{
+				ControlID:   result.ControlID,
+				ControlName: result.ControlName,
+				Rule:        rule,
+				Actual:      actual,
+				Threshold:   threshold,
+				Message:     fmt.Sprintf(format, args...),
+			})
+		}
+
+		if failed(t.FailOn, result.Status) {
+			add("fail-on", result.Status, string(t.FailOn), "control is %s", result.Status)
+		}
+		if t.MinEffectiveness != nil && result.Effectiveness < *t.MinEffectiveness {
+			add("min-effectiveness", percent(result.Effectiveness), percent(*t.MinEffectiveness),
+				"effectiveness %s is below %s", percent(result.Effectiveness), percent(*t.MinEffectiveness))
+		}
+		if t.MinConfidence != nil && result.Confidence < *t.MinConfidence {
+			add("min-confidence", percent(result.Confidence), percent(*t.MinConfidence),
+				"confidence %s is below %s", percent(result.Confidence), percent(*t.MinConfidence))
+		}
+	}
+	return violations
+}
+
+// failed reports whether a status fails the fail-on setting.
+func failed(failOn FailOn, status string) bool {
+	switch failOn {
+	case FailOnIneffective:
+		return status == "INEFFECTIVE"
+	case FailOnPartial:
+		return status == "INEFFECTIVE" || status == "PARTIALLY_EFFECTIVE"
+	}
+	return false
+}
+
+func percent(f float64) string {
+	return fmt.Sprintf("%.1f%%", f*100)
+}
@@ -0,0 +1,85 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+func ratio(f float64) *float64 {
+	return &f
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`
+failOn: ineffective
+minEffectiveness: 0.7
+frameworks:
+  NIST:
+    minConfidence: 0.6
+categories:
+  preventive:
+    failOn: partial
+    minEffectiveness: 0.9
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := p.For("NIST", control.CategoryPreventive)
+	if got.FailOn != FailOnPartial || *got.MinEffectiveness != 0.9 || *got.MinConfidence != 0.6 {
+		t.Errorf("preventive NIST thresholds = %+v", got)
+	}
+	got = p.For("Other", control.CategoryDetective)
+	if got.FailOn != FailOnIneffective || *got.MinEffectiveness != 0.7 || got.MinConfidence != nil {
+		t.Errorf("default thresholds = %+v", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := map[string]string{
+		"failOn: sometimes":                          `invalid fail-on "sometimes"`,
+		"minEffectiveness: 1.5":                      "minEffectiveness 1.5 must be between 0 and 1",
+		"categories:\n  magic:\n    failOn: partial": `unknown category "magic"`,
+		"minEfectiveness: 0.5":                       "field minEfectiveness not found",
+		"frameworks:\n  X:\n    minConfidence: -1":   `framework "X": minConfidence -1 must be between 0 and 1`,
+	}
+	for data, want := range tests {
+		if _, err := Parse([]byte(data)); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Parse(%q) error = %v, want %q", data, err, want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	controls := []control.SecurityControl{
+		{ID: "c1", Category: control.CategoryPreventive},
+		{ID: "c2", Category: control.CategoryDetective},
+		{ID: "c3", Category: control.CategoryDetective},
+	}
+	results := []control.ControlValidationResult{
+		{ControlID: "c1", Status: "PARTIALLY_EFFECTIVE", Effectiveness: 0.8, Confidence: 0.9},
+		{ControlID: "c2", Status: "PARTIALLY_EFFECTIVE", Effectiveness: 0.8, Confidence: 0.5},
+		{ControlID: "c3", Status: "EFFECTIVE", Effectiveness: 1, Confidence: 0.9},
+	}
+
+	p := New()
+	if p.Active() || len(p.Evaluate("", controls, results)) != 0 {
+		t.Fatal("empty policy must pass every result")
+	}
+
+	p.Thresholds = Thresholds{FailOn: FailOnIneffective, MinConfidence: ratio(0.6)}
+	p.Categories[control.CategoryPreventive] = Thresholds{FailOn: FailOnPartial}
+
+	violations := p.Evaluate("", controls, results)
+	if len(violations) != 2 {
+		t.Fatalf("got %d violations, want 2: %+v", len(violations), violations)
+	}
+	if violations[0].ControlID != "c1" || violations[0].Rule != "fail-on" {
+		t.Errorf("violation 0 = %+v", violations[0])
+	}
+	if violations[1].ControlID != "c2" || violations[1].Rule != "min-confidence" || violations[1].Message != "confidence 50.0% is below 60.0%" {
+		t.Errorf("violation 1 = %+v", violations[1])
+	}
+}
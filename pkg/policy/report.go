@@ -0,0 +1,26 @@
+package policy
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// ViolationColumns are the columns of a report section of violations.
+var ViolationColumns = []report.Column{
+	{Key: "controlId", Title: "Control ID", Kind: report.KindString},
+	{Key: "controlName", Title: "Control", Kind: report.KindString},
+	{Key: "rule", Title: "Rule", Kind: report.KindString},
+	{Key: "actual", Title: "Actual", Kind: report.KindString},
+	{Key: "threshold", Title: "Threshold", Kind: report.KindString},
+	{Key: "message", Title: "Message", Kind: report.KindString},
+}
+
+// AddViolations adds a summary and a "violations" section to a report.
+func AddViolations(r *report.Report, violations []Violation) {
+	r.AddSummary("policyPassed", len(violations) == 0)
+	r.AddSummary("policyViolations", len(violations))
+
+	section := r.AddSection("violations", "Policy Violations", ViolationColumns...)
+	for _, v := range violations {
+		section.AddRow(v.ControlID, v.ControlName, v.Rule, v.Actual, v.Threshold, v.Message)
+	}
+}
@@ -0,0 +1,232 @@
+// Package junit renders control test results as JUnit XML for test
+// dashboards.
+package junit
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+// TestSuites is the root of a JUnit XML report.
+type TestSuites struct {
+	XMLName  xml.Name    `xml:"testsuites"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Skipped  int         `xml:"skipped,attr"`
+	Time     string      `xml:"time,attr"`
+	Suites   []TestSuite `xml:"testsuite"`
+}
+
+// TestSuite groups test cases.
+type TestSuite struct {
+	Name      string     `xml:"name,attr"`
+	Tests     int        `xml:"tests,attr"`
+	Failures  int        `xml:"failures,attr"`
+	Errors    int        `xml:"errors,attr"`
+	Skipped   int        `xml:"skipped,attr"`
+	Time      string     `xml:"time,attr"`
+	Timestamp string     `xml:"timestamp,attr,omitempty"`
+	Cases     []TestCase `xml:"testcase"`
+}
+
+// TestCase is the result of one control test.
+type TestCase struct {
+	Name      string   `xml:"name,attr"`
+	ClassName string   `xml:"classname,attr"`
+	Time      string   `xml:"time,attr"`
+	File      string   `xml:"file,attr,omitempty"`
+	Line      int      `xml:"line,attr,omitempty"`
+	Failure   *Problem `xml:"failure,omitempty"`
+	Error     *Problem `xml:"error,omitempty"`
+	Skipped   *Problem `xml:"skipped,omitempty"`
+	SystemOut *Output  `xml:"system-out,omitempty"`
+}
+
+// Output is text captured from a test.
+type Output struct {
+	Text string `xml:",cdata"`
+}
+
+// Problem describes a failure, error or skip.
+type Problem struct {
+	Message string `xml:"message,attr,omitempty"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",cdata"`
+}
+
+// Locator returns the file and line where a test is defined.
+type Locator func(testID string) (file string, line int, ok bool)
+
+// Builder builds JUnit reports from control test results.
+type Builder struct {
+	name     string
+	controls []control.SecurityControl
+	locate   Locator
+}
+
+// NewBuilder creates a builder for a suite with the given name.
+func NewBuilder(name string) *Builder {
+	return &Builder{name: name}
+}
+
+// SetControls sets the controls used to group tests by the control they
+// are linked to.
+func (b *Builder) SetControls(controls []control.SecurityControl) {
+	b.controls = controls
+}
+
+// SetLocator sets the function used to locate tests in catalog files.
+func (b *Builder) SetLocator(locate Locator) {
+	b.locate = locate
+}
+
+// Build builds a report with a test case for every test. Tests without a
+// result, or whose result was never recorded, are reported as skipped.
+func (b *Builder) Build(tests []validate.ControlTest, results []validate.ValidationResult) *TestSuites {
+	byTest := make(map[string]validate.ValidationResult)
+	for _, result := range results {
+		byTest[result.TestID] = result
+	}
+	linked := make(map[string]string)
+	for _, ctrl := range b.controls {
+		for _, id := range ctrl.Tests {
+			if _, ok := linked[id]; !ok {
+				linked[id] = ctrl.ID
+			}
+		}
+	}
+
+	suite := TestSuite{Name: b.name, Cases: make([]TestCase, 0, len(tests))}
+	var total time.Duration
+	var first time.Time
+	for _, test := range tests {
+		tc := TestCase{
+			Name:      test.ID + ": " + test.Name,
+			ClassName: className(linked[test.ID], test),
+			Time:      seconds(0),
+		}
+		if b.locate != nil {
+			if file, line, ok := b.locate(test.ID); ok {
+				tc.File, tc.Line = file, line
+			}
+		}
+
+		result, ok := byTest[test.ID]
+		switch {
+		case !ok:
+			tc.Skipped = &Problem{Message: "not run"}
+			suite.Skipped++
+		case result.ValidationResult == "NOT_RUN":
+			tc.Skipped = &Problem{Message: result.Error}
+			suite.Skipped++
+		case result.Error != "":
+			tc.Error = &Problem{Message: result.Error, Type: "ERROR", Text: details(test, result)}
+			suite.Errors++
+		case !result.TestPassed:
+			tc.Failure = &Problem{Message: failureMessage(test, result), Type: result.ValidationResult, Text: details(test, result)}
+			suite.Failures++
+		}
+		if ok {
+			tc.Time = seconds(result.Duration)
+			tc.SystemOut = systemOut(result)
+			total += result.Duration
+			if first.IsZero() || (!result.ValidatedAt.IsZero() && result.ValidatedAt.Before(first)) {
+				first = result.ValidatedAt
+			}
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suite.Tests = len(suite.Cases)
+	suite.Time = seconds(total)
+	if !first.IsZero() {
+		suite.Timestamp = first.UTC().Format("2006-01-02T15:04:05")
+	}
+
+	return &TestSuites{
+		Name:     b.name,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []TestSuite{suite},
+	}
+}
+
+// className groups a test under its linked control, or its method when it
+// is not linked to one.
+func className(controlID string, test validate.ControlTest) string {
+	if controlID != "" {
+		return "securitycontrol." + controlID
+	}
+	return "securitycontrol." + string(test.Method)
+}
+
+func failureMessage(test validate.ControlTest, result validate.ValidationResult) string {
+	if result.ActualResult != "" {
+		return result.ActualResult
+	}
+	if test.ExpectedResult != "" {
+		return "expected: " + test.ExpectedResult
+	}
+	return "test failed"
+}
+
+// details describes the expected and actual result of a failed test.
+func details(test validate.ControlTest, result validate.ValidationResult) string {
+	var sb strings.Builder
+	if test.ExpectedResult != "" {
+		sb.WriteString("Expected: " + test.ExpectedResult + "\n")
+	}
+	if result.ActualResult != "" {
+		sb.WriteString("Actual: " + result.ActualResult + "\n")
+	}
+	if result.Error != "" {
+		sb.WriteString("Error: " + result.Error + "\n")
+	}
+	if result.Attempts > 1 {
+		sb.WriteString(fmt.Sprintf("Attempts: %d\n", result.Attempts))
+	}
+	for _, rec := range result.Recommendations {
+		sb.WriteString("Recommendation: " + rec + "\n")
+	}
+	return sb.String()
+}
+
+// systemOut lists the evidence captured by a test by name and digest.
+func systemOut(result validate.ValidationResult) *Output {
+	if len(result.Evidence) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	for _, e := range result.Evidence {
+		sb.WriteString(fmt.Sprintf("%s sha256:%s\n", e.Name, e.SHA256))
+	}
+	return &Output{Text: sb.String()}
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// Write writes a report as indented XML.
+func Write(w io.Writer, suites *TestSuites) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,73 @@
+package junit
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/validate"
+)
+
+func TestBuild(t *testing.T) {
+	tests := []validate.ControlTest{
+		{ID: "t1", Name: "Pass", Method: validate.MethodAutomation},
+		{ID: "t2", Name: "Fail", Method: validate.MethodAutomation, ExpectedResult: "exit code 0"},
+		{ID: "t3", Name: "Broken", Method: validate.MethodAutomation},
+		{ID: "t4", Name: "Manual", Method: validate.MethodInterview},
+		{ID: "t5", Name: "Missing", Method: validate.MethodTesting},
+	}
+	results := []validate.ValidationResult{
+		{TestID: "t1", TestPassed: true, ValidationResult: "PASS", Duration: 1500 * time.Millisecond},
+		{TestID: "t2", ValidationResult: "FAIL", ActualResult: "exit code 1", Duration: 250 * time.Millisecond},
+		{TestID: "t3", ValidationResult: "ERROR", Error: "command timed out after 1s"},
+		{TestID: "t4", ValidationResult: "NOT_RUN", Error: "no result recorded for manual test"},
+	}
+
+	builder := NewBuilder("suite")
+	builder.SetControls([]control.SecurityControl{{ID: "c1", Tests: []string{"t1", "t2"}}})
+	suites := builder.Build(tests, results)
+
+	if suites.Tests != 5 || suites.Failures != 1 || suites.Errors != 1 || suites.Skipped != 2 || suites.Time != "1.750" {
+		t.Errorf("totals = %+v", suites)
+	}
+	cases := suites.Suites[0].Cases
+	if cases[0].ClassName != "securitycontrol.c1" || cases[0].Time != "1.500" || cases[0].Failure != nil {
+		t.Errorf("passing case = %+v", cases[0])
+	}
+	if cases[1].Failure == nil || cases[1].Failure.Message != "exit code 1" || !strings.Contains(cases[1].Failure.Text, "Expected: exit code 0") {
+		t.Errorf("failing case = %+v", cases[1])
+	}
+	if cases[2].Error == nil || cases[2].ClassName != "securitycontrol.automation" {
+		t.Errorf("error case = %+v", cases[2])
+	}
+	if cases[3].Skipped == nil || cases[4].Skipped == nil || cases[4].Skipped.Message != "not run" {
+		t.Errorf("skipped cases = %+v, %+v", cases[3], cases[4])
+	}
+}
+
+func TestWrite(t *testing.T) {
+	suites := NewBuilder("suite").Build(
+		[]validate.ControlTest{{ID: "t1", Name: "Fail"}},
+		[]validate.ValidationResult{{TestID: "t1", ValidationResult: "FAIL", ActualResult: "a < b\nsecond line"}},
+	)
+
+	var buf bytes.Buffer
+	if err := Write(&buf, suites); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), xml.Header) {
+		t.Errorf("missing XML header:\n%s", buf.String())
+	}
+
+	var decoded TestSuites
+	if err := xml.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	failure := decoded.Suites[0].Cases[0].Failure
+	if failure == nil || !strings.Contains(failure.Text, "a < b\nsecond line") {
+		t.Errorf("failure = %+v", failure)
+	}
+}
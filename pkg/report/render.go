@@ -0,0 +1,313 @@
+package report
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is an output format of a report.
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatJSON     Format = "json"
+	FormatYAML     Format = "yaml"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "markdown"
+
+	// SARIF and JUnit are built from control and test results rather than
+	// from a Report; see the sarif and junit packages.
+	FormatSARIF Format = "sarif"
+	FormatJUnit Format = "junit"
+)
+
+// Formats lists the supported output formats.
+var Formats = []Format{FormatText, FormatJSON, FormatYAML, FormatCSV, FormatMarkdown, FormatSARIF, FormatJUnit}
+
+// ParseFormat parses a format name.
+func ParseFormat(name string) (Format, error) {
+	switch strings.ToLower(name) {
+	case "", "text", "txt":
+		return FormatText, nil
+	case "json":
+		return FormatJSON, nil
+	case "yaml", "yml":
+		return FormatYAML, nil
+	case "csv":
+		return FormatCSV, nil
+	case "markdown", "md":
+		return FormatMarkdown, nil
+	case "sarif":
+		return FormatSARIF, nil
+	case "junit":
+		return FormatJUnit, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want one of %s)", name, formatNames())
+}
+
+func formatNames() string {
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
+
+// Render writes a report in the given format.
+func Render(w io.Writer, r *Report, format Format) error {
+	switch format {
+	case FormatText:
+		return RenderText(w, r)
+	case FormatJSON:
+		return RenderJSON(w, r)
+	case FormatYAML:
+		return RenderYAML(w, r)
+	case FormatCSV:
+		return RenderCSV(w, r)
+	case FormatMarkdown:
+		return RenderMarkdown(w, r)
+	case FormatSARIF, FormatJUnit:
+		return fmt.Errorf("format %q is not supported for %s reports", format, r.Kind)
+	}
+	return fmt.Errorf("unknown format %q", format)
+}
+
+// RenderJSON writes a report as indented JSON.
+func RenderJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// RenderYAML writes a report as YAML with the same structure as JSON.
+func RenderYAML(w io.Writer, r *Report) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(r); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// RenderCSV writes the rows of a report as CSV. Reports with several
+// sections get a leading "section" column and the union of their columns.
+func RenderCSV(w io.Writer, r *Report) error {
+	columns := r.Sections
+	var keys []string
+	seen := make(map[string]bool)
+	if len(columns) > 1 {
+		keys = append(keys, "section")
+	}
+	for _, s := range columns {
+		for _, col := range s.Columns {
+			if !seen[col.Key] {
+				seen[col.Key] = true
+				keys = append(keys, col.Key)
+			}
+		}
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(keys); err != nil {
+		return err
+	}
+	for _, s := range r.Sections {
+		for _, row := range s.Rows {
+			record := make([]string, len(keys))
+			for i, key := range keys {
+				if key == "section" && len(columns) > 1 {
+					record[i] = s.Name
+					continue
+				}
+				record[i] = formatRaw(row.Get(key), "; ")
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// RenderMarkdown writes a report as Markdown with a table per section.
+func RenderMarkdown(w io.Writer, r *Report) error {
+	var sb strings.Builder
+	sb.WriteString("# " + r.Title + "\n\n")
+	sb.WriteString("Generated: " + r.GeneratedAt.Format(time.RFC3339) + "\n\n")
+
+	if len(r.Summary) > 0 {
+		for _, f := range r.Summary {
+			sb.WriteString(fmt.Sprintf("- **%s:** %s\n", label(f.Key), markdownCell(formatValue(f.Value, f.kind(), ", "))))
+		}
+		sb.WriteString("\n")
+	}
+
+	for _, s := range r.Sections {
+		sb.WriteString("## " + s.Title + "\n\n")
+		if len(s.Rows) == 0 {
+			sb.WriteString("_None_\n\n")
+			continue
+		}
+		titles := make([]string, len(s.Columns))
+		rules := make([]string, len(s.Columns))
+		for i, col := range s.Columns {
+			titles[i] = markdownCell(col.Title)
+			rules[i] = "---"
+		}
+		sb.WriteString("| " + strings.Join(titles, " | ") + " |\n")
+		sb.WriteString("| " + strings.Join(rules, " | ") + " |\n")
+		for _, row := range s.Rows {
+			cells := make([]string, len(s.Columns))
+			for i, col := range s.Columns {
+				cells[i] = markdownCell(formatValue(row.Get(col.Key), col.Kind, "<br>"))
+			}
+			sb.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// RenderText writes a report as plain text, one block per row.
+func RenderText(w io.Writer, r *Report) error {
+	var sb strings.Builder
+	sb.WriteString(r.Title + "\n")
+	sb.WriteString(strings.Repeat("=", len(r.Title)) + "\n\n")
+
+	for _, f := range r.Summary {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", label(f.Key), formatValue(f.Value, f.kind(), ", ")))
+	}
+	if len(r.Summary) > 0 {
+		sb.WriteString("\n")
+	}
+
+	for _, s := range r.Sections {
+		sb.WriteString(s.Title + ":\n")
+		if len(s.Rows) == 0 {
+			sb.WriteString("  None\n")
+		}
+		for _, row := range s.Rows {
+			for i, col := range s.Columns {
+				prefix := "    "
+				if i == 0 {
+					prefix = "  - "
+				}
+				sb.WriteString(fmt.Sprintf("%s%s: %s\n", prefix, col.Title, formatValue(row.Get(col.Key), col.Kind, ", ")))
+			}
+		}
+		sb.WriteString("\n")
+	}
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// formatValue formats a value for display.
+func formatValue(value interface{}, kind ColumnKind, sep string) string {
+	if kind == KindPercent {
+		if f, ok := toFloat(value); ok {
+			return fmt.Sprintf("%.1f%%", f*100)
+		}
+	}
+	if t, ok := value.(time.Time); ok {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2006-01-02 15:04:05")
+	}
+	return formatRaw(value, sep)
+}
+
+// formatRaw formats a value without unit conversion.
+func formatRaw(value interface{}, sep string) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case time.Duration:
+		return v.String()
+	case []string:
+		return strings.Join(v, sep)
+	case []interface{}:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = formatRaw(item, sep)
+		}
+		return strings.Join(parts, sep)
+	}
+	return fmt.Sprint(value)
+}
+
+// kind returns the kind of a summary field, or the kind of its value when
+// none was set.
+func (f Field) kind() ColumnKind {
+	if f.Kind != "" {
+		return f.Kind
+	}
+	return kindOf(f.Value)
+}
+
+func kindOf(value interface{}) ColumnKind {
+	switch value.(type) {
+	case []string, []interface{}:
+		return KindList
+	case time.Time:
+		return KindTime
+	}
+	return KindString
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// label turns a camelCase key into a title such as "Not Implemented".
+func label(key string) string {
+	var sb strings.Builder
+	for i, r := range key {
+		if i == 0 {
+			sb.WriteString(strings.ToUpper(string(r)))
+			continue
+		}
+		if r >= 'A' && r <= 'Z' {
+			sb.WriteByte(' ')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", " ")
+}
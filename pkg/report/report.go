@@ -0,0 +1,201 @@
+// Package report provides a structured report model and renders it as
+// text, JSON, YAML, CSV or Markdown.
+package report
+
+import (
+	"bytes"
+	"encoding/json"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is the version of the report schema. It changes when fields
+// are removed or change meaning; new fields may be added within a version.
+const SchemaVersion = "1"
+
+// SchemaURL identifies the JSON schema of the report envelope and resolves
+// to the schema file in this repository.
+const SchemaURL = "https://raw.githubusercontent.com/hallucinaut/securitycontrol/main/pkg/report/report-v1.schema.json"
+
+// ColumnKind describes how a column's values are formatted.
+type ColumnKind string
+
+const (
+	KindString  ColumnKind = "string"
+	KindNumber  ColumnKind = "number"
+	KindPercent ColumnKind = "percent"
+	KindBool    ColumnKind = "bool"
+	KindList    ColumnKind = "list"
+	KindTime    ColumnKind = "time"
+)
+
+// Report represents a structured command report.
+type Report struct {
+	Schema        string    `json:"$schema" yaml:"$schema"`
+	SchemaVersion string    `json:"schemaVersion" yaml:"schemaVersion"`
+	Kind          string    `json:"kind" yaml:"kind"`
+	Title         string    `json:"title" yaml:"title"`
+	GeneratedAt   time.Time `json:"generatedAt" yaml:"generatedAt"`
+	Summary       Fields    `json:"summary" yaml:"summary"`
+	Sections      []Section `json:"sections" yaml:"sections"`
+}
+
+// Section is a table of rows within a report.
+type Section struct {
+	Name    string   `json:"name" yaml:"name"`
+	Title   string   `json:"title" yaml:"title"`
+	Columns []Column `json:"columns" yaml:"columns"`
+	Rows    []Fields `json:"rows" yaml:"rows"`
+}
+
+// Column describes a column of a section.
+type Column struct {
+	Key   string     `json:"key" yaml:"key"`
+	Title string     `json:"title" yaml:"title"`
+	Kind  ColumnKind `json:"kind" yaml:"kind"`
+}
+
+// Field is a key/value pair. Kind sets how a summary field is formatted in
+// text and Markdown output; it is not encoded.
+type Field struct {
+	Key   string
+	Value interface{}
+	Kind  ColumnKind
+}
+
+// Fields is an ordered set of key/value pairs. It is encoded as a JSON or
+// YAML object that preserves the order of its keys.
+type Fields []Field
+
+// New creates a report of the given kind.
+func New(kind, title string) *Report {
+	return &Report{
+		Schema:        SchemaURL,
+		SchemaVersion: SchemaVersion,
+		Kind:          kind,
+		Title:         title,
+		GeneratedAt:   time.Now().UTC(),
+		Summary:       make(Fields, 0),
+		Sections:      make([]Section, 0),
+	}
+}
+
+// AddSummary adds a summary field.
+func (r *Report) AddSummary(key string, value interface{}) {
+	r.Summary = append(r.Summary, Field{Key: key, Value: value})
+}
+
+// AddSummaryKind adds a summary field formatted like a column of the given
+// kind, such as a ratio shown as a percentage.
+func (r *Report) AddSummaryKind(key string, value interface{}, kind ColumnKind) {
+	r.Summary = append(r.Summary, Field{Key: key, Value: value, Kind: kind})
+}
+
+// AddSection adds a section and returns it for rows to be added.
+func (r *Report) AddSection(name, title string, columns ...Column) *Section {
+	r.Sections = append(r.Sections, Section{
+		Name:    name,
+		Title:   title,
+		Columns: columns,
+		Rows:    make([]Fields, 0),
+	})
+	return &r.Sections[len(r.Sections)-1]
+}
+
+// GetSection returns the section with the given name.
+func (r *Report) GetSection(name string) *Section {
+	for i := range r.Sections {
+		if r.Sections[i].Name == name {
+			return &r.Sections[i]
+		}
+	}
+	return nil
+}
+
+// AddRow adds a row with values in column order. Zero times are recorded
+// as null.
+func (s *Section) AddRow(values ...interface{}) {
+	row := make(Fields, len(s.Columns))
+	for i, col := range s.Columns {
+		row[i] = Field{Key: col.Key}
+		if i >= len(values) {
+			continue
+		}
+		if t, ok := values[i].(time.Time); ok && t.IsZero() {
+			continue
+		}
+		row[i].Value = values[i]
+	}
+	s.Rows = append(s.Rows, row)
+}
+
+// Get returns the value of a field.
+func (f Fields) Get(key string) interface{} {
+	for _, field := range f {
+		if field.Key == key {
+			return field.Value
+		}
+	}
+	return nil
+}
+
+// MarshalJSON encodes the fields as an ordered JSON object.
+func (f Fields) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range f {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON decodes a JSON object, preserving key order.
+func (f *Fields) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if _, err := dec.Token(); err != nil {
+		return err
+	}
+
+	*f = make(Fields, 0)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return err
+		}
+		*f = append(*f, Field{Key: tok.(string), Value: value})
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// MarshalYAML encodes the fields as an ordered YAML mapping.
+func (f Fields) MarshalYAML() (interface{}, error) {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, field := range f {
+		var value yaml.Node
+		if err := value.Encode(field.Value); err != nil {
+			return nil, err
+		}
+		node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: field.Key}, &value)
+	}
+	return node, nil
+}
@@ -0,0 +1,163 @@
+package report
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func sample() *Report {
+	r := New("status", "Status")
+	r.GeneratedAt = time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	r.AddSummary("controls", 2)
+	r.AddSummaryKind("averageEffectiveness", 0.75, KindPercent)
+	s := r.AddSection("controls", "Results",
+		Column{Key: "id", Title: "ID", Kind: KindString},
+		Column{Key: "effectiveness", Title: "Effectiveness", Kind: KindPercent},
+		Column{Key: "issues", Title: "Issues", Kind: KindList},
+		Column{Key: "nextReview", Title: "Next Review", Kind: KindTime},
+	)
+	s.AddRow("ctrl-001", 1.0, []string{}, time.Time{})
+	s.AddRow("ctrl|002", 0.5, []string{"No owner", "Stale"}, r.GeneratedAt)
+	return r
+}
+
+func TestRenderJSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderJSON(&buf, sample()); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"controls": 2,
+    "averageEffectiveness": 0.75`) {
+		t.Errorf("summary keys not in order:\n%s", out)
+	}
+	if !strings.Contains(out, `"nextReview": null`) {
+		t.Errorf("zero time not encoded as null:\n%s", out)
+	}
+
+	var decoded Report
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.SchemaVersion != SchemaVersion || decoded.Kind != "status" {
+		t.Errorf("decoded envelope = %+v", decoded)
+	}
+	rows := decoded.GetSection("controls").Rows
+	if len(rows) != 2 || rows[1].Get("id") != "ctrl|002" || rows[1][0].Key != "id" {
+		t.Errorf("decoded rows = %v", rows)
+	}
+}
+
+func TestRenderYAMLMatchesJSON(t *testing.T) {
+	var yamlBuf, jsonBuf bytes.Buffer
+	if err := RenderYAML(&yamlBuf, sample()); err != nil {
+		t.Fatal(err)
+	}
+	if err := RenderJSON(&jsonBuf, sample()); err != nil {
+		t.Fatal(err)
+	}
+
+	var fromYAML, fromJSON map[string]interface{}
+	if err := yaml.Unmarshal(yamlBuf.Bytes(), &fromYAML); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"$schema", "schemaVersion", "kind", "title"} {
+		if fromYAML[key] != fromJSON[key] {
+			t.Errorf("%s: yaml %v, json %v", key, fromYAML[key], fromJSON[key])
+		}
+	}
+}
+
+func TestRenderCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderCSV(&buf, sample()); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"id", "effectiveness", "issues", "nextReview"},
+		{"ctrl-001", "1", "", ""},
+		{"ctrl|002", "0.5", "No owner; Stale", "2026-01-02T03:04:05Z"},
+	}
+	if len(records) != len(want) {
+		t.Fatalf("got %d records, want %d", len(records), len(want))
+	}
+	for i := range want {
+		if strings.Join(records[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("record %d = %v, want %v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestRenderCSVSections(t *testing.T) {
+	r := sample()
+	r.AddSection("tests", "Tests", Column{Key: "id", Title: "ID"}, Column{Key: "passed", Title: "Passed"}).AddRow("test-001", true)
+
+	var buf bytes.Buffer
+	if err := RenderCSV(&buf, r); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "section,id,effectiveness,issues,nextReview,passed" {
+		t.Errorf("header = %q", lines[0])
+	}
+	if lines[3] != "tests,test-001,,,,true" {
+		t.Errorf("row = %q", lines[3])
+	}
+}
+
+func TestRenderMarkdown(t *testing.T) {
+	var buf bytes.Buffer
+	if err := RenderMarkdown(&buf, sample()); err != nil {
+		t.Fatal(err)
+	}
+
+	out := buf.String()
+	for _, want := range []string{
+		"# Status\n",
+		"- **Controls:** 2\n",
+		"- **Average Effectiveness:** 75.0%\n",
+		"| ID | Effectiveness | Issues | Next Review |\n",
+		"| ctrl\\|002 | 50.0% | No owner<br>Stale | 2026-01-02 03:04:05 |\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	for name, want := range map[string]Format{"": FormatText, "JSON": FormatJSON, "yml": FormatYAML, "md": FormatMarkdown} {
+		got, err := ParseFormat(name)
+		if err != nil || got != want {
+			t.Errorf("ParseFormat(%q) = %q, %v", name, got, err)
+		}
+	}
+	if _, err := ParseFormat("xml"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
+
+func TestSchemaIsJSON(t *testing.T) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(Schema(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["$id"] != SchemaURL {
+		t.Errorf("$id = %v, want %s", doc["$id"], SchemaURL)
+	}
+}
@@ -0,0 +1,302 @@
+// Package sarif renders control validation results as SARIF 2.1.0 logs for
+// code-scanning tools.
+package sarif
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// Version is the SARIF version produced.
+const Version = "2.1.0"
+
+// SchemaURI is the JSON schema of SARIF 2.1.0 logs.
+const SchemaURI = "https://json.schemastore.org/sarif-2.1.0.json"
+
+// InformationURI is the home page reported for the tool.
+const InformationURI = "https://github.com/hallucinaut/securitycontrol"
+
+// Log is the root of a SARIF log.
+type Log struct {
+	Schema  string `json:"$schema"`
+	Version string `json:"version"`
+	Runs    []Run  `json:"runs"`
+}
+
+// Run is a single run of the tool.
+type Run struct {
+	Tool    Tool     `json:"tool"`
+	Results []Result `json:"results"`
+}
+
+// Tool describes the tool that produced a run.
+type Tool struct {
+	Driver Driver `json:"driver"`
+}
+
+// Driver describes the tool's primary component and its rules.
+type Driver struct {
+	Name           string `json:"name"`
+	Version        string `json:"version,omitempty"`
+	InformationURI string `json:"informationUri,omitempty"`
+	Rules          []Rule `json:"rules"`
+}
+
+// Rule describes a security control as a reporting rule.
+type Rule struct {
+	ID                   string          `json:"id"`
+	Name                 string          `json:"name,omitempty"`
+	ShortDescription     *Message        `json:"shortDescription,omitempty"`
+	FullDescription      *Message        `json:"fullDescription,omitempty"`
+	HelpURI              string          `json:"helpUri,omitempty"`
+	Help                 *Message        `json:"help,omitempty"`
+	DefaultConfiguration *Configuration  `json:"defaultConfiguration,omitempty"`
+	Properties           *RuleProperties `json:"properties,omitempty"`
+}
+
+// Configuration holds a rule's default reporting level.
+type Configuration struct {
+	Level string `json:"level"`
+}
+
+// RuleProperties holds control metadata attached to a rule.
+type RuleProperties struct {
+	Tags       []string `json:"tags,omitempty"`
+	Category   string   `json:"category,omitempty"`
+	Type       string   `json:"type,omitempty"`
+	Owner      string   `json:"owner,omitempty"`
+	References []string `json:"references,omitempty"`
+}
+
+// Message is a SARIF message.
+type Message struct {
+	Text string `json:"text"`
+}
+
+// Result is a single finding.
+type Result struct {
+	RuleID              string            `json:"ruleId"`
+	RuleIndex           int               `json:"ruleIndex"`
+	Level               string            `json:"level"`
+	Message             Message           `json:"message"`
+	Locations           []Location        `json:"locations,omitempty"`
+	PartialFingerprints map[string]string `json:"partialFingerprints,omitempty"`
+	Properties          *ResultProperties `json:"properties,omitempty"`
+}
+
+// ResultProperties holds the validation scores behind a result.
+type ResultProperties struct {
+	Status        string  `json:"status"`
+	Effectiveness float64 `json:"effectiveness"`
+	Confidence    float64 `json:"confidence"`
+	Untested      bool    `json:"untested"`
+	IssueCode     string  `json:"issueCode,omitempty"`
+	Severity      string  `json:"severity,omitempty"`
+	Field         string  `json:"field,omitempty"`
+	Remediation   string  `json:"remediation,omitempty"`
+}
+
+// Location is where a result was found.
+type Location struct {
+	PhysicalLocation *PhysicalLocation `json:"physicalLocation,omitempty"`
+	LogicalLocations []LogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+// PhysicalLocation is a position in a file.
+type PhysicalLocation struct {
+	ArtifactLocation ArtifactLocation `json:"artifactLocation"`
+	Region           *Region          `json:"region,omitempty"`
+}
+
+// ArtifactLocation names a file.
+type ArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// Region is a range within a file.
+type Region struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+// LogicalLocation names a control.
+type LogicalLocation struct {
+	Name               string `json:"name"`
+	FullyQualifiedName string `json:"fullyQualifiedName,omitempty"`
+	Kind               string `json:"kind,omitempty"`
+}
+
+// Locator returns the file and line where a control is defined.
+type Locator func(controlID string) (file string, line int, ok bool)
+
+// Builder builds SARIF logs from control validation results.
+type Builder struct {
+	toolVersion string
+	locate      Locator
+}
+
+// NewBuilder creates a builder reporting the given tool version.
+func NewBuilder(toolVersion string) *Builder {
+	return &Builder{toolVersion: toolVersion}
+}
+
+// SetLocator sets the function used to locate controls in catalog files.
+func (b *Builder) SetLocator(locate Locator) {
+	b.locate = locate
+}
+
+// Build builds a log with a rule for every control and a result for every
+// issue of a control that is not effective. Controls that are not effective
+// but have no issues get a single result.
+func (b *Builder) Build(controls []control.SecurityControl, results []control.ControlValidationResult) *Log {
+	driver := Driver{
+		Name:           "securitycontrol",
+		Version:        b.toolVersion,
+		InformationURI: InformationURI,
+		Rules:          make([]Rule, 0, len(controls)),
+	}
+	index := make(map[string]int)
+	for _, ctrl := range controls {
+		index[ctrl.ID] = len(driver.Rules)
+		driver.Rules = append(driver.Rules, rule(ctrl))
+	}
+
+	run := Run{Tool: Tool{Driver: driver}, Results: make([]Result, 0)}
+	for _, result := range results {
+		if result.Status == "EFFECTIVE" {
+			continue
+		}
+		i, ok := index[result.ControlID]
+		if !ok {
+			i = len(run.Tool.Driver.Rules)
+			index[result.ControlID] = i
+			run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, Rule{ID: result.ControlID, Name: result.ControlName})
+		}
+
+		issues := result.Issues
+		if len(issues) == 0 {
+			issues = []control.Issue{{Message: fmt.Sprintf("Control is %s", strings.ToLower(strings.ReplaceAll(result.Status, "_", " ")))}}
+		}
+		for _, issue := range issues {
+			run.Results = append(run.Results, Result{
+				RuleID:    result.ControlID,
+				RuleIndex: i,
+				Level:     level(result.Status, issue.Severity),
+				Message: Message{Text: fmt.Sprintf("%s: %s (%.1f%% effective)",
+					result.ControlName, issue.Message, result.Effectiveness*100)},
+				Locations: b.locations(result.ControlID),
+				PartialFingerprints: map[string]string{
+					"securitycontrol/v1": fingerprint(result.ControlID, issue.Message),
+				},
+				Properties: &ResultProperties{
+					Status:        result.Status,
+					Effectiveness: result.Effectiveness,
+					Confidence:    result.Confidence,
+					Untested:      result.Untested,
+					IssueCode:     issue.Code,
+					Severity:      string(issue.Severity),
+					Field:         issue.Field,
+					Remediation:   issue.Remediation,
+				},
+			})
+		}
+	}
+
+	return &Log{Schema: SchemaURI, Version: Version, Runs: []Run{run}}
+}
+
+// locations returns the physical and logical location of a control.
+func (b *Builder) locations(controlID string) []Location {
+	loc := Location{LogicalLocations: []LogicalLocation{{
+		Name:               controlID,
+		FullyQualifiedName: "controls/" + controlID,
+		Kind:               "object",
+	}}}
+	if b.locate != nil {
+		if file, line, ok := b.locate(controlID); ok {
+			loc.PhysicalLocation = &PhysicalLocation{ArtifactLocation: ArtifactLocation{URI: file}}
+			if line > 0 {
+				loc.PhysicalLocation.Region = &Region{StartLine: line}
+			}
+		}
+	}
+	return []Location{loc}
+}
+
+// rule describes a control as a rule.
+func rule(ctrl control.SecurityControl) Rule {
+	r := Rule{
+		ID:                   ctrl.ID,
+		Name:                 ruleName(ctrl.Name),
+		ShortDescription:     &Message{Text: ctrl.Name},
+		DefaultConfiguration: &Configuration{Level: "error"},
+		Properties: &RuleProperties{
+			Category:   string(ctrl.Category),
+			Type:       string(ctrl.Type),
+			Owner:      ctrl.Owner,
+			References: ctrl.References,
+		},
+	}
+	if ctrl.Description != "" {
+		r.FullDescription = &Message{Text: ctrl.Description}
+	}
+	if ctrl.Verification != "" {
+		r.Help = &Message{Text: "Verification: " + ctrl.Verification}
+	}
+
+	tags := []string{"security"}
+	if ctrl.Category != "" {
+		tags = append(tags, string(ctrl.Category))
+	}
+	if ctrl.Type != "" {
+		tags = append(tags, string(ctrl.Type))
+	}
+	r.Properties.Tags = append(tags, ctrl.References...)
+
+	for _, ref := range ctrl.References {
+		if strings.HasPrefix(ref, "https://") || strings.HasPrefix(ref, "http://") {
+			r.HelpURI = ref
+			break
+		}
+	}
+	return r
+}
+
+// ruleName turns a control name into a PascalCase rule name.
+func ruleName(name string) string {
+	var sb strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return sb.String()
+}
+
+// level maps a validation status and issue severity to a SARIF level.
+// Issues of ineffective controls and high or critical issues are errors.
+func level(status string, severity control.Severity) string {
+	if status == "INEFFECTIVE" || severity.Rank() >= control.SeverityHigh.Rank() {
+		return "error"
+	}
+	return "warning"
+}
+
+// fingerprint identifies a finding across runs.
+func fingerprint(controlID, msg string) string {
+	sum := sha256.Sum256([]byte(controlID + "\x00" + msg))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Write writes a log as indented JSON.
+func Write(w io.Writer, log *Log) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
@@ -0,0 +1,79 @@
+package sarif
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+func TestBuild(t *testing.T) {
+	controls := []control.SecurityControl{
+		{ID: "c1", Name: "Disk Encryption", Category: control.CategoryPreventive, Type: control.TypeTechnical,
+			References: []string{"CIS-3.6", "https://example.com/enc"}},
+		{ID: "c2", Name: "Logging"},
+		{ID: "c3", Name: "Backups"},
+	}
+	results := []control.ControlValidationResult{
+		{ControlID: "c1", ControlName: "Disk Encryption", Status: "INEFFECTIVE", Issues: []control.Issue{control.NewIssue(control.IssueNoOwner), control.NewIssue(control.IssueNoEvidence)}},
+		{ControlID: "c2", ControlName: "Logging", Status: "EFFECTIVE", Effectiveness: 1},
+		{ControlID: "c3", ControlName: "Backups", Status: "PARTIALLY_EFFECTIVE", Effectiveness: 0.7},
+	}
+
+	builder := NewBuilder("1.2.3")
+	builder.SetLocator(func(id string) (string, int, bool) {
+		return "controls.yaml", 7, id == "c1"
+	})
+	log := builder.Build(controls, results)
+
+	run := log.Runs[0]
+	if len(run.Tool.Driver.Rules) != 3 {
+		t.Fatalf("got %d rules, want 3", len(run.Tool.Driver.Rules))
+	}
+	rule := run.Tool.Driver.Rules[0]
+	if rule.ID != "c1" || rule.Name != "DiskEncryption" || rule.HelpURI != "https://example.com/enc" || rule.Properties.Category != "preventive" {
+		t.Errorf("rule = %+v", rule)
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("got %d results, want 3: %+v", len(run.Results), run.Results)
+	}
+	first := run.Results[0]
+	if first.RuleID != "c1" || first.Level != "error" || first.Locations[0].PhysicalLocation.Region.StartLine != 7 {
+		t.Errorf("first result = %+v", first)
+	}
+	if run.Results[0].PartialFingerprints["securitycontrol/v1"] == run.Results[1].PartialFingerprints["securitycontrol/v1"] {
+		t.Error("issues of one control share a fingerprint")
+	}
+	last := run.Results[2]
+	if last.RuleIndex != 2 || last.Level != "warning" || last.Locations[0].PhysicalLocation != nil {
+		t.Errorf("last result = %+v", last)
+	}
+	// Results of controls without a file are still located by control ID
+	if logical := last.Locations[0].LogicalLocations; len(logical) != 1 || logical[0].FullyQualifiedName != "controls/"+last.RuleID {
+		t.Errorf("logical locations = %+v", logical)
+	}
+	if last.Message.Text != "Backups: Control is partially effective (70.0% effective)" {
+		t.Errorf("message = %q", last.Message.Text)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Write(&buf, NewBuilder("1").Build(nil, nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if doc["version"] != "2.1.0" || doc["$schema"] != SchemaURI {
+		t.Errorf("header = %v", doc)
+	}
+	runs := doc["runs"].([]interface{})
+	if results := runs[0].(map[string]interface{})["results"]; results == nil {
+		t.Error("results must be an empty array, not null")
+	}
+}
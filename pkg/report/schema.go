@@ -0,0 +1,11 @@
+package report
+
+import _ "embed"
+
+//go:embed report-v1.schema.json
+var schema []byte
+
+// Schema returns the JSON schema of reports rendered as JSON.
+func Schema() []byte {
+	return append([]byte(nil), schema...)
+}
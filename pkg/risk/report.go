@@ -0,0 +1,80 @@
+package risk
+
+import (
+	"fmt"
+
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// ScenarioColumns are the columns of a report section of the risk register.
+var ScenarioColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "name", Title: "Scenario", Kind: report.KindString},
+	{Key: "category", Title: "Category", Kind: report.KindString},
+	{Key: "asset", Title: "Asset", Kind: report.KindString},
+	{Key: "threat", Title: "Threat", Kind: report.KindString},
+	{Key: "likelihood", Title: "Likelihood", Kind: report.KindNumber},
+	{Key: "impact", Title: "Impact", Kind: report.KindNumber},
+	{Key: "inherentRisk", Title: "Inherent Risk", Kind: report.KindNumber},
+	{Key: "residualRisk", Title: "Residual Risk", Kind: report.KindNumber},
+	{Key: "reduction", Title: "Reduction", Kind: report.KindPercent},
+	{Key: "underControlled", Title: "Under-Controlled", Kind: report.KindBool},
+	{Key: "controls", Title: "Controls", Kind: report.KindList},
+}
+
+// TotalColumns are the columns of a report section of risk totals.
+var TotalColumns = []report.Column{
+	{Key: "category", Title: "Category", Kind: report.KindString},
+	{Key: "scenarios", Title: "Scenarios", Kind: report.KindNumber},
+	{Key: "inherentRisk", Title: "Inherent Risk", Kind: report.KindNumber},
+	{Key: "residualRisk", Title: "Residual Risk", Kind: report.KindNumber},
+	{Key: "reduction", Title: "Reduction", Kind: report.KindPercent},
+}
+
+// MitigationColumns are the columns of a report section of scenario
+// mitigations.
+var MitigationColumns = []report.Column{
+	{Key: "scenarioId", Title: "Scenario", Kind: report.KindString},
+	{Key: "controlId", Title: "Control ID", Kind: report.KindString},
+	{Key: "controlName", Title: "Control", Kind: report.KindString},
+	{Key: "controlStatus", Title: "Control Status", Kind: report.KindString},
+	{Key: "status", Title: "Status", Kind: report.KindString},
+	{Key: "riskReduction", Title: "Risk Reduction", Kind: report.KindPercent},
+	{Key: "effectiveness", Title: "Effectiveness", Kind: report.KindPercent},
+	{Key: "reduction", Title: "Applied Reduction", Kind: report.KindPercent},
+	{Key: "missing", Title: "Missing", Kind: report.KindBool},
+}
+
+// AddRegister adds the framework totals to a report's summary and the
+// "risks", "categories" and "mitigations" sections. Only the given
+// assessments are listed in the risks and mitigations sections; the totals
+// always cover the whole register.
+func AddRegister(r *report.Report, reg *Register, assessments []Assessment) {
+	r.AddSummary("scenarios", reg.Total.Scenarios)
+	r.AddSummary("underControlled", len(reg.UnderControlled()))
+	r.AddSummary("inherentRisk", reg.Total.InherentRisk)
+	r.AddSummary("residualRisk", reg.Total.ResidualRisk)
+	r.AddSummaryKind("riskReduction", reg.Total.Reduction(), report.KindPercent)
+
+	risks := r.AddSection("risks", "Risk Register", ScenarioColumns...)
+	for _, a := range assessments {
+		s := a.Scenario
+		controls := make([]string, 0, len(a.Mitigations))
+		for _, m := range a.Mitigations {
+			controls = append(controls, fmt.Sprintf("%s (-%.0f%%)", m.ControlID, m.Reduction()*100))
+		}
+		risks.AddRow(s.ID, s.Name, s.Category, s.Asset, s.Threat, s.Likelihood, s.Impact, s.Inherent(), a.ResidualRisk, a.Reduction(), a.UnderControlled(), controls)
+	}
+
+	categories := r.AddSection("categories", "Risk by Category", TotalColumns...)
+	for _, t := range reg.Categories {
+		categories.AddRow(t.Name, t.Scenarios, t.InherentRisk, t.ResidualRisk, t.Reduction())
+	}
+
+	mitigations := r.AddSection("mitigations", "Mitigations", MitigationColumns...)
+	for _, a := range assessments {
+		for _, m := range a.Mitigations {
+			mitigations.AddRow(a.Scenario.ID, m.ControlID, m.ControlName, string(m.ControlStatus), m.Status, m.RiskReduction, m.Effectiveness, m.Reduction(), m.Missing)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+// Package risk computes the residual risk of risk scenarios from the risk
+// reduction of their mitigating controls and the controls' measured
+// effectiveness.
+package risk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+)
+
+// RiskScenario is a threat to an asset and the controls that mitigate it.
+// A control may mitigate any number of scenarios. The inherent risk is the
+// risk before any control, on whatever scale the organization uses; when
+// InherentRisk is not set it is Likelihood × Impact. Frequency and Magnitude
+// quantify the scenario for loss simulation: loss events per year and loss
+// per event.
+type RiskScenario struct {
+	ID           string    `yaml:"id"`
+	Name         string    `yaml:"name"`
+	Description  string    `yaml:"description,omitempty"`
+	Category     string    `yaml:"category,omitempty"`
+	Asset        string    `yaml:"asset,omitempty"`
+	Threat       string    `yaml:"threat,omitempty"`
+	Likelihood   float64   `yaml:"likelihood,omitempty"`
+	Impact       float64   `yaml:"impact,omitempty"`
+	InherentRisk float64   `yaml:"inherentRisk,omitempty"`
+	Frequency    *Estimate `yaml:"frequency,omitempty"`
+	Magnitude    *Estimate `yaml:"magnitude,omitempty"`
+	Controls     []string  `yaml:"controls,omitempty"`
+}
+
+// Distributions of an Estimate.
+const (
+	DistributionPERT      = "pert"
+	DistributionLognormal = "lognormal"
+)
+
+// Estimate is an uncertain quantity: a PERT distribution given by its
+// minimum, most likely and maximum values, or a lognormal distribution given
+// by the bounds of its 90% confidence interval.
+type Estimate struct {
+	Distribution string  `yaml:"distribution"`
+	Min          float64 `yaml:"min,omitempty"`
+	Mode         float64 `yaml:"mode,omitempty"`
+	Max          float64 `yaml:"max,omitempty"`
+	Low          float64 `yaml:"low,omitempty"`
+	High         float64 `yaml:"high,omitempty"`
+}
+
+// Validate checks that the estimate describes a valid distribution of
+// non-negative values.
+func (e *Estimate) Validate() error {
+	switch e.Distribution {
+	case DistributionPERT:
+		if e.Min < 0 || e.Min > e.Mode || e.Mode > e.Max {
+			return errors.New("pert estimate needs 0 ≤ min ≤ mode ≤ max")
+		}
+	case DistributionLognormal:
+		if e.Low <= 0 || e.Low >= e.High {
+			return errors.New("lognormal estimate needs 0 < low < high")
+		}
+	default:
+		return fmt.Errorf("unknown distribution %q (want %s or %s)", e.Distribution, DistributionPERT, DistributionLognormal)
+	}
+	return nil
+}
+
+// Quantified reports whether the scenario has both a frequency and a
+// magnitude estimate.
+func (s RiskScenario) Quantified() bool {
+	return s.Frequency != nil && s.Magnitude != nil
+}
+
+// Inherent returns the scenario's inherent risk.
+func (s RiskScenario) Inherent() float64 {
+	if s.InherentRisk != 0 {
+		return s.InherentRisk
+	}
+	return s.Likelihood * s.Impact
+}
+
+// ControlScenarios maps each control ID to the IDs of the scenarios it
+// mitigates, in scenario order.
+func ControlScenarios(scenarios []RiskScenario) map[string][]string {
+	index := make(map[string][]string)
+	for _, s := range scenarios {
+		for _, id := range s.Controls {
+			index[id] = append(index[id], s.ID)
+		}
+	}
+	return index
+}
+
+// scenariosFile is the layout of a risk scenarios file.
+type scenariosFile struct {
+	Scenarios []RiskScenario `yaml:"scenarios"`
+}
+
+// Load reads risk scenarios from a YAML file.
+func Load(path string) ([]RiskScenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	scenarios, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return scenarios, nil
+}
+
+// Parse parses risk scenarios from YAML. Unknown keys are rejected.
+func Parse(data []byte) ([]RiskScenario, error) {
+	var file scenariosFile
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	if err := Validate(file.Scenarios); err != nil {
+		return nil, err
+	}
+	return file.Scenarios, nil
+}
+
+// Validate checks that scenarios have unique IDs and well formed risk.
+func Validate(scenarios []RiskScenario) error {
+	seen := make(map[string]bool, len(scenarios))
+	for _, s := range scenarios {
+		if s.ID == "" {
+			return errors.New("risk scenario without id")
+		}
+		if seen[s.ID] {
+			return fmt.Errorf("duplicate risk scenario %q", s.ID)
+		}
+		seen[s.ID] = true
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("risk scenario %s: %w", s.ID, err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the scenario's risk is well formed.
+func (s RiskScenario) Validate() error {
+	if s.InherentRisk < 0 || s.Likelihood < 0 || s.Impact < 0 {
+		return errors.New("inherent risk, likelihood and impact must not be negative")
+	}
+	if s.Frequency != nil {
+		if err := s.Frequency.Validate(); err != nil {
+			return fmt.Errorf("frequency: %w", err)
+		}
+	}
+	if s.Magnitude != nil {
+		if err := s.Magnitude.Validate(); err != nil {
+			return fmt.Errorf("magnitude: %w", err)
+		}
+	}
+	return nil
+}
+
+// Mitigation is the contribution of one control to a scenario.
+type Mitigation struct {
+	ControlID     string
+	ControlName   string
+	ControlStatus control.ControlStatus
+	// Status is the validation status of the control.
+	Status        string
+	RiskReduction float64
+	Effectiveness float64
+	// Missing is set when the control is not in the framework or has no
+	// validation result; it then reduces no risk.
+	Missing bool
+}
+
+// Reduction is the share of the risk the control removes: its risk
+// reduction scaled by its measured effectiveness.
+func (m Mitigation) Reduction() float64 {
+	if m.Missing {
+		return 0
+	}
+	return clamp(m.RiskReduction * m.Effectiveness)
+}
+
+// Mitigates reports whether the control is implemented and was validated as
+// at least partially effective.
+func (m Mitigation) Mitigates() bool {
+	return !m.Missing && m.ControlStatus != control.StatusNotImplemented && m.Status != "INEFFECTIVE"
+}
+
+// Assessment is the residual risk of a scenario.
+type Assessment struct {
+	Scenario     RiskScenario
+	Mitigations  []Mitigation
+	ResidualRisk float64
+}
+
+// Reduction is the share of the inherent risk removed by the controls.
+func (a Assessment) Reduction() float64 {
+	return reduction(a.Scenario.Inherent(), a.ResidualRisk)
+}
+
+// UnderControlled reports whether none of the scenario's controls mitigate
+// it: every control is ineffective, not implemented or missing, or there are
+// none.
+func (a Assessment) UnderControlled() bool {
+	for _, m := range a.Mitigations {
+		if m.Mitigates() {
+			return false
+		}
+	}
+	return true
+}
+
+// Total is the inherent and residual risk of a group of scenarios.
+type Total struct {
+	Name         string
+	Scenarios    int
+	InherentRisk float64
+	ResidualRisk float64
+}
+
+// Reduction is the share of the inherent risk removed by the controls.
+func (t Total) Reduction() float64 {
+	return reduction(t.InherentRisk, t.ResidualRisk)
+}
+
+// Register is the risk register of a framework: the assessment of each
+// scenario, totals per scenario category and the framework total.
+type Register struct {
+	Framework   string
+	Assessments []Assessment
+	Categories  []Total
+	Total       Total
+}
+
+// UnderControlled returns the assessments of under-controlled scenarios.
+func (reg *Register) UnderControlled() []Assessment {
+	var under []Assessment
+	for _, a := range reg.Assessments {
+		if a.UnderControlled() {
+			under = append(under, a)
+		}
+	}
+	return under
+}
+
+// Assess computes the residual risk of each scenario. Controls mitigating
+// the same scenario act as independent layers of defense, so their
+// reductions combine multiplicatively:
+//
+//	residual = inherent × Π (1 − riskReduction × effectiveness)
+//
+// Scenario risks are summed per category and for the framework.
+func Assess(fw control.ControlFramework, scenarios []RiskScenario, results []control.ControlValidationResult) *Register {
+	controls := make(map[string]control.SecurityControl, len(fw.Controls))
+	for _, c := range fw.Controls {
+		controls[c.ID] = c
+	}
+	measured := make(map[string]control.ControlValidationResult, len(results))
+	for _, r := range results {
+		measured[r.ControlID] = r
+	}
+
+	reg := &Register{Framework: fw.Name, Total: Total{Name: fw.Name}}
+	categories := make(map[string]*Total)
+	for _, s := range scenarios {
+		a := Assessment{Scenario: s, ResidualRisk: s.Inherent()}
+		for _, id := range s.Controls {
+			m := Mitigation{ControlID: id}
+			c, known := controls[id]
+			result, validated := measured[id]
+			m.ControlName = c.Name
+			m.ControlStatus = c.Status
+			if known && validated {
+				m.Status = result.Status
+				m.RiskReduction = c.RiskReduction
+				m.Effectiveness = result.Effectiveness
+			} else {
+				m.Missing = true
+			}
+			a.ResidualRisk *= 1 - m.Reduction()
+			a.Mitigations = append(a.Mitigations, m)
+		}
+		reg.Assessments = append(reg.Assessments, a)
+
+		name := s.Category
+		if name == "" {
+			name = "uncategorized"
+		}
+		total := categories[name]
+		if total == nil {
+			total = &Total{Name: name}
+			categories[name] = total
+		}
+		total.add(a)
+		reg.Total.add(a)
+	}
+
+	for _, total := range categories {
+		reg.Categories = append(reg.Categories, *total)
+	}
+	sort.Slice(reg.Categories, func(i, j int) bool {
+		return reg.Categories[i].Name < reg.Categories[j].Name
+	})
+	return reg
+}
+
+// add adds a scenario's risk to a total.
+func (t *Total) add(a Assessment) {
+	t.Scenarios++
+	t.InherentRisk += a.Scenario.Inherent()
+	t.ResidualRisk += a.ResidualRisk
+}
+
+// reduction returns the share of inherent risk removed, or 0 when there is
+// no inherent risk.
+func reduction(inherent, residual float64) float64 {
+	if inherent <= 0 {
+		return 0
+	}
+	return 1 - residual/inherent
+}
+
+// clamp limits a share to [0, 1].
+func clamp(x float64) float64 {
+	if x < 0 {
+		return 0
+	}
+	if x > 1 {
+		return 1
+	}
+	return x
+}
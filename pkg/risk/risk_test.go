@@ -0,0 +1,118 @@
+package risk
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+var framework = control.ControlFramework{
+	Name: "Test",
+	Controls: []control.SecurityControl{
+		{ID: "mfa", Name: "MFA", RiskReduction: 0.5},
+		{ID: "siem", Name: "SIEM", RiskReduction: 0.4},
+		{ID: "ir", Name: "IR Plan", RiskReduction: 0.2},
+	},
+}
+
+var results = []control.ControlValidationResult{
+	{ControlID: "mfa", Status: "EFFECTIVE", Effectiveness: 1.0},
+	{ControlID: "siem", Status: "PARTIALLY_EFFECTIVE", Effectiveness: 0.5},
+	{ControlID: "ir", Status: "INEFFECTIVE", Effectiveness: 0.0},
+}
+
+func TestAssess(t *testing.T) {
+	scenarios := []RiskScenario{
+		{ID: "r1", Category: "identity", InherentRisk: 100, Controls: []string{"mfa", "siem"}},
+		{ID: "r2", Category: "operations", InherentRisk: 50, Controls: []string{"siem", "ir", "unknown"}},
+		{ID: "r3", InherentRisk: 10},
+	}
+	reg := Assess(framework, scenarios, results)
+
+	// 100 × (1 − 0.5) × (1 − 0.2), not 100 × (1 − 0.7)
+	want := []float64{40, 40, 10}
+	for i, a := range reg.Assessments {
+		if math.Abs(a.ResidualRisk-want[i]) > 1e-9 {
+			t.Errorf("%s residual = %v, want %v", a.Scenario.ID, a.ResidualRisk, want[i])
+		}
+	}
+	if m := reg.Assessments[1].Mitigations[2]; !m.Missing || m.Reduction() != 0 {
+		t.Errorf("unknown control = %+v", m)
+	}
+	if math.Abs(reg.Assessments[0].Reduction()-0.6) > 1e-9 {
+		t.Errorf("r1 reduction = %v", reg.Assessments[0].Reduction())
+	}
+
+	if len(reg.Categories) != 3 || reg.Categories[0].Name != "identity" || reg.Categories[2].Name != "uncategorized" {
+		t.Fatalf("categories = %+v", reg.Categories)
+	}
+	if reg.Total.Scenarios != 3 || reg.Total.InherentRisk != 160 || math.Abs(reg.Total.ResidualRisk-90) > 1e-9 {
+		t.Errorf("total = %+v", reg.Total)
+	}
+
+	// r2 keeps the partially effective SIEM; r3 has no controls at all
+	if reg.Assessments[0].UnderControlled() || reg.Assessments[1].UnderControlled() || !reg.Assessments[2].UnderControlled() {
+		t.Error("unexpected under-controlled scenarios")
+	}
+
+	r := report.New("risk", "Risk Register")
+	AddRegister(r, reg, reg.Assessments)
+	if r.Summary.Get("scenarios") != 3 || len(r.GetSection("risks").Rows) != 3 || len(r.GetSection("mitigations").Rows) != 5 {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+func TestUnderControlled(t *testing.T) {
+	fw := framework
+	fw.Controls = append([]control.SecurityControl(nil), framework.Controls...)
+	fw.Controls[1].Status = control.StatusNotImplemented
+
+	scenarios := []RiskScenario{
+		{ID: "r1", Likelihood: 5, Impact: 4, Controls: []string{"siem", "ir", "unknown"}},
+		{ID: "r2", Likelihood: 5, Impact: 4, Controls: []string{"mfa", "ir"}},
+	}
+	reg := Assess(fw, scenarios, results)
+
+	under := reg.UnderControlled()
+	if len(under) != 1 || under[0].Scenario.ID != "r1" {
+		t.Fatalf("under-controlled = %+v", under)
+	}
+	if reg.Total.InherentRisk != 40 {
+		t.Errorf("inherent risk = %v, want likelihood × impact", reg.Total.InherentRisk)
+	}
+	if got := ControlScenarios(scenarios)["ir"]; len(got) != 2 || got[0] != "r1" || got[1] != "r2" {
+		t.Errorf("scenarios of ir = %v", got)
+	}
+}
+
+func TestParse(t *testing.T) {
+	scenarios, err := Parse([]byte(`
+scenarios:
+  - id: r1
+    name: Account takeover
+    inherentRisk: 80
+    controls: [mfa]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) != 1 || scenarios[0].InherentRisk != 80 || scenarios[0].Controls[0] != "mfa" {
+		t.Errorf("scenarios = %+v", scenarios)
+	}
+
+	for name, data := range map[string]string{
+		"duplicate":            "scenarios: [{id: a}, {id: a}]",
+		"missing id":           "scenarios: [{name: a}]",
+		"negative risk":        "scenarios: [{id: a, inherentRisk: -1}]",
+		"negative impact":      "scenarios: [{id: a, likelihood: 2, impact: -1}]",
+		"unknown key":          "scenarios: [{id: a, risk: 1}]",
+		"bad estimate":         "scenarios: [{id: a, frequency: {distribution: pert, min: 3, mode: 2, max: 4}}]",
+		"unknown distribution": "scenarios: [{id: a, magnitude: {distribution: uniform}}]",
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
@@ -0,0 +1,634 @@
+package rules
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Expr is a compiled rule expression.
+type Expr struct {
+	source string
+	root   node
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.source
+}
+
+// Compile parses an expression and checks that every identifier is a field
+// of the schema and every function exists with a valid number of arguments.
+func Compile(source string, schema Schema) (*Expr, error) {
+	p := &parser{source: source}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok, "unexpected %q", tok.text)
+	}
+	if err := check(root, schema); err != nil {
+		return nil, err
+	}
+	return &Expr{source: source, root: root}, nil
+}
+
+// Eval evaluates the expression against an environment.
+func (e *Expr) Eval(env Env, now time.Time) (interface{}, error) {
+	return e.root.eval(&context{env: env, now: now})
+}
+
+// EvalBool evaluates an expression that must produce a boolean.
+func (e *Expr) EvalBool(env Env, now time.Time) (bool, error) {
+	v, err := e.Eval(env, now)
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression is %s, not bool", typeName(v))
+	}
+	return b, nil
+}
+
+// context holds the state of an evaluation.
+type context struct {
+	env Env
+	now time.Time
+}
+
+// Lexer
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+type parser struct {
+	source string
+	tokens []token
+	next   int
+}
+
+// operators lists the operator tokens, longest first.
+var operators = []string{"&&", "||", "==", "!=", "<=", ">=", "<", ">", "!", "+", "-", "*", "/", "(", ")", "[", "]", ","}
+
+func (p *parser) lex() error {
+	s := p.source
+	for i := 0; i < len(s); {
+		c := rune(s[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case c == '"' || c == '\'':
+			j := i + 1
+			for j < len(s) && rune(s[j]) != c {
+				if s[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(s) {
+				return fmt.Errorf("position %d: unterminated string", i+1)
+			}
+			text := s[i+1 : j]
+			if c == '"' {
+				unquoted, err := strconv.Unquote(s[i : j+1])
+				if err != nil {
+					return fmt.Errorf("position %d: invalid string: %v", i+1, err)
+				}
+				text = unquoted
+			}
+			p.tokens = append(p.tokens, token{kind: tokString, text: text, pos: i})
+			i = j + 1
+		case c >= '0' && c <= '9' || c == '.' && i+1 < len(s) && s[i+1] >= '0' && s[i+1] <= '9':
+			j := i
+			for j < len(s) && (s[j] >= '0' && s[j] <= '9' || s[j] == '.') {
+				j++
+			}
+			p.tokens = append(p.tokens, token{kind: tokNumber, text: s[i:j], pos: i})
+			i = j
+		case c == '_' || unicode.IsLetter(c):
+			j := i
+			for j < len(s) && (s[j] == '_' || unicode.IsLetter(rune(s[j])) || unicode.IsDigit(rune(s[j]))) {
+				j++
+			}
+			p.tokens = append(p.tokens, token{kind: tokIdent, text: s[i:j], pos: i})
+			i = j
+		default:
+			op := ""
+			for _, candidate := range operators {
+				if strings.HasPrefix(s[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return fmt.Errorf("position %d: unexpected character %q", i+1, c)
+			}
+			p.tokens = append(p.tokens, token{kind: tokOp, text: op, pos: i})
+			i += len(op)
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokEOF, pos: len(s)})
+	return nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) advance() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is one of the given operators or
+// keywords.
+func (p *parser) accept(texts ...string) (token, bool) {
+	tok := p.peek()
+	if tok.kind != tokOp && tok.kind != tokIdent {
+		return tok, false
+	}
+	for _, text := range texts {
+		if tok.text == text {
+			return p.advance(), true
+		}
+	}
+	return tok, false
+}
+
+func (p *parser) expect(text string) error {
+	if _, ok := p.accept(text); !ok {
+		tok := p.peek()
+		if tok.kind == tokEOF {
+			return p.errorf(tok, "expected %q at end of expression", text)
+		}
+		return p.errorf(tok, "expected %q, found %q", text, tok.text)
+	}
+	return nil
+}
+
+func (p *parser) errorf(tok token, format string, args ...interface{}) error {
+	return fmt.Errorf("position %d: %s", tok.pos+1, fmt.Sprintf(format, args...))
+}
+
+// Parser, from lowest to highest precedence
+
+func (p *parser) parseExpr() (node, error) {
+	return p.parseBinary(0)
+}
+
+// precedence lists the binary operators by increasing precedence.
+var precedence = [][]string{
+	{"||"},
+	{"&&"},
+	{"==", "!="},
+	{"<", "<=", ">", ">=", "in"},
+	{"+", "-"},
+	{"*", "/"},
+}
+
+func (p *parser) parseBinary(level int) (node, error) {
+	if level == len(precedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		tok, ok := p.accept(precedence[level]...)
+		if !ok {
+			return left, nil
+		}
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryNode{op: tok.text, left: left, right: right, pos: tok.pos}
+	}
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if tok, ok := p.accept("!", "-"); ok {
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryNode{op: tok.text, x: x, pos: tok.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.advance()
+	switch tok.kind {
+	case tokNumber:
+		f, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, p.errorf(tok, "invalid number %q", tok.text)
+		}
+		return &literalNode{value: f}, nil
+	case tokString:
+		return &literalNode{value: tok.text}, nil
+	case tokIdent:
+		switch tok.text {
+		case "true":
+			return &literalNode{value: true}, nil
+		case "false":
+			return &literalNode{value: false}, nil
+		case "null":
+			return &literalNode{value: nil}, nil
+		case "in":
+			return nil, p.errorf(tok, "unexpected %q", tok.text)
+		}
+		if _, ok := p.accept("("); ok {
+			args, err := p.parseList(")")
+			if err != nil {
+				return nil, err
+			}
+			return &callNode{name: tok.text, args: args, pos: tok.pos}, nil
+		}
+		return &identNode{name: tok.text, pos: tok.pos}, nil
+	case tokOp:
+		switch tok.text {
+		case "(":
+			x, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			return x, p.expect(")")
+		case "[":
+			items, err := p.parseList("]")
+			if err != nil {
+				return nil, err
+			}
+			return &listNode{items: items}, nil
+		}
+	case tokEOF:
+		return nil, p.errorf(tok, "unexpected end of expression")
+	}
+	return nil, p.errorf(tok, "unexpected %q", tok.text)
+}
+
+// parseList parses comma-separated expressions up to a closing token.
+func (p *parser) parseList(closing string) ([]node, error) {
+	var items []node
+	if _, ok := p.accept(closing); ok {
+		return items, nil
+	}
+	for {
+		item, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+		if _, ok := p.accept(closing); ok {
+			return items, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Syntax tree
+
+type node interface {
+	eval(ctx *context) (interface{}, error)
+}
+
+type literalNode struct {
+	value interface{}
+}
+
+func (n *literalNode) eval(ctx *context) (interface{}, error) {
+	return n.value, nil
+}
+
+type identNode struct {
+	name string
+	pos  int
+}
+
+func (n *identNode) eval(ctx *context) (interface{}, error) {
+	return ctx.env[n.name], nil
+}
+
+type listNode struct {
+	items []node
+}
+
+func (n *listNode) eval(ctx *context) (interface{}, error) {
+	list := make([]interface{}, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+type unaryNode struct {
+	op  string
+	x   node
+	pos int
+}
+
+func (n *unaryNode) eval(ctx *context) (interface{}, error) {
+	v, err := n.x.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "!":
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("cannot negate %s", typeName(v))
+		}
+		return !b, nil
+	default:
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("cannot negate %s", typeName(v))
+		}
+		return -f, nil
+	}
+}
+
+type binaryNode struct {
+	op          string
+	left, right node
+	pos         int
+}
+
+func (n *binaryNode) eval(ctx *context) (interface{}, error) {
+	left, err := n.left.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Logical operators short-circuit
+	if n.op == "&&" || n.op == "||" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s needs bool operands, not %s", n.op, typeName(left))
+		}
+		if (n.op == "&&") != l {
+			return l, nil
+		}
+		right, err := n.right.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s needs bool operands, not %s", n.op, typeName(right))
+		}
+		return r, nil
+	}
+
+	right, err := n.right.eval(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==", "!=":
+		eq, err := equal(left, right)
+		if err != nil {
+			return nil, err
+		}
+		return eq == (n.op == "=="), nil
+	case "<", "<=", ">", ">=":
+		c, err := compare(left, right)
+		if err != nil {
+			return nil, err
+		}
+		switch n.op {
+		case "<":
+			return c < 0, nil
+		case "<=":
+			return c <= 0, nil
+		case ">":
+			return c > 0, nil
+		default:
+			return c >= 0, nil
+		}
+	case "in":
+		switch r := right.(type) {
+		case []interface{}:
+			for _, item := range r {
+				if eq, err := equal(left, item); err == nil && eq {
+					return true, nil
+				}
+			}
+			return false, nil
+		case string:
+			l, ok := left.(string)
+			if !ok {
+				return nil, fmt.Errorf("cannot look for %s in string", typeName(left))
+			}
+			return strings.Contains(r, l), nil
+		}
+		return nil, fmt.Errorf("in needs a list or string, not %s", typeName(right))
+	case "+":
+		if l, ok := left.(string); ok {
+			if r, ok := right.(string); ok {
+				return l + r, nil
+			}
+		}
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+	if !lok || !rok {
+		return nil, fmt.Errorf("cannot apply %s to %s and %s", n.op, typeName(left), typeName(right))
+	}
+	switch n.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	default:
+		if r == 0 {
+			return nil, fmt.Errorf("division by zero")
+		}
+		return l / r, nil
+	}
+}
+
+type callNode struct {
+	name string
+	args []node
+	pos  int
+	fn   *function
+}
+
+func (n *callNode) eval(ctx *context) (interface{}, error) {
+	args := make([]interface{}, len(n.args))
+	for i, arg := range n.args {
+		v, err := arg.eval(ctx)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	// Functions called without their subject return a predicate
+	if n.fn.predicate && len(args) == n.fn.min-1 {
+		return predicate(func(x interface{}) (bool, error) {
+			v, err := n.fn.call(ctx, append([]interface{}{x}, args...))
+			if err != nil {
+				return false, err
+			}
+			b, _ := v.(bool)
+			return b, nil
+		}), nil
+	}
+
+	v, err := n.fn.call(ctx, args)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", n.name, err)
+	}
+	return v, nil
+}
+
+// check resolves identifiers and functions.
+func check(n node, schema Schema) error {
+	switch n := n.(type) {
+	case *identNode:
+		if _, ok := schema[n.name]; !ok {
+			return fmt.Errorf("position %d: unknown field %q", n.pos+1, n.name)
+		}
+	case *listNode:
+		for _, item := range n.items {
+			if err := check(item, schema); err != nil {
+				return err
+			}
+		}
+	case *unaryNode:
+		return check(n.x, schema)
+	case *binaryNode:
+		if err := check(n.left, schema); err != nil {
+			return err
+		}
+		return check(n.right, schema)
+	case *callNode:
+		fn, ok := functions[n.name]
+		if !ok {
+			return fmt.Errorf("position %d: unknown function %q", n.pos+1, n.name)
+		}
+		min := fn.min
+		if fn.predicate {
+			min--
+		}
+		if len(n.args) < min || len(n.args) > fn.max {
+			return fmt.Errorf("position %d: %s takes %s", n.pos+1, n.name, fn.arity())
+		}
+		n.fn = fn
+		for _, arg := range n.args {
+			if err := check(arg, schema); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// equal compares two values for equality. Any value may be compared with
+// null.
+func equal(a, b interface{}) (bool, error) {
+	if a == nil || b == nil {
+		return a == nil && b == nil, nil
+	}
+	switch a := a.(type) {
+	case string:
+		if b, ok := b.(string); ok {
+			return a == b, nil
+		}
+	case float64:
+		if b, ok := b.(float64); ok {
+			return a == b, nil
+		}
+	case bool:
+		if b, ok := b.(bool); ok {
+			return a == b, nil
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Equal(b), nil
+		}
+	}
+	return false, fmt.Errorf("cannot compare %s and %s", typeName(a), typeName(b))
+}
+
+// compare orders two numbers, strings or times.
+func compare(a, b interface{}) (int, error) {
+	switch a := a.(type) {
+	case float64:
+		if b, ok := b.(float64); ok {
+			switch {
+			case a < b:
+				return -1, nil
+			case a > b:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	case string:
+		if b, ok := b.(string); ok {
+			return strings.Compare(a, b), nil
+		}
+	case time.Time:
+		if b, ok := b.(time.Time); ok {
+			return a.Compare(b), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot order %s and %s", typeName(a), typeName(b))
+}
+
+// typeName names the type of a value in error messages.
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "list"
+	case time.Time:
+		return "time"
+	case predicate:
+		return "predicate"
+	}
+	return fmt.Sprintf("%T", v)
+}
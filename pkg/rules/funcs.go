@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// predicate is a function value passed to any, all and count.
+type predicate func(x interface{}) (bool, error)
+
+// function is a built-in function. Functions marked predicate may be called
+// without their first argument to produce a predicate, as in
+// any(references, startsWith("NIST")).
+type function struct {
+	min, max  int
+	predicate bool
+	doc       string
+	call      func(ctx *context, args []interface{}) (interface{}, error)
+}
+
+func (f *function) arity() string {
+	if f.min == f.max {
+		return fmt.Sprintf("%d argument(s)", f.min)
+	}
+	return fmt.Sprintf("%d to %d arguments", f.min, f.max)
+}
+
+// functions are the functions available to expressions.
+var functions = map[string]*function{
+	"len": {min: 1, max: 1, doc: "len(x) is the length of a string or list", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case string:
+			return float64(len(v)), nil
+		case []interface{}:
+			return float64(len(v)), nil
+		case nil:
+			return 0.0, nil
+		}
+		return nil, fmt.Errorf("cannot take length of %s", typeName(args[0]))
+	}},
+	"any": {min: 2, max: 2, doc: "any(list, pred) reports whether pred holds for some item", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		n, err := countMatches(args[0], args[1])
+		return n > 0, err
+	}},
+	"all": {min: 2, max: 2, doc: "all(list, pred) reports whether pred holds for every item", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		n, err := countMatches(args[0], args[1])
+		list, _ := args[0].([]interface{})
+		return n == len(list), err
+	}},
+	"count": {min: 2, max: 2, doc: "count(list, pred) is the number of items pred holds for", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		n, err := countMatches(args[0], args[1])
+		return float64(n), err
+	}},
+	"startsWith": {min: 2, max: 2, predicate: true, doc: "startsWith(s, prefix) or startsWith(prefix)", call: stringTest(strings.HasPrefix)},
+	"endsWith":   {min: 2, max: 2, predicate: true, doc: "endsWith(s, suffix) or endsWith(suffix)", call: stringTest(strings.HasSuffix)},
+	"contains":   {min: 2, max: 2, predicate: true, doc: "contains(s, substr) or contains(substr)", call: stringTest(strings.Contains)},
+	"matches": {min: 2, max: 2, predicate: true, doc: "matches(s, regexp) or matches(regexp)", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		s, pattern, err := strings2(args)
+		if err != nil {
+			return nil, err
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		return re.MatchString(s), nil
+	}},
+	"lower": {min: 1, max: 1, doc: "lower(s) is s in lower case", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, not %s", typeName(args[0]))
+		}
+		return strings.ToLower(s), nil
+	}},
+	"isZero": {min: 1, max: 1, doc: "isZero(x) reports whether x is empty, zero, false, null or an unset time", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		switch v := args[0].(type) {
+		case nil:
+			return true, nil
+		case string:
+			return v == "", nil
+		case float64:
+			return v == 0, nil
+		case bool:
+			return !v, nil
+		case []interface{}:
+			return len(v) == 0, nil
+		case time.Time:
+			return v.IsZero(), nil
+		}
+		return false, nil
+	}},
+	"now": {min: 0, max: 0, doc: "now() is the evaluation time", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		return ctx.now, nil
+	}},
+	"daysAgo": {min: 1, max: 1, doc: "daysAgo(n) is the time n days before now", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		n, ok := args[0].(float64)
+		if !ok {
+			return nil, fmt.Errorf("expected number, not %s", typeName(args[0]))
+		}
+		return ctx.now.Add(-time.Duration(n * float64(24*time.Hour))), nil
+	}},
+	"monthsAgo": {min: 1, max: 1, doc: "monthsAgo(n) is the time n whole months before now", call: func(ctx *context, args []interface{}) (interface{}, error) {
+		n, ok := args[0].(float64)
+		if !ok || n != float64(int(n)) {
+			return nil, fmt.Errorf("expected whole number of months, not %v", args[0])
+		}
+		return ctx.now.AddDate(0, -int(n), 0), nil
+	}},
+}
+
+// Functions returns the documentation of each function, sorted by name.
+func Functions() []string {
+	docs := make([]string, 0, len(functions))
+	for _, fn := range functions {
+		docs = append(docs, fn.doc)
+	}
+	sort.Strings(docs)
+	return docs
+}
+
+// countMatches counts the items of a list a predicate holds for.
+func countMatches(list, pred interface{}) (int, error) {
+	items, ok := list.([]interface{})
+	if !ok && list != nil {
+		return 0, fmt.Errorf("expected list, not %s", typeName(list))
+	}
+	p, ok := pred.(predicate)
+	if !ok {
+		return 0, fmt.Errorf("expected predicate such as startsWith(\"x\"), not %s", typeName(pred))
+	}
+	n := 0
+	for _, item := range items {
+		ok, err := p(item)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+// stringTest adapts a string comparison to a function.
+func stringTest(test func(s, t string) bool) func(ctx *context, args []interface{}) (interface{}, error) {
+	return func(ctx *context, args []interface{}) (interface{}, error) {
+		s, t, err := strings2(args)
+		if err != nil {
+			return nil, err
+		}
+		return test(s, t), nil
+	}
+}
+
+// strings2 returns two string arguments.
+func strings2(args []interface{}) (string, string, error) {
+	s, ok := args[0].(string)
+	if !ok {
+		return "", "", fmt.Errorf("expected string, not %s", typeName(args[0]))
+	}
+	t, ok := args[1].(string)
+	if !ok {
+		return "", "", fmt.Errorf("expected string, not %s", typeName(args[1]))
+	}
+	return s, t, nil
+}
@@ -0,0 +1,343 @@
+// Package rules evaluates declarative rules written in a small expression
+// language. Each rule has a condition over a record's fields and the issue
+// it produces, along with unit tests of the condition.
+package rules
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Kind is the type of a field.
+type Kind string
+
+const (
+	KindString Kind = "string"
+	KindNumber Kind = "number"
+	KindBool   Kind = "bool"
+	KindList   Kind = "list"
+	KindTime   Kind = "time"
+)
+
+// Schema maps the fields available to expressions to their kinds.
+type Schema map[string]Kind
+
+// Env holds the field values an expression is evaluated against. Numbers
+// are float64, lists are []interface{} and times are time.Time.
+type Env map[string]interface{}
+
+// Fields returns the schema's field names, sorted.
+func (s Schema) Fields() []string {
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Env converts loosely typed values, such as those decoded from YAML, to an
+// environment. Fields that are not set have their zero value.
+func (s Schema) Env(values map[string]interface{}) (Env, error) {
+	env := make(Env, len(s))
+	for name, kind := range s {
+		env[name] = zero(kind)
+	}
+	for name, value := range values {
+		kind, ok := s[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown field %q", name)
+		}
+		v, err := convert(kind, value)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", name, err)
+		}
+		env[name] = v
+	}
+	return env, nil
+}
+
+// zero returns the zero value of a kind.
+func zero(kind Kind) interface{} {
+	switch kind {
+	case KindNumber:
+		return 0.0
+	case KindBool:
+		return false
+	case KindList:
+		return []interface{}{}
+	case KindTime:
+		return time.Time{}
+	}
+	return ""
+}
+
+// convert converts a value to a kind.
+func convert(kind Kind, value interface{}) (interface{}, error) {
+	switch kind {
+	case KindString:
+		if s, ok := value.(string); ok {
+			return s, nil
+		}
+	case KindNumber:
+		switch v := value.(type) {
+		case int:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case KindBool:
+		if b, ok := value.(bool); ok {
+			return b, nil
+		}
+	case KindList:
+		if items, ok := value.([]interface{}); ok {
+			list := make([]interface{}, len(items))
+			for i, item := range items {
+				list[i] = fmt.Sprint(item)
+			}
+			return list, nil
+		}
+	case KindTime:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			return parseTime(v)
+		}
+	}
+	return nil, fmt.Errorf("expected %s, got %v", kind, value)
+}
+
+// parseTime parses an RFC 3339 time or a date.
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
+// Rule is a condition over a record and the issue it produces.
+type Rule struct {
+	ID          string    `yaml:"id"`
+	Description string    `yaml:"description,omitempty"`
+	When        string    `yaml:"when,omitempty"`
+	Disabled    bool      `yaml:"disabled,omitempty"`
+	Issue       IssueSpec `yaml:"issue,omitempty"`
+	Tests       []Test    `yaml:"tests,omitempty"`
+
+	expr *Expr
+}
+
+// IssueSpec describes the issue a rule produces.
+type IssueSpec struct {
+	Code        string   `yaml:"code"`
+	Severity    string   `yaml:"severity"`
+	Field       string   `yaml:"field,omitempty"`
+	Message     string   `yaml:"message"`
+	Remediation string   `yaml:"remediation,omitempty"`
+	References  []string `yaml:"references,omitempty"`
+}
+
+// Test is a unit test of a rule: the rule must fire for the record, or
+// not, at the given time.
+type Test struct {
+	Name   string                 `yaml:"name"`
+	Now    string                 `yaml:"now,omitempty"`
+	Record map[string]interface{} `yaml:"record"`
+	Fires  bool                   `yaml:"fires"`
+}
+
+// Expr returns the compiled condition of the rule.
+func (r *Rule) Expr() *Expr {
+	return r.expr
+}
+
+// Set is an ordered set of rules sharing a schema.
+type Set struct {
+	Rules  []Rule
+	schema Schema
+}
+
+// fileSpec is the layout of a rules file.
+type fileSpec struct {
+	Rules []Rule `yaml:"rules"`
+}
+
+// Parse parses and compiles a rules file. Disabled rules only need an ID.
+func Parse(name string, data []byte, schema Schema) (*Set, error) {
+	var spec fileSpec
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&spec); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	set := &Set{Rules: make([]Rule, 0, len(spec.Rules)), schema: schema}
+	seen := make(map[string]bool)
+	for _, rule := range spec.Rules {
+		if rule.ID == "" {
+			return nil, fmt.Errorf("%s: rule without id", name)
+		}
+		if seen[rule.ID] {
+			return nil, fmt.Errorf("%s: duplicate rule %q", name, rule.ID)
+		}
+		seen[rule.ID] = true
+
+		if !rule.Disabled {
+			if err := rule.compile(schema); err != nil {
+				return nil, fmt.Errorf("%s: rule %s: %w", name, rule.ID, err)
+			}
+		}
+		set.Rules = append(set.Rules, rule)
+	}
+	return set, nil
+}
+
+// Load loads and merges rules files in order.
+func Load(schema Schema, paths ...string) (*Set, error) {
+	set := &Set{schema: schema}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		file, err := Parse(path, data, schema)
+		if err != nil {
+			return nil, err
+		}
+		set = set.Merge(file)
+	}
+	return set, nil
+}
+
+// compile checks and compiles a rule.
+func (r *Rule) compile(schema Schema) error {
+	var missing []string
+	for field, value := range map[string]string{"when": r.When, "issue.code": r.Issue.Code, "issue.severity": r.Issue.Severity, "issue.message": r.Issue.Message} {
+		if value == "" {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+
+	expr, err := Compile(r.When, schema)
+	if err != nil {
+		return fmt.Errorf("when: %w", err)
+	}
+	r.expr = expr
+	return nil
+}
+
+// Merge returns a set with the rules of other added. Rules with the ID of
+// an existing rule replace it in place, so a disabled rule turns it off.
+func (s *Set) Merge(other *Set) *Set {
+	merged := &Set{Rules: append([]Rule(nil), s.Rules...), schema: s.schema}
+	for _, rule := range other.Rules {
+		if i := merged.index(rule.ID); i >= 0 {
+			merged.Rules[i] = rule
+		} else {
+			merged.Rules = append(merged.Rules, rule)
+		}
+	}
+	return merged
+}
+
+// index returns the position of a rule, or -1.
+func (s *Set) index(id string) int {
+	for i := range s.Rules {
+		if s.Rules[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Get returns the rule with the given ID.
+func (s *Set) Get(id string) *Rule {
+	if i := s.index(id); i >= 0 {
+		return &s.Rules[i]
+	}
+	return nil
+}
+
+// Active returns the rules that are not disabled.
+func (s *Set) Active() []Rule {
+	var active []Rule
+	for _, rule := range s.Rules {
+		if !rule.Disabled {
+			active = append(active, rule)
+		}
+	}
+	return active
+}
+
+// Match is a rule that fired for a record, or failed to evaluate.
+type Match struct {
+	Rule Rule
+	Err  error
+}
+
+// Evaluate evaluates every active rule against an environment and returns
+// the rules that fired and those that failed, in rule order.
+func (s *Set) Evaluate(env Env, now time.Time) []Match {
+	var matches []Match
+	for _, rule := range s.Active() {
+		fired, err := rule.expr.EvalBool(env, now)
+		if err != nil || fired {
+			matches = append(matches, Match{Rule: rule, Err: err})
+		}
+	}
+	return matches
+}
+
+// TestResult is the result of a rule test.
+type TestResult struct {
+	RuleID string
+	Test   string
+	Passed bool
+	Fired  bool
+	Err    error
+}
+
+// RunTests runs the tests of every active rule. Tests without a time are
+// evaluated at now.
+func (s *Set) RunTests(now time.Time) []TestResult {
+	var results []TestResult
+	for _, rule := range s.Active() {
+		for _, test := range rule.Tests {
+			result := TestResult{RuleID: rule.ID, Test: test.Name}
+			result.Fired, result.Err = rule.runTest(test, s.schema, now)
+			result.Passed = result.Err == nil && result.Fired == test.Fires
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+// runTest evaluates a rule against a test record.
+func (r *Rule) runTest(test Test, schema Schema, now time.Time) (bool, error) {
+	if test.Now != "" {
+		t, err := parseTime(test.Now)
+		if err != nil {
+			return false, fmt.Errorf("now: %w", err)
+		}
+		now = t
+	}
+	env, err := schema.Env(test.Record)
+	if err != nil {
+		return false, err
+	}
+	return r.expr.EvalBool(env, now)
+}
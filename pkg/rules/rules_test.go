@@ -0,0 +1,169 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var schema = Schema{
+	"name":       KindString,
+	"category":   KindString,
+	"score":      KindNumber,
+	"active":     KindBool,
+	"tags":       KindList,
+	"reviewedAt": KindTime,
+}
+
+func TestEval(t *testing.T) {
+	now := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	env, err := schema.Env(map[string]interface{}{
+		"name":       "Monitoring",
+		"category":   "detective",
+		"score":      2,
+		"active":     true,
+		"tags":       []interface{}{"NIST-AU-6", "SOC2"},
+		"reviewedAt": "2024-01-15",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		expr string
+		want bool
+	}{
+		{`category == "detective" && len(tags) < 3`, true},
+		{`category != "detective" || score > 5`, false},
+		{`!any(tags, startsWith("ISO"))`, true},
+		{`all(tags, matches("^[A-Z]"))`, true},
+		{`count(tags, contains("-")) == 1`, true},
+		{`"SOC2" in tags && "ISO" in tags`, false},
+		{`"Mon" in name`, true},
+		{`category in ["preventive", "detective"]`, true},
+		{`score * 2 + 1 >= 5 && -score < 0`, true},
+		{`reviewedAt < monthsAgo(3) && reviewedAt > daysAgo(365)`, true},
+		{`isZero(reviewedAt) || active == false`, false},
+		{`lower(name) == 'monitoring'`, true},
+		{`startsWith(name, "Moni") && endsWith(name, "ring")`, true},
+	}
+	for _, tt := range tests {
+		expr, err := Compile(tt.expr, schema)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+			continue
+		}
+		got, err := expr.EvalBool(env, now)
+		if err != nil {
+			t.Errorf("%s: %v", tt.expr, err)
+		} else if got != tt.want {
+			t.Errorf("%s = %t, want %t", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for expr, want := range map[string]string{
+		`owner == ""`:              "unknown field",
+		`len(tags, 2)`:             "argument",
+		`missing(tags)`:            "unknown function",
+		`name == "x`:               "",
+		`(score > 1`:               "",
+		`score >`:                  "",
+		`category == "a" category`: "",
+	} {
+		_, err := Compile(expr, schema)
+		if err == nil {
+			t.Errorf("%s: expected error", expr)
+		} else if !strings.Contains(err.Error(), want) {
+			t.Errorf("%s: error %q does not mention %q", expr, err, want)
+		}
+	}
+}
+
+func TestEvalTypeErrors(t *testing.T) {
+	env, _ := schema.Env(nil)
+	for _, source := range []string{`len(score) > 1`, `any(tags, lower("X"))`, `score`, `name < 1`} {
+		expr, err := Compile(source, schema)
+		if err != nil {
+			t.Fatalf("%s: %v", source, err)
+		}
+		if _, err := expr.EvalBool(env, time.Now()); err == nil {
+			t.Errorf("%s: expected error", source)
+		}
+	}
+}
+
+const file = `
+rules:
+  - id: thin-tags
+    description: Too few tags
+    when: len(tags) < 2
+    issue: {code: X-TAG-001, severity: medium, message: Too few tags}
+    tests:
+      - name: one tag
+        record: {tags: [a]}
+        fires: true
+      - name: two tags
+        record: {tags: [a, b]}
+        fires: true
+  - id: stale
+    when: reviewedAt < monthsAgo(6)
+    issue: {code: X-REV-001, severity: low, message: Stale review}
+    tests:
+      - name: old review
+        now: "2024-06-30"
+        record: {reviewedAt: "2023-06-30"}
+        fires: true
+`
+
+func TestSet(t *testing.T) {
+	set, err := Parse("file", []byte(file), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Active()) != 2 || set.Get("stale").Expr() == nil {
+		t.Fatalf("set = %+v", set)
+	}
+
+	results := set.RunTests(time.Now())
+	if len(results) != 3 {
+		t.Fatalf("got %d results", len(results))
+	}
+	for _, r := range results {
+		if r.Passed == (r.Test == "two tags") {
+			t.Errorf("%s: passed %t, fired %t, err %v", r.Test, r.Passed, r.Fired, r.Err)
+		}
+	}
+
+	env, _ := schema.Env(map[string]interface{}{"tags": []interface{}{"a"}, "reviewedAt": time.Now()})
+	matches := set.Evaluate(env, time.Now())
+	if len(matches) != 1 || matches[0].Rule.ID != "thin-tags" {
+		t.Errorf("matches = %+v", matches)
+	}
+
+	override, err := Parse("override", []byte("rules:\n  - id: thin-tags\n    disabled: true\n"), schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	merged := set.Merge(override)
+	if len(merged.Rules) != 2 || len(merged.Active()) != 1 || merged.Rules[0].ID != "thin-tags" {
+		t.Errorf("merged = %+v", merged.Rules)
+	}
+	if len(set.Active()) != 2 {
+		t.Error("merge must not modify the original set")
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"duplicate":   "rules:\n  - {id: a, when: active, issue: {code: A, severity: low, message: m}}\n  - {id: a, when: active, issue: {code: A, severity: low, message: m}}\n",
+		"missing":     "rules:\n  - {id: a, when: active}\n",
+		"unknown key": "rules:\n  - {id: a, if: active}\n",
+		"bad expr":    "rules:\n  - {id: a, when: 'active &&', issue: {code: A, severity: low, message: m}}\n",
+	} {
+		if _, err := Parse(name, []byte(data), schema); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
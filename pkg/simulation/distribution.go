@@ -0,0 +1,129 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/hallucinaut/securitycontrol/pkg/risk"
+)
+
+// z90 is the standard normal quantile of 0.95, the half-width of a 90%
+// confidence interval in standard deviations.
+const z90 = 1.6448536269514722
+
+// pertLambda is the weight of the most likely value of a PERT distribution.
+const pertLambda = 4
+
+// Distribution is a probability distribution that can be sampled.
+type Distribution interface {
+	// Sample draws a value using r.
+	Sample(r *rand.Rand) float64
+	// Mean returns the expected value.
+	Mean() float64
+}
+
+// NewDistribution returns the distribution described by an estimate.
+func NewDistribution(e risk.Estimate) (Distribution, error) {
+	if err := e.Validate(); err != nil {
+		return nil, err
+	}
+	switch e.Distribution {
+	case risk.DistributionPERT:
+		return NewPERT(e.Min, e.Mode, e.Max), nil
+	case risk.DistributionLognormal:
+		return NewLognormal(e.Low, e.High), nil
+	}
+	return nil, fmt.Errorf("unknown distribution %q", e.Distribution)
+}
+
+// PERT is a beta distribution scaled to [Min, Max] whose peak is at Mode.
+type PERT struct {
+	Min, Mode, Max float64
+	alpha, beta    float64
+}
+
+// NewPERT returns a PERT distribution. Min, Mode and Max must be ordered.
+func NewPERT(min, mode, max float64) *PERT {
+	d := &PERT{Min: min, Mode: mode, Max: max, alpha: 1, beta: 1}
+	if max > min {
+		d.alpha = 1 + pertLambda*(mode-min)/(max-min)
+		d.beta = 1 + pertLambda*(max-mode)/(max-min)
+	}
+	return d
+}
+
+func (d *PERT) Sample(r *rand.Rand) float64 {
+	if d.Max == d.Min {
+		return d.Min
+	}
+	x := gamma(r, d.alpha)
+	y := gamma(r, d.beta)
+	return d.Min + (d.Max-d.Min)*x/(x+y)
+}
+
+func (d *PERT) Mean() float64 {
+	return (d.Min + pertLambda*d.Mode + d.Max) / (pertLambda + 2)
+}
+
+// Lognormal is a lognormal distribution.
+type Lognormal struct {
+	Mu, Sigma float64
+}
+
+// NewLognormal returns the lognormal distribution whose 90% confidence
+// interval is [low, high].
+func NewLognormal(low, high float64) *Lognormal {
+	mu := (math.Log(low) + math.Log(high)) / 2
+	sigma := (math.Log(high) - math.Log(low)) / (2 * z90)
+	return &Lognormal{Mu: mu, Sigma: sigma}
+}
+
+func (d *Lognormal) Sample(r *rand.Rand) float64 {
+	return math.Exp(d.Mu + d.Sigma*r.NormFloat64())
+}
+
+func (d *Lognormal) Mean() float64 {
+	return math.Exp(d.Mu + d.Sigma*d.Sigma/2)
+}
+
+// gamma draws from a gamma distribution with the given shape, which must be
+// at least 1, and unit scale (Marsaglia and Tsang).
+func gamma(r *rand.Rand, shape float64) float64 {
+	d := shape - 1.0/3
+	c := 1 / math.Sqrt(9*d)
+	for {
+		x := r.NormFloat64()
+		v := 1 + c*x
+		if v <= 0 {
+			continue
+		}
+		v = v * v * v
+		u := r.Float64()
+		if math.Log(u) < x*x/2+d-d*v+d*math.Log(v) {
+			return d * v
+		}
+	}
+}
+
+// poisson draws the number of events of a Poisson process with the given
+// mean. Large means use the normal approximation.
+func poisson(r *rand.Rand, mean float64) int {
+	if mean <= 0 {
+		return 0
+	}
+	if mean > 30 {
+		n := math.Round(mean + math.Sqrt(mean)*r.NormFloat64())
+		if n < 0 {
+			return 0
+		}
+		return int(n)
+	}
+	limit := math.Exp(-mean)
+	n, p := 0, r.Float64()
+	for p > limit {
+		n++
+		p *= r.Float64()
+	}
+	return n
+}
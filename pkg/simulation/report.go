@@ -0,0 +1,57 @@
+package simulation
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// ScenarioColumns are the columns of a report section of simulated
+// scenario losses.
+var ScenarioColumns = []report.Column{
+	{Key: "id", Title: "ID", Kind: report.KindString},
+	{Key: "name", Title: "Scenario", Kind: report.KindString},
+	{Key: "frequencyReduction", Title: "Events Stopped", Kind: report.KindPercent},
+	{Key: "magnitudeReduction", Title: "Loss Avoided per Event", Kind: report.KindPercent},
+	{Key: "inherentAnnualLoss", Title: "Inherent ALE", Kind: report.KindNumber},
+	{Key: "residualAnnualLoss", Title: "Residual ALE", Kind: report.KindNumber},
+}
+
+// ControlColumns are the columns of a report section of control values.
+var ControlColumns = []report.Column{
+	{Key: "controlId", Title: "Control ID", Kind: report.KindString},
+	{Key: "controlName", Title: "Control", Kind: report.KindString},
+	{Key: "marginalValue", Title: "Marginal Value", Kind: report.KindNumber},
+	{Key: "scenarios", Title: "Scenarios", Kind: report.KindList},
+}
+
+// ExceedanceColumns are the columns of a report section of the loss
+// exceedance curve.
+var ExceedanceColumns = []report.Column{
+	{Key: "probability", Title: "Probability", Kind: report.KindPercent},
+	{Key: "inherentLoss", Title: "Inherent Loss", Kind: report.KindNumber},
+	{Key: "residualLoss", Title: "Residual Loss", Kind: report.KindNumber},
+}
+
+// AddResult adds the simulation totals to a report's summary and the
+// "scenarios", "controls" and "exceedance" sections.
+func AddResult(r *report.Report, res *Result) {
+	r.AddSummary("iterations", res.Iterations)
+	r.AddSummary("seed", res.Seed)
+	r.AddSummary("inherentAnnualLoss", res.InherentALE)
+	r.AddSummary("residualAnnualLoss", res.ResidualALE)
+	r.AddSummary("skipped", res.Skipped)
+
+	scenarios := r.AddSection("scenarios", "Annualized Loss Expectancy", ScenarioColumns...)
+	for _, s := range res.Scenarios {
+		scenarios.AddRow(s.Scenario.ID, s.Scenario.Name, s.FrequencyReduction, s.MagnitudeReduction, s.InherentALE, s.ResidualALE)
+	}
+
+	controls := r.AddSection("controls", "Marginal Value of Controls", ControlColumns...)
+	for _, c := range res.Controls {
+		controls.AddRow(c.ControlID, c.ControlName, c.MarginalValue, c.Scenarios)
+	}
+
+	exceedance := r.AddSection("exceedance", "Loss Exceedance", ExceedanceColumns...)
+	for _, p := range res.Exceedance {
+		exceedance.AddRow(p.Probability, p.InherentLoss, p.ResidualLoss)
+	}
+}
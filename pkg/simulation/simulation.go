@@ -0,0 +1,257 @@
+// Package simulation estimates the loss exposure of risk scenarios with a
+// FAIR-style Monte Carlo simulation. Each scenario's loss event frequency
+// and loss magnitude are drawn from their distributions, reduced by the
+// measured effectiveness of the scenario's controls, and summed into annual
+// losses. Simulations are deterministic for a given seed.
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/risk"
+)
+
+// DefaultIterations is the number of simulated years used when none is set.
+const DefaultIterations = 10000
+
+// ExceedanceProbabilities are the points of the loss exceedance curve.
+var ExceedanceProbabilities = []float64{0.9, 0.75, 0.5, 0.25, 0.1, 0.05, 0.01}
+
+// Options configures a simulation.
+type Options struct {
+	Iterations int
+	Seed       int64
+}
+
+// ScenarioResult is the simulated loss of one scenario.
+type ScenarioResult struct {
+	Scenario risk.RiskScenario
+	// FrequencyReduction is the share of loss events the scenario's
+	// preventive and deterrent controls stop.
+	FrequencyReduction float64
+	// MagnitudeReduction is the share of the loss of each event the other
+	// controls avoid.
+	MagnitudeReduction float64
+	InherentALE        float64
+	ResidualALE        float64
+}
+
+// ControlValue is the loss a control avoids.
+type ControlValue struct {
+	ControlID   string
+	ControlName string
+	Scenarios   []string
+	// MarginalValue is how much the annualized loss expectancy would rise
+	// without the control, all other controls unchanged.
+	MarginalValue float64
+}
+
+// ExceedancePoint is a point of the loss exceedance curve: the annual loss
+// exceeded with the given probability, without and with controls.
+type ExceedancePoint struct {
+	Probability  float64
+	InherentLoss float64
+	ResidualLoss float64
+}
+
+// Result is the outcome of a simulation.
+type Result struct {
+	Framework   string
+	Iterations  int
+	Seed        int64
+	InherentALE float64
+	ResidualALE float64
+	Scenarios   []ScenarioResult
+	Controls    []ControlValue
+	Exceedance  []ExceedancePoint
+	// Skipped lists scenarios without a frequency or magnitude estimate.
+	Skipped []string
+}
+
+// reducesFrequency reports whether a control category stops loss events
+// rather than limiting their loss.
+func reducesFrequency(category control.ControlCategory) bool {
+	return category == control.CategoryPreventive || category == control.CategoryDeterrent
+}
+
+// scenarioModel is a scenario prepared for simulation. keep holds, for each
+// variant, the share of events that still occur and the share of their loss
+// that remains.
+type scenarioModel struct {
+	result    ScenarioResult
+	frequency Distribution
+	magnitude Distribution
+	keepFreq  []float64
+	keepMag   []float64
+	losses    []float64
+}
+
+// Simulate runs the simulation over the quantified scenarios. Control
+// effectiveness comes from the validation results, as in risk.Assess:
+// each control removes riskReduction × effectiveness of the events
+// (preventive and deterrent controls) or of the loss per event (others).
+//
+// Every variant, with all controls, with none and without each control in
+// turn, is evaluated over the same simulated events, so marginal values are
+// not blurred by sampling noise between runs.
+func Simulate(fw control.ControlFramework, scenarios []risk.RiskScenario, results []control.ControlValidationResult, opts Options) (*Result, error) {
+	if opts.Iterations <= 0 {
+		return nil, errors.New("iterations must be positive")
+	}
+
+	res := &Result{Framework: fw.Name, Iterations: opts.Iterations, Seed: opts.Seed}
+	var quantified []risk.RiskScenario
+	for _, s := range scenarios {
+		if s.Quantified() {
+			quantified = append(quantified, s)
+		} else {
+			res.Skipped = append(res.Skipped, s.ID)
+		}
+	}
+	if len(quantified) == 0 {
+		return nil, errors.New("no risk scenario has both a frequency and a magnitude estimate")
+	}
+
+	categories := make(map[string]control.ControlCategory, len(fw.Controls))
+	for _, c := range fw.Controls {
+		categories[c.ID] = c.Category
+	}
+
+	// Variant 0 has no controls, variant 1 all of them and variant 2+k all
+	// but the k-th control.
+	reg := risk.Assess(fw, quantified, results)
+	index := make(map[string]int)
+	for _, a := range reg.Assessments {
+		for _, m := range a.Mitigations {
+			if _, ok := index[m.ControlID]; !ok {
+				index[m.ControlID] = len(res.Controls)
+				res.Controls = append(res.Controls, ControlValue{ControlID: m.ControlID, ControlName: m.ControlName})
+			}
+			value := &res.Controls[index[m.ControlID]]
+			if n := len(value.Scenarios); n == 0 || value.Scenarios[n-1] != a.Scenario.ID {
+				value.Scenarios = append(value.Scenarios, a.Scenario.ID)
+			}
+		}
+	}
+	variants := 2 + len(res.Controls)
+
+	models := make([]*scenarioModel, 0, len(reg.Assessments))
+	for _, a := range reg.Assessments {
+		m := &scenarioModel{
+			result:   ScenarioResult{Scenario: a.Scenario},
+			keepFreq: ones(variants),
+			keepMag:  ones(variants),
+			losses:   make([]float64, variants),
+		}
+		var err error
+		if m.frequency, err = NewDistribution(*a.Scenario.Frequency); err != nil {
+			return nil, fmt.Errorf("risk scenario %s frequency: %w", a.Scenario.ID, err)
+		}
+		if m.magnitude, err = NewDistribution(*a.Scenario.Magnitude); err != nil {
+			return nil, fmt.Errorf("risk scenario %s magnitude: %w", a.Scenario.ID, err)
+		}
+
+		for _, mit := range a.Mitigations {
+			keep := m.keepMag
+			if reducesFrequency(categories[mit.ControlID]) {
+				keep = m.keepFreq
+			}
+			without := 2 + index[mit.ControlID]
+			for v := 1; v < variants; v++ {
+				if v != without {
+					keep[v] *= 1 - mit.Reduction()
+				}
+			}
+		}
+		m.result.FrequencyReduction = 1 - m.keepFreq[1]
+		m.result.MagnitudeReduction = 1 - m.keepMag[1]
+		models = append(models, m)
+	}
+
+	r := rand.New(rand.NewSource(opts.Seed))
+	inherent := make([]float64, opts.Iterations)
+	residual := make([]float64, opts.Iterations)
+	for i := 0; i < opts.Iterations; i++ {
+		for _, m := range models {
+			var year0, year1 float64
+			events := poisson(r, m.frequency.Sample(r))
+			for e := 0; e < events; e++ {
+				loss := m.magnitude.Sample(r)
+				// An event occurs under a variant with the probability
+				// the variant's controls let it through.
+				u := r.Float64()
+				for v := range m.losses {
+					if u < m.keepFreq[v] {
+						l := loss * m.keepMag[v]
+						m.losses[v] += l
+						switch v {
+						case 0:
+							year0 += l
+						case 1:
+							year1 += l
+						}
+					}
+				}
+			}
+			inherent[i] += year0
+			residual[i] += year1
+		}
+	}
+
+	n := float64(opts.Iterations)
+	without := make([]float64, len(res.Controls))
+	for _, m := range models {
+		m.result.InherentALE = m.losses[0] / n
+		m.result.ResidualALE = m.losses[1] / n
+		res.InherentALE += m.result.InherentALE
+		res.ResidualALE += m.result.ResidualALE
+		for k := range without {
+			without[k] += m.losses[2+k] / n
+		}
+		res.Scenarios = append(res.Scenarios, m.result)
+	}
+	for k := range res.Controls {
+		res.Controls[k].MarginalValue = without[k] - res.ResidualALE
+	}
+	sort.SliceStable(res.Controls, func(i, j int) bool {
+		return res.Controls[i].MarginalValue > res.Controls[j].MarginalValue
+	})
+
+	sort.Float64s(inherent)
+	sort.Float64s(residual)
+	for _, p := range ExceedanceProbabilities {
+		res.Exceedance = append(res.Exceedance, ExceedancePoint{
+			Probability:  p,
+			InherentLoss: quantile(inherent, 1-p),
+			ResidualLoss: quantile(residual, 1-p),
+		})
+	}
+	return res, nil
+}
+
+// ones returns a slice of n ones.
+func ones(n int) []float64 {
+	s := make([]float64, n)
+	for i := range s {
+		s[i] = 1
+	}
+	return s
+}
+
+// quantile returns the q-quantile of sorted values by the nearest-rank
+// method.
+func quantile(sorted []float64, q float64) float64 {
+	i := int(math.Ceil(q*float64(len(sorted)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	if i >= len(sorted) {
+		i = len(sorted) - 1
+	}
+	return sorted[i]
+}
@@ -0,0 +1,137 @@
+package simulation
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+	"github.com/hallucinaut/securitycontrol/pkg/risk"
+)
+
+var framework = control.ControlFramework{
+	Name: "Test",
+	Controls: []control.SecurityControl{
+		{ID: "mfa", Name: "MFA", Category: control.CategoryPreventive, RiskReduction: 0.5},
+		{ID: "backup", Name: "Backups", Category: control.CategoryRecovery, RiskReduction: 0.8},
+		{ID: "ir", Name: "IR Plan", Category: control.CategoryCorrective, RiskReduction: 0.3},
+	},
+}
+
+var results = []control.ControlValidationResult{
+	{ControlID: "mfa", Status: "EFFECTIVE", Effectiveness: 1.0},
+	{ControlID: "backup", Status: "PARTIALLY_EFFECTIVE", Effectiveness: 0.5},
+	{ControlID: "ir", Status: "INEFFECTIVE", Effectiveness: 0.0},
+}
+
+func constant(v float64) *risk.Estimate {
+	return &risk.Estimate{Distribution: risk.DistributionPERT, Min: v, Mode: v, Max: v}
+}
+
+func TestDistributions(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for name, d := range map[string]Distribution{
+		"pert":      NewPERT(1, 2, 9),
+		"lognormal": NewLognormal(1000, 100000),
+	} {
+		sum := 0.0
+		const n = 200000
+		for i := 0; i < n; i++ {
+			sum += d.Sample(r)
+		}
+		if mean := sum / n; math.Abs(mean-d.Mean())/d.Mean() > 0.02 {
+			t.Errorf("%s sample mean = %v, want about %v", name, mean, d.Mean())
+		}
+	}
+
+	if got := NewPERT(1, 2, 9).Mean(); got != 3 {
+		t.Errorf("PERT mean = %v, want 3", got)
+	}
+	if got := math.Exp(NewLognormal(1000, 100000).Mu); math.Abs(got-10000) > 1e-6 {
+		t.Errorf("lognormal median = %v, want 10000", got)
+	}
+	if _, err := NewDistribution(risk.Estimate{Distribution: "uniform"}); err == nil {
+		t.Error("expected error for unknown distribution")
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	scenarios := []risk.RiskScenario{
+		{ID: "takeover", Frequency: constant(2), Magnitude: constant(1000), Controls: []string{"mfa", "ir"}},
+		{ID: "ransomware", Frequency: constant(1), Magnitude: constant(5000), Controls: []string{"backup", "ir"}},
+		{ID: "unquantified", InherentRisk: 10},
+	}
+	opts := Options{Iterations: 20000, Seed: 42}
+	res, err := Simulate(framework, scenarios, results, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(res.Skipped, []string{"unquantified"}) {
+		t.Errorf("skipped = %v", res.Skipped)
+	}
+
+	// takeover: 2 events × 1000, half stopped by MFA
+	// ransomware: 1 event × 5000, 40% of the loss avoided by backups
+	want := map[string][2]float64{"takeover": {2000, 1000}, "ransomware": {5000, 3000}}
+	for _, s := range res.Scenarios {
+		w := want[s.Scenario.ID]
+		if math.Abs(s.InherentALE-w[0])/w[0] > 0.03 || math.Abs(s.ResidualALE-w[1])/w[1] > 0.03 {
+			t.Errorf("%s ALE = %v → %v, want about %v → %v", s.Scenario.ID, s.InherentALE, s.ResidualALE, w[0], w[1])
+		}
+	}
+	if s := res.Scenarios[0]; s.FrequencyReduction != 0.5 || s.MagnitudeReduction != 0 {
+		t.Errorf("takeover reductions = %v, %v", s.FrequencyReduction, s.MagnitudeReduction)
+	}
+
+	values := map[string]float64{}
+	for _, c := range res.Controls {
+		values[c.ControlID] = c.MarginalValue
+	}
+	if res.Controls[0].ControlID != "backup" || values["ir"] != 0 {
+		t.Errorf("controls = %+v", res.Controls)
+	}
+	if math.Abs(values["mfa"]-1000)/1000 > 0.05 {
+		t.Errorf("mfa marginal value = %v, want about 1000", values["mfa"])
+	}
+
+	if len(res.Exceedance) != len(ExceedanceProbabilities) {
+		t.Fatalf("exceedance = %+v", res.Exceedance)
+	}
+	for i := 1; i < len(res.Exceedance); i++ {
+		if res.Exceedance[i].InherentLoss < res.Exceedance[i-1].InherentLoss {
+			t.Errorf("exceedance curve not monotonic: %+v", res.Exceedance)
+		}
+	}
+
+	again, err := Simulate(framework, scenarios, results, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(res, again) {
+		t.Error("simulation is not deterministic for a seed")
+	}
+	opts.Seed++
+	other, _ := Simulate(framework, scenarios, results, opts)
+	if other.InherentALE == res.InherentALE {
+		t.Error("different seeds gave the same result")
+	}
+
+	r := report.New("simulate", "Loss Simulation")
+	AddResult(r, res)
+	if len(r.GetSection("scenarios").Rows) != 2 || len(r.GetSection("controls").Rows) != 3 {
+		t.Errorf("report = %+v", r)
+	}
+}
+
+func TestSimulateErrors(t *testing.T) {
+	if _, err := Simulate(framework, []risk.RiskScenario{{ID: "a", InherentRisk: 1}}, results, Options{Iterations: 10}); err == nil {
+		t.Error("expected error without quantified scenarios")
+	}
+	scenarios := []risk.RiskScenario{{ID: "a", Frequency: constant(1), Magnitude: constant(1)}}
+	if _, err := Simulate(framework, scenarios, results, Options{}); err == nil {
+		t.Error("expected error without iterations")
+	}
+}
@@ -0,0 +1,208 @@
+package validate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrNotRecorded is returned by the manual executor for tests that have no
+// recorded result.
+var ErrNotRecorded = errors.New("no result recorded for manual test")
+
+// Outcome represents the result of executing a control test.
+type Outcome struct {
+	Passed       bool
+	ActualResult string
+	Evidence     []Evidence
+	TestedAt     time.Time
+	Duration     time.Duration
+	Err          error
+}
+
+// Result returns the validation result of the outcome: PASS, FAIL, ERROR
+// or NOT_RUN.
+func (o Outcome) Result() string {
+	switch {
+	case errors.Is(o.Err, ErrNotRecorded):
+		return "NOT_RUN"
+	case o.Err != nil:
+		return "ERROR"
+	case !o.Passed:
+		return "FAIL"
+	}
+	return "PASS"
+}
+
+// Evidence represents an artifact captured while executing a test.
+type Evidence struct {
+	Name    string
+	Content string
+	SHA256  string
+}
+
+// NewEvidence creates evidence with its content hash.
+func NewEvidence(name, content string) Evidence {
+	sum := sha256.Sum256([]byte(content))
+	return Evidence{
+		Name:    name,
+		Content: content,
+		SHA256:  hex.EncodeToString(sum[:]),
+	}
+}
+
+// Executor executes control tests.
+type Executor interface {
+	Execute(ctx context.Context, test ControlTest) Outcome
+}
+
+// Retrier is implemented by executors that report whether a failed or
+// errored test is worth executing again. Tests of executors that do not
+// implement it are retried.
+type Retrier interface {
+	Retryable() bool
+}
+
+// ExecutorFunc adapts a function to the Executor interface.
+type ExecutorFunc func(ctx context.Context, test ControlTest) Outcome
+
+// Execute calls f(ctx, test).
+func (f ExecutorFunc) Execute(ctx context.Context, test ControlTest) Outcome {
+	return f(ctx, test)
+}
+
+// Registry maps test kinds to executors. A test's kind is its Kind field,
+// or its validation method when Kind is empty.
+type Registry struct {
+	mu        sync.RWMutex
+	executors map[string]Executor
+}
+
+// NewRegistry creates an empty executor registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		executors: make(map[string]Executor),
+	}
+}
+
+// DefaultRegistry creates a registry with the shell executor registered
+// for automation tests and the manual executor for every other method.
+func DefaultRegistry() *Registry {
+	r := NewRegistry()
+	manual := ManualExecutor{}
+	for _, method := range []ValidationMethod{
+		MethodDocumentation,
+		MethodInterview,
+		MethodObservation,
+		MethodTesting,
+	} {
+		r.Register(string(method), manual)
+	}
+	r.Register(KindManual, manual)
+
+	shell := NewShellExecutor()
+	r.Register(string(MethodAutomation), shell)
+	r.Register(KindCommand, shell)
+	return r
+}
+
+// Register registers an executor for a test kind or validation method.
+func (r *Registry) Register(kind string, executor Executor) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.executors[kind] = executor
+}
+
+// Lookup returns the executor for a test.
+func (r *Registry) Lookup(test ControlTest) (Executor, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	executor, ok := r.executors[test.kind()]
+	return executor, ok
+}
+
+// Retry reports whether a test that ended with the given validation result
+// is worth executing again: it failed or errored, and its executor runs
+// something whose outcome can change.
+func (r *Registry) Retry(test ControlTest, result string) bool {
+	if result != "FAIL" && result != "ERROR" {
+		return false
+	}
+	executor, ok := r.Lookup(test)
+	if !ok {
+		return false
+	}
+	if retrier, ok := executor.(Retrier); ok {
+		return retrier.Retryable()
+	}
+	return true
+}
+
+// Execute runs a test through its registered executor and fills in the
+// duration and timestamp when the executor leaves them unset. Start and
+// finish events are reported to the context's progress function.
+func (r *Registry) Execute(ctx context.Context, test ControlTest) Outcome {
+	reportProgress(ctx, ProgressEvent{Kind: ProgressStart, TestID: test.ID, Steps: len(test.Steps), Message: test.Name})
+
+	executor, ok := r.Lookup(test)
+	if !ok {
+		outcome := Outcome{
+			TestedAt: time.Now(),
+			Err:      fmt.Errorf("no executor registered for %q", test.kind()),
+		}
+		reportProgress(ctx, ProgressEvent{Kind: ProgressFinish, TestID: test.ID, Outcome: &outcome})
+		return outcome
+	}
+
+	start := time.Now()
+	outcome := executor.Execute(ctx, test)
+	if outcome.Duration == 0 {
+		outcome.Duration = time.Since(start)
+	}
+	if outcome.TestedAt.IsZero() {
+		outcome.TestedAt = start
+	}
+	reportProgress(ctx, ProgressEvent{Kind: ProgressFinish, TestID: test.ID, Outcome: &outcome})
+	return outcome
+}
+
+// KindManual is the test kind of the manual executor.
+const KindManual = "manual"
+
+// ManualExecutor reports the result recorded on a test by a person, such as
+// an interview or document review.
+type ManualExecutor struct{}
+
+// Retryable returns false, as a recorded result does not change.
+func (ManualExecutor) Retryable() bool {
+	return false
+}
+
+// Execute returns the recorded result of a test.
+func (ManualExecutor) Execute(ctx context.Context, test ControlTest) Outcome {
+	if test.TestedAt.IsZero() {
+		return Outcome{Err: ErrNotRecorded}
+	}
+	for i, step := range test.Steps {
+		reportStep(ctx, test, i+1, step+" (recorded)")
+	}
+
+	actual := test.ActualResult
+	if actual == "" && test.Passed {
+		actual = test.ExpectedResult
+	}
+
+	outcome := Outcome{
+		Passed:       test.Passed,
+		ActualResult: actual,
+		TestedAt:     test.TestedAt,
+	}
+	if test.Notes != "" {
+		outcome.Evidence = append(outcome.Evidence, NewEvidence("notes", test.Notes))
+	}
+	return outcome
+}
@@ -0,0 +1,128 @@
+package validate
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// InteractiveExecutor walks a person through a manual test's steps,
+// prompting for the result of each step and for the actual result. The
+// transcript of the session is recorded as evidence.
+type InteractiveExecutor struct {
+	mu  sync.Mutex
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// NewInteractiveExecutor creates an interactive executor reading answers
+// from in and writing prompts to out.
+func NewInteractiveExecutor(in io.Reader, out io.Writer) *InteractiveExecutor {
+	return &InteractiveExecutor{
+		in:  bufio.NewReader(in),
+		out: out,
+	}
+}
+
+// Retryable returns false, so that a person is not asked again for the
+// result they gave.
+func (e *InteractiveExecutor) Retryable() bool {
+	return false
+}
+
+// Execute prompts for the result of each step of the test. The test passes
+// when no step fails and at least one step passes; a test without steps is
+// answered as a whole.
+func (e *InteractiveExecutor) Execute(ctx context.Context, test ControlTest) Outcome {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var transcript strings.Builder
+	start := time.Now()
+	outcome := Outcome{TestedAt: start}
+
+	if test.Description != "" {
+		fmt.Fprintf(e.out, "%s\n", test.Description)
+	}
+	if test.ExpectedResult != "" {
+		fmt.Fprintf(e.out, "Expected: %s\n", test.ExpectedResult)
+	}
+
+	passed, failed := 0, 0
+	steps := test.Steps
+	if len(steps) == 0 {
+		steps = []string{"Perform the test"}
+	}
+	for i, step := range steps {
+		result, err := e.askStep(ctx, fmt.Sprintf("[%d/%d] %s\n  Result [p]ass, [f]ail, [s]kip: ", i+1, len(steps), step))
+		if err != nil {
+			outcome.Err = err
+			return outcome
+		}
+		switch result {
+		case "pass":
+			passed++
+		case "fail":
+			failed++
+		}
+		fmt.Fprintf(&transcript, "[%d/%d] %s: %s\n", i+1, len(steps), step, result)
+	}
+
+	outcome.Passed = failed == 0 && passed > 0
+	actual, err := e.readLine(ctx, "Actual result: ")
+	if err != nil {
+		outcome.Err = err
+		return outcome
+	}
+	if actual == "" && outcome.Passed {
+		actual = test.ExpectedResult
+	}
+	outcome.ActualResult = actual
+	fmt.Fprintf(&transcript, "Actual result: %s\n", actual)
+
+	outcome.Evidence = []Evidence{NewEvidence("transcript", transcript.String())}
+	outcome.Duration = time.Since(start)
+	return outcome
+}
+
+// stepAnswers maps the accepted answers to a step prompt to a result.
+var stepAnswers = map[string]string{
+	"p": "pass", "pass": "pass",
+	"f": "fail", "fail": "fail",
+	"s": "skip", "skip": "skip",
+}
+
+// askStep prompts until a step result is entered.
+func (e *InteractiveExecutor) askStep(ctx context.Context, prompt string) (string, error) {
+	for {
+		answer, err := e.readLine(ctx, prompt)
+		if err != nil {
+			return "", err
+		}
+		if result, ok := stepAnswers[strings.ToLower(answer)]; ok {
+			return result, nil
+		}
+		fmt.Fprintln(e.out, "  Please answer pass, fail or skip")
+	}
+}
+
+// readLine prompts for and reads a line of input.
+func (e *InteractiveExecutor) readLine(ctx context.Context, prompt string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	fmt.Fprint(e.out, prompt)
+	line, err := e.in.ReadString('\n')
+	if err != nil && (line == "" || !errors.Is(err, io.EOF)) {
+		if errors.Is(err, io.EOF) {
+			return "", errors.New("input ended before the test was completed")
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
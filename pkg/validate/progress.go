@@ -0,0 +1,56 @@
+package validate
+
+import (
+	"context"
+)
+
+// ProgressKind identifies a progress event.
+type ProgressKind string
+
+const (
+	ProgressStart  ProgressKind = "start"
+	ProgressStep   ProgressKind = "step"
+	ProgressFinish ProgressKind = "finish"
+)
+
+// ProgressEvent reports the progress of a running test. Step events carry
+// the 1-based step number and the number of steps; finish events carry the
+// outcome.
+type ProgressEvent struct {
+	Kind    ProgressKind
+	TestID  string
+	Step    int
+	Steps   int
+	Message string
+	Outcome *Outcome
+}
+
+// ProgressFunc receives progress events. It may be called concurrently
+// when tests run in parallel.
+type ProgressFunc func(ProgressEvent)
+
+type progressKey struct{}
+
+// WithProgress returns a context that delivers progress events of the
+// tests executed with it to fn.
+func WithProgress(ctx context.Context, fn ProgressFunc) context.Context {
+	return context.WithValue(ctx, progressKey{}, fn)
+}
+
+// reportProgress delivers an event to the context's progress function.
+func reportProgress(ctx context.Context, event ProgressEvent) {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFunc); ok && fn != nil {
+		fn(event)
+	}
+}
+
+// reportStep reports a step of a test.
+func reportStep(ctx context.Context, test ControlTest, step int, message string) {
+	reportProgress(ctx, ProgressEvent{
+		Kind:    ProgressStep,
+		TestID:  test.ID,
+		Step:    step,
+		Steps:   len(test.Steps),
+		Message: message,
+	})
+}
@@ -0,0 +1,119 @@
+package validate
+
+import (
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+// ResultColumns are the columns of a report section of test results.
+var ResultColumns = []report.Column{
+	{Key: "id", Title: "Test ID", Kind: report.KindString},
+	{Key: "name", Title: "Name", Kind: report.KindString},
+	{Key: "result", Title: "Result", Kind: report.KindString},
+	{Key: "passed", Title: "Passed", Kind: report.KindBool},
+	{Key: "actualResult", Title: "Actual", Kind: report.KindString},
+	{Key: "error", Title: "Error", Kind: report.KindString},
+	{Key: "effectiveness", Title: "Effectiveness", Kind: report.KindPercent},
+	{Key: "riskRemaining", Title: "Risk Remaining", Kind: report.KindPercent},
+	{Key: "attempts", Title: "Attempts", Kind: report.KindNumber},
+	{Key: "durationSeconds", Title: "Duration (s)", Kind: report.KindNumber},
+	{Key: "evidence", Title: "Evidence", Kind: report.KindList},
+	{Key: "recommendations", Title: "Recommendations", Kind: report.KindList},
+	{Key: "validatedAt", Title: "Validated At", Kind: report.KindTime},
+}
+
+// PlanColumns are the columns of a report section of tests to be executed.
+var PlanColumns = []report.Column{
+	{Key: "id", Title: "Test ID", Kind: report.KindString},
+	{Key: "name", Title: "Name", Kind: report.KindString},
+	{Key: "kind", Title: "Kind", Kind: report.KindString},
+	{Key: "steps", Title: "Steps", Kind: report.KindList},
+	{Key: "command", Title: "Command", Kind: report.KindString},
+	{Key: "env", Title: "Environment", Kind: report.KindList},
+	{Key: "timeoutSeconds", Title: "Timeout (s)", Kind: report.KindNumber},
+	{Key: "expectedResult", Title: "Expected", Kind: report.KindString},
+}
+
+// BuildValidationReport builds a structured report of the validator's
+// results.
+func (v *ControlValidator) BuildValidationReport() *report.Report {
+	r := report.New("test-validation", "Security Control Test Report")
+	AddResults(r, v.GetResults())
+	return r
+}
+
+// BuildValidationReport builds a structured report of the validator's
+// results.
+func BuildValidationReport(validator *ControlValidator) *report.Report {
+	return validator.BuildValidationReport()
+}
+
+// AddResults adds a summary and a "tests" section of test results to a
+// report. Evidence is listed by name and SHA-256 digest.
+func AddResults(r *report.Report, results []ValidationResult) {
+	passed := 0
+	for _, result := range results {
+		if result.TestPassed {
+			passed++
+		}
+	}
+	rate := 0.0
+	if len(results) > 0 {
+		rate = float64(passed) / float64(len(results))
+	}
+
+	r.AddSummary("tests", len(results))
+	r.AddSummary("testsPassed", passed)
+	r.AddSummary("testsFailed", len(results)-passed)
+	r.AddSummaryKind("successRate", rate, report.KindPercent)
+
+	section := r.AddSection("tests", "Test Results", ResultColumns...)
+	for _, result := range results {
+		evidence := make([]string, len(result.Evidence))
+		for i, e := range result.Evidence {
+			evidence[i] = e.Name + " sha256:" + e.SHA256
+		}
+		recommendations := result.Recommendations
+		if recommendations == nil {
+			recommendations = []string{}
+		}
+		section.AddRow(
+			result.TestID,
+			result.ControlName,
+			result.ValidationResult,
+			result.TestPassed,
+			result.ActualResult,
+			result.Error,
+			result.Effectiveness,
+			result.RiskRemaining,
+			result.Attempts,
+			result.Duration.Seconds(),
+			evidence,
+			recommendations,
+			result.ValidatedAt,
+		)
+	}
+}
+
+// AddPlan adds a "plan" section listing the tests that would be executed.
+// Only the names of command environment variables are listed.
+func AddPlan(r *report.Report, tests []ControlTest) {
+	r.AddSummary("tests", len(tests))
+
+	section := r.AddSection("plan", "Test Plan", PlanColumns...)
+	for _, test := range tests {
+		steps := test.Steps
+		if steps == nil {
+			steps = []string{}
+		}
+		command, env, timeout := "", []string{}, 0.0
+		if test.Command != nil {
+			command = test.Command.String()
+			env = test.Command.EnvNames()
+			timeout = test.Command.Timeout.Seconds()
+			if test.Command.Timeout <= 0 {
+				timeout = DefaultCommandTimeout.Seconds()
+			}
+		}
+		section.AddRow(test.ID, test.Name, test.kind(), steps, command, env, timeout, test.ExpectedResult)
+	}
+}
@@ -0,0 +1,387 @@
+package validate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KindCommand is the test kind of the shell command executor.
+const KindCommand = "command"
+
+// DefaultMaxOutput is the default number of bytes kept from each of a
+// command's output streams.
+const DefaultMaxOutput = 64 * 1024
+
+// DefaultMaxAssertOutput is the default number of bytes of stdout kept for
+// checking a command's expectations.
+const DefaultMaxAssertOutput = 16 * 1024 * 1024
+
+// DefaultCommandTimeout bounds commands that declare no timeout.
+const DefaultCommandTimeout = 5 * time.Minute
+
+// Command describes a command run by an automated test and the results
+// expected from it.
+type Command struct {
+	Path           string
+	Args           []string
+	Env            map[string]string
+	Dir            string
+	Timeout        time.Duration
+	ExpectExitCode int
+	ExpectStdout   string
+	ExpectJSON     []JSONAssertion
+}
+
+// String returns the command line, quoting arguments that contain spaces
+// or quotes. Environment values are omitted.
+func (c *Command) String() string {
+	parts := make([]string, 0, len(c.Args)+1)
+	for _, arg := range append([]string{c.Path}, c.Args...) {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\") {
+			arg = strconv.Quote(arg)
+		}
+		parts = append(parts, arg)
+	}
+	return strings.Join(parts, " ")
+}
+
+// EnvNames returns the sorted names of the command's environment variables.
+func (c *Command) EnvNames() []string {
+	names := make([]string, 0, len(c.Env))
+	for name := range c.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// JSONAssertion checks a value in a command's JSON output. Path uses dot
+// and index notation such as "$.items[0].enabled". When neither Equals nor
+// Matches is set the value only has to exist.
+type JSONAssertion struct {
+	Path    string
+	Equals  string
+	Matches string
+}
+
+// redaction replaces matches of a pattern in command output.
+type redaction struct {
+	pattern     *regexp.Regexp
+	replacement string
+}
+
+// defaultRedactions match common secret formats in command output.
+var defaultRedactions = []redaction{
+	{regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), "[REDACTED]"},
+	{regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`), "[REDACTED]"},
+	{regexp.MustCompile(`(?i)\b(bearer\s+)[A-Za-z0-9\-._~+/]+=*`), "${1}[REDACTED]"},
+	{regexp.MustCompile(`(?i)\b((?:password|passwd|secret|token|api[_-]?key|access[_-]?key)["']?\s*[:=]\s*["']?)[^\s"',;]+`), "${1}[REDACTED]"},
+}
+
+// ShellExecutor runs a test's Command and checks its exit code, stdout and
+// JSON output. Expectations are checked against stdout up to a generous
+// limit, while the output recorded as evidence is capped and redacted.
+type ShellExecutor struct {
+	maxOutput       int
+	maxAssertOutput int
+	redactions      []redaction
+}
+
+// NewShellExecutor creates a shell executor with the default output cap and
+// redaction patterns.
+func NewShellExecutor() *ShellExecutor {
+	return &ShellExecutor{
+		maxOutput:       DefaultMaxOutput,
+		maxAssertOutput: DefaultMaxAssertOutput,
+		redactions:      append([]redaction(nil), defaultRedactions...),
+	}
+}
+
+// SetMaxOutput sets the number of bytes of each output stream recorded as
+// evidence.
+func (e *ShellExecutor) SetMaxOutput(n int) {
+	e.maxOutput = n
+}
+
+// SetMaxAssertOutput sets the number of bytes of stdout kept for checking
+// expectations. Commands writing more fail tests asserting on stdout.
+func (e *ShellExecutor) SetMaxAssertOutput(n int) {
+	e.maxAssertOutput = n
+}
+
+// AddRedaction adds a pattern whose matches are replaced in output. The
+// replacement may reference groups as in regexp.ReplaceAllString.
+func (e *ShellExecutor) AddRedaction(pattern *regexp.Regexp, replacement string) {
+	e.redactions = append(e.redactions, redaction{pattern: pattern, replacement: replacement})
+}
+
+// Execute runs the test's command. Tests without a command fall back to
+// their recorded manual result.
+func (e *ShellExecutor) Execute(ctx context.Context, test ControlTest) Outcome {
+	cmdSpec := test.Command
+	if cmdSpec == nil {
+		return ManualExecutor{}.Execute(ctx, test)
+	}
+
+	timeout := cmdSpec.Timeout
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	stdout := &cappedBuffer{max: max(e.maxOutput, e.maxAssertOutput)}
+	stderr := &cappedBuffer{max: e.maxOutput}
+
+	cmd := exec.CommandContext(ctx, cmdSpec.Path, cmdSpec.Args...)
+	cmd.Dir = cmdSpec.Dir
+	cmd.Env = os.Environ()
+	for _, k := range cmdSpec.EnvNames() {
+		cmd.Env = append(cmd.Env, k+"="+cmdSpec.Env[k])
+	}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	cmd.WaitDelay = time.Second
+
+	// The command runs as a whole, so its steps are reported as the plan
+	for i, step := range test.Steps {
+		reportStep(ctx, test, i+1, "will run: "+step)
+	}
+
+	start := time.Now()
+	runErr := cmd.Run()
+	outcome := Outcome{TestedAt: start, Duration: time.Since(start)}
+
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+
+	// Only the first maxOutput bytes of stdout are recorded
+	outBytes := stdout.Bytes()
+	outTruncated := stdout.truncated > 0
+	if len(outBytes) > e.maxOutput {
+		outBytes = outBytes[:e.maxOutput]
+		outTruncated = true
+	}
+
+	redact := e.redactor(cmdSpec.Env)
+	outText := redact(string(outBytes))
+	errText := redact(stderr.String())
+	outcome.Evidence = []Evidence{
+		NewEvidence("stdout", outText),
+		NewEvidence("stderr", errText),
+		NewEvidence("exit-code", strconv.Itoa(exitCode)),
+	}
+
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		outcome.Err = fmt.Errorf("command timed out after %s", timeout)
+		return outcome
+	case ctx.Err() != nil:
+		outcome.Err = ctx.Err()
+		return outcome
+	case runErr != nil && !isExitError(runErr):
+		outcome.Err = runErr
+		return outcome
+	}
+
+	// Assertions run against the captured output before redaction
+	failures := checkCommand(cmdSpec, exitCode, stdout.Bytes(), stdout.truncated > 0)
+	outcome.Passed = len(failures) == 0
+	outcome.ActualResult = fmt.Sprintf("exit code %d", exitCode)
+	if len(failures) > 0 {
+		outcome.ActualResult += ": " + redact(strings.Join(failures, "; "))
+	}
+	if outTruncated || stderr.truncated > 0 {
+		outcome.ActualResult += fmt.Sprintf(" (output truncated to %d bytes)", e.maxOutput)
+	}
+	return outcome
+}
+
+// redactor returns a function redacting secrets and the values of secret
+// environment variables passed to the command.
+func (e *ShellExecutor) redactor(env map[string]string) func(string) string {
+	var values []string
+	for k, v := range env {
+		if isSecretName(k) && v != "" {
+			values = append(values, v)
+		}
+	}
+
+	return func(s string) string {
+		for _, v := range values {
+			s = strings.ReplaceAll(s, v, "[REDACTED]")
+		}
+		for _, r := range e.redactions {
+			s = r.pattern.ReplaceAllString(s, r.replacement)
+		}
+		return s
+	}
+}
+
+// checkCommand returns the expectations a command run failed. Expectations
+// on stdout fail when it was truncated, as they cannot be evaluated.
+func checkCommand(cmd *Command, exitCode int, stdout []byte, truncated bool) []string {
+	var failures []string
+	if exitCode != cmd.ExpectExitCode {
+		failures = append(failures, fmt.Sprintf("expected exit code %d", cmd.ExpectExitCode))
+	}
+
+	if truncated && (cmd.ExpectStdout != "" || len(cmd.ExpectJSON) > 0) {
+		return append(failures, "output truncated, cannot evaluate assertion")
+	}
+
+	if cmd.ExpectStdout != "" {
+		re, err := regexp.Compile(cmd.ExpectStdout)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("invalid stdout pattern: %v", err))
+		} else if !re.Match(stdout) {
+			failures = append(failures, fmt.Sprintf("stdout does not match /%s/", cmd.ExpectStdout))
+		}
+	}
+
+	if len(cmd.ExpectJSON) == 0 {
+		return failures
+	}
+	var doc interface{}
+	if err := json.Unmarshal(stdout, &doc); err != nil {
+		return append(failures, "stdout is not valid JSON")
+	}
+	for _, a := range cmd.ExpectJSON {
+		if msg := a.check(doc); msg != "" {
+			failures = append(failures, msg)
+		}
+	}
+	return failures
+}
+
+// check returns a failure message, or "" when the assertion holds.
+func (a JSONAssertion) check(doc interface{}) string {
+	value, err := lookupJSON(doc, a.Path)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", a.Path, err)
+	}
+
+	actual := jsonString(value)
+	if a.Equals != "" && actual != a.Equals {
+		return fmt.Sprintf("%s is %s, expected %s", a.Path, actual, a.Equals)
+	}
+	if a.Matches != "" {
+		re, err := regexp.Compile(a.Matches)
+		if err != nil {
+			return fmt.Sprintf("%s: invalid pattern: %v", a.Path, err)
+		}
+		if !re.MatchString(actual) {
+			return fmt.Sprintf("%s is %s, expected to match /%s/", a.Path, actual, a.Matches)
+		}
+	}
+	return ""
+}
+
+var jsonPathToken = regexp.MustCompile(`\.([^.\[]+)|\[(\d+)\]|\["([^"]*)"\]`)
+
+// ValidateJSONPath checks that a JSON path is well formed.
+func ValidateJSONPath(path string) error {
+	if !strings.HasPrefix(path, "$") {
+		return fmt.Errorf("JSON path %q must start with $", path)
+	}
+	if jsonPathToken.ReplaceAllString(path[1:], "") != "" {
+		return fmt.Errorf("invalid JSON path %q", path)
+	}
+	return nil
+}
+
+// lookupJSON evaluates a JSON path against a decoded document.
+func lookupJSON(doc interface{}, path string) (interface{}, error) {
+	if err := ValidateJSONPath(path); err != nil {
+		return nil, err
+	}
+
+	value := doc
+	for _, m := range jsonPathToken.FindAllStringSubmatch(strings.TrimPrefix(path, "$"), -1) {
+		switch {
+		case m[2] != "":
+			list, ok := value.([]interface{})
+			i, _ := strconv.Atoi(m[2])
+			if !ok || i >= len(list) {
+				return nil, errors.New("not found")
+			}
+			value = list[i]
+		default:
+			key := m[1] + m[3]
+			obj, ok := value.(map[string]interface{})
+			if !ok {
+				return nil, errors.New("not found")
+			}
+			if value, ok = obj[key]; !ok {
+				return nil, errors.New("not found")
+			}
+		}
+	}
+	return value, nil
+}
+
+// jsonString formats a JSON value for comparison. Strings are compared
+// without quotes.
+func jsonString(value interface{}) string {
+	if s, ok := value.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+func isExitError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr)
+}
+
+func isSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, s := range []string{"SECRET", "TOKEN", "PASSWORD", "PASSWD", "KEY", "CREDENTIAL"} {
+		if strings.Contains(upper, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// cappedBuffer keeps the first max bytes written and counts the rest.
+type cappedBuffer struct {
+	buf       bytes.Buffer
+	max       int
+	truncated int
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	room := b.max - b.buf.Len()
+	if room <= 0 {
+		b.truncated += len(p)
+		return len(p), nil
+	}
+	if len(p) > room {
+		b.buf.Write(p[:room])
+		b.truncated += len(p) - room
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *cappedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func (b *cappedBuffer) String() string {
+	return b.buf.String()
+}
@@ -2,8 +2,14 @@
 package validate
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/control"
+	"github.com/hallucinaut/securitycontrol/pkg/engine"
 )
 
 // ValidationMethod represents a validation method.
@@ -19,24 +25,28 @@ const (
 
 // ControlTest represents a control test case.
 type ControlTest struct {
-	ID              string
-	Name            string
-	Description     string
-	Method          ValidationMethod
-	Steps           []string
-	ExpectedResult  string
-	ActualResult    string
-	Passed          bool
-	Notes           string
-	TestedAt        time.Time
-	TestedBy        string
+	ID             string
+	Name           string
+	Description    string
+	Method         ValidationMethod
+	Kind           string
+	Command        *Command
+	Steps          []string
+	ExpectedResult string
+	ActualResult   string
+	Passed         bool
+	Notes          string
+	TestedAt       time.Time
+	TestedBy       string
 }
 
 // ControlValidator validates security controls through testing.
 type ControlValidator struct {
-	controls     []ControlTest
-	validation   []ControlValidation
-	results      []ValidationResult
+	mu         sync.RWMutex
+	controls   []ControlTest
+	validation []ControlValidation
+	results    []ValidationResult
+	executors  *Registry
 }
 
 // ControlValidation represents a control validation.
@@ -52,66 +62,133 @@ type ControlValidation struct {
 
 // ValidationResult represents a validation result.
 type ValidationResult struct {
-	ID              string
-	ControlID       string
-	ControlName     string
-	TestPassed      bool
+	ID               string
+	TestID           string
+	ControlID        string
+	ControlName      string
+	TestPassed       bool
 	ValidationResult string
-	Effectiveness   float64
-	RiskRemaining   float64
-	Recommendations []string
-	ValidatedAt     time.Time
+	Effectiveness    float64
+	RiskRemaining    float64
+	Recommendations  []string
+	ActualResult     string
+	Evidence         []Evidence
+	Duration         time.Duration
+	Attempts         int
+	Error            string
+	ValidatedAt      time.Time
 }
 
 // NewControlValidator creates a new control validator.
 func NewControlValidator() *ControlValidator {
 	return &ControlValidator{
-		controls: make([]ControlTest, 0),
+		controls:   make([]ControlTest, 0),
 		validation: make([]ControlValidation, 0),
-		results:  make([]ValidationResult, 0),
+		results:    make([]ValidationResult, 0),
+		executors:  DefaultRegistry(),
 	}
 }
 
+// SetRegistry sets the executor registry tests are dispatched through.
+func (v *ControlValidator) SetRegistry(registry *Registry) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.executors = registry
+}
+
+// GetRegistry returns the executor registry.
+func (v *ControlValidator) GetRegistry() *Registry {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return v.executors
+}
+
 // AddControlTest adds a control test.
 func (v *ControlValidator) AddControlTest(test ControlTest) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
 	v.controls = append(v.controls, test)
 }
 
 // Validate validates controls.
 func (v *ControlValidator) Validate() []ValidationResult {
-	var results []ValidationResult
+	results, _ := v.ValidateAll(context.Background(), engine.Options{Workers: 1})
+	return results
+}
 
-	for _, test := range v.controls {
-		result := v.validateControlTest(test)
-		results = append(results, result)
+// ValidateAll executes every control test concurrently. Failed and errored
+// tests are retried up to opts.Retries times, unless their executor reports
+// recorded results. Results are returned in test order. If ctx is cancelled
+// or the run times out, the tests completed so far are returned with the
+// context error.
+func (v *ControlValidator) ValidateAll(ctx context.Context, opts engine.Options) ([]ValidationResult, error) {
+	tests := v.GetControlTests()
+	registry := v.GetRegistry()
+	executed := make([]*ValidationResult, len(tests))
+
+	err := engine.Run(ctx, len(tests), opts, func(ctx context.Context, i, attempt int) bool {
+		result := validateControlTest(ctx, registry, tests[i])
+		result.Attempts = attempt
+		executed[i] = &result
+		return registry.Retry(tests[i], result.ValidationResult)
+	})
+
+	results := make([]ValidationResult, 0, len(tests))
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for i, result := range executed {
+		if result == nil {
+			continue
+		}
+		if result.TestPassed && result.Attempts > 1 {
+			result.Recommendations = append(result.Recommendations, fmt.Sprintf("Test passed after %d attempts; investigate flaky behavior", result.Attempts))
+		}
+		if i < len(v.controls) && v.controls[i].ID == tests[i].ID {
+			v.controls[i] = tests[i].withResult(*result)
+		}
+		results = append(results, *result)
 	}
 
 	v.results = results
-	return results
+	return results, err
 }
 
-// validateControlTest validates a control test.
-func (v *ControlValidator) validateControlTest(test ControlTest) ValidationResult {
-	// Simulate test execution
-	passed := true
-	effectiveness := 0.8
+// validateControlTest executes a control test through a registry.
+func validateControlTest(ctx context.Context, registry *Registry, test ControlTest) ValidationResult {
+	outcome := registry.Execute(ctx, test)
 
-	// In production: execute actual tests
-	// For demo: simulate results
+	passed := outcome.Passed && outcome.Err == nil
+	effectiveness := 0.0
+	if passed {
+		effectiveness = 1.0
+	}
 
 	result := ValidationResult{
-		ID:              "val-" + time.Now().Format("20060102150405"),
-		ControlID:       test.ID,
-		ControlName:     test.Name,
-		TestPassed:      passed,
-		ValidationResult: "PASS",
-		Effectiveness:   effectiveness,
-		RiskRemaining:   1.0 - effectiveness,
-		Recommendations: make([]string, 0),
-		ValidatedAt:     time.Now(),
+		ID:               "val-" + time.Now().Format("20060102150405"),
+		TestID:           test.ID,
+		ControlID:        test.ID,
+		ControlName:      test.Name,
+		TestPassed:       passed,
+		ValidationResult: outcome.Result(),
+		Effectiveness:    effectiveness,
+		RiskRemaining:    1.0 - effectiveness,
+		Recommendations:  make([]string, 0),
+		ActualResult:     outcome.ActualResult,
+		Evidence:         outcome.Evidence,
+		Duration:         outcome.Duration,
+		ValidatedAt:      outcome.TestedAt,
 	}
 
-	if !passed {
+	switch {
+	case errors.Is(outcome.Err, ErrNotRecorded):
+		result.ValidationResult = "NOT_RUN"
+		result.Error = outcome.Err.Error()
+		result.Recommendations = append(result.Recommendations, "Perform the test and record its result")
+	case outcome.Err != nil:
+		result.ValidationResult = "ERROR"
+		result.Error = outcome.Err.Error()
+		result.Recommendations = append(result.Recommendations, "Fix test execution: "+outcome.Err.Error())
+	case !passed:
 		result.ValidationResult = "FAIL"
 		result.Recommendations = append(result.Recommendations, "Review and fix control implementation")
 	}
@@ -119,18 +196,58 @@ func (v *ControlValidator) validateControlTest(test ControlTest) ValidationResul
 	return result
 }
 
+// withResult returns the test updated with the result of executing it.
+func (t ControlTest) withResult(result ValidationResult) ControlTest {
+	if result.Error != "" {
+		return t
+	}
+	t.ActualResult = result.ActualResult
+	t.Passed = result.TestPassed
+	t.TestedAt = result.ValidatedAt
+	return t
+}
+
+// kind returns the executor kind of the test.
+func (t ControlTest) kind() string {
+	if t.Kind != "" {
+		return t.Kind
+	}
+	return string(t.Method)
+}
+
+// Completed reports whether the test ran to a pass or fail result.
+func (r ValidationResult) Completed() bool {
+	return r.Error == ""
+}
+
+// Outcome returns the result as a test outcome for control scoring.
+func (r ValidationResult) Outcome() control.TestOutcome {
+	return control.TestOutcome{
+		TestID:   r.TestID,
+		Passed:   r.TestPassed,
+		TestedAt: r.ValidatedAt,
+	}
+}
+
 // GetResults returns all validation results.
 func (v *ControlValidator) GetResults() []ValidationResult {
-	return v.results
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]ValidationResult(nil), v.results...)
 }
 
 // GetControlTests returns all control tests.
 func (v *ControlValidator) GetControlTests() []ControlTest {
-	return v.controls
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	return append([]ControlTest(nil), v.controls...)
 }
 
 // ValidateByMethod validates controls by method.
 func (v *ControlValidator) ValidateByMethod(method ValidationMethod) []ControlTest {
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+
 	var tests []ControlTest
 	for _, test := range v.controls {
 		if test.Method == method {
@@ -142,11 +259,15 @@ func (v *ControlValidator) ValidateByMethod(method ValidationMethod) []ControlTe
 
 // GenerateValidationReport generates validation report.
 func (v *ControlValidator) GenerateValidationReport() string {
+	return GenerateResultsReport(v.GetResults())
+}
+
+// GenerateResultsReport generates a report of test validation results.
+func GenerateResultsReport(results []ValidationResult) string {
 	var report string
 
 	report += "=== Security Control Validation Report ===\n\n"
 
-	results := v.GetResults()
 	if len(results) == 0 {
 		report += "No validation results available\n"
 		return report
@@ -180,6 +301,12 @@ func (v *ControlValidator) GenerateValidationReport() string {
 		report += "  [" + fmt.Sprintf("%d", i+1) + "] " + status + " " + result.ControlName + "\n"
 		report += "      Control ID: " + result.ControlID + "\n"
 		report += "      Result: " + result.ValidationResult + "\n"
+		if result.ActualResult != "" {
+			report += "      Actual: " + result.ActualResult + "\n"
+		}
+		if result.Error != "" {
+			report += "      Error: " + result.Error + "\n"
+		}
 		report += "      Effectiveness: " + fmt.Sprintf("%.1f%%", result.Effectiveness*100) + "\n"
 		report += "      Risk Remaining: " + fmt.Sprintf("%.1f%%", result.RiskRemaining*100) + "\n"
 
@@ -210,10 +337,10 @@ func CreateCommonControlTests() []ControlTest {
 				"Review access logs",
 			},
 			ExpectedResult: "Unauthorized access denied",
-			Passed:        true,
-			Notes:         "All access controls functioning correctly",
-			TestedAt:      time.Now(),
-			TestedBy:      "Security Team",
+			Passed:         true,
+			Notes:          "All access controls functioning correctly",
+			TestedAt:       time.Now(),
+			TestedBy:       "Security Team",
 		},
 		{
 			ID:          "test-002",
@@ -226,10 +353,10 @@ func CreateCommonControlTests() []ControlTest {
 				"Test data encryption",
 			},
 			ExpectedResult: "Data encrypted correctly",
-			Passed:        true,
-			Notes:         "Encryption properly configured",
-			TestedAt:      time.Now(),
-			TestedBy:      "Security Team",
+			Passed:         true,
+			Notes:          "Encryption properly configured",
+			TestedAt:       time.Now(),
+			TestedBy:       "Security Team",
 		},
 		{
 			ID:          "test-003",
@@ -242,10 +369,10 @@ func CreateCommonControlTests() []ControlTest {
 				"Test alert generation",
 			},
 			ExpectedResult: "Monitoring active and alerts working",
-			Passed:        true,
-			Notes:         "All monitoring systems operational",
-			TestedAt:      time.Now(),
-			TestedBy:      "SOC Team",
+			Passed:         true,
+			Notes:          "All monitoring systems operational",
+			TestedAt:       time.Now(),
+			TestedBy:       "SOC Team",
 		},
 		{
 			ID:          "test-004",
@@ -258,10 +385,10 @@ func CreateCommonControlTests() []ControlTest {
 				"Verify recovery time",
 			},
 			ExpectedResult: "Backups successful and recoverable",
-			Passed:        true,
-			Notes:         "Backup and recovery working correctly",
-			TestedAt:      time.Now(),
-			TestedBy:      "IT Operations",
+			Passed:         true,
+			Notes:          "Backup and recovery working correctly",
+			TestedAt:       time.Now(),
+			TestedBy:       "IT Operations",
 		},
 	}
 }
@@ -273,10 +400,10 @@ func GenerateValidationReport(validator *ControlValidator) string {
 
 // ValidateControl validates control.
 func ValidateControl(validator *ControlValidator, test ControlTest) ValidationResult {
-	return validator.validateControlTest(test)
+	return validateControlTest(context.Background(), validator.GetRegistry(), test)
 }
 
 // GetValidationResult returns validation result.
 func GetValidationResult(result *ValidationResult) *ValidationResult {
 	return result
-}
\ No newline at end of file
+}
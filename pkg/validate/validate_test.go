@@ -0,0 +1,304 @@
+package validate
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hallucinaut/securitycontrol/pkg/engine"
+	"github.com/hallucinaut/securitycontrol/pkg/report"
+)
+
+func TestValidateManualExecutor(t *testing.T) {
+	validator := NewControlValidator()
+	validator.AddControlTest(ControlTest{
+		ID:             "t-pass",
+		Method:         MethodInterview,
+		ExpectedResult: "Procedure followed",
+		Passed:         true,
+		TestedAt:       time.Now().Add(-time.Hour),
+	})
+	validator.AddControlTest(ControlTest{ID: "t-unrecorded", Method: MethodDocumentation})
+
+	results := validator.Validate()
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if !results[0].TestPassed || results[0].ActualResult != "Procedure followed" {
+		t.Errorf("unexpected manual result: %+v", results[0])
+	}
+	if results[1].ValidationResult != "NOT_RUN" || results[1].Completed() {
+		t.Errorf("expected unrecorded test to be NOT_RUN: %+v", results[1])
+	}
+}
+
+func TestValidateDispatchesByKind(t *testing.T) {
+	registry := DefaultRegistry()
+	registry.Register("probe", ExecutorFunc(func(ctx context.Context, test ControlTest) Outcome {
+		return Outcome{Passed: false, ActualResult: "port 22 open"}
+	}))
+	registry.Register(string(MethodTesting), ExecutorFunc(func(ctx context.Context, test ControlTest) Outcome {
+		return Outcome{Err: errors.New("unreachable")}
+	}))
+
+	validator := NewControlValidator()
+	validator.SetRegistry(registry)
+	validator.AddControlTest(ControlTest{ID: "t-probe", Method: MethodTesting, Kind: "probe"})
+	validator.AddControlTest(ControlTest{ID: "t-test", Method: MethodTesting})
+	validator.AddControlTest(ControlTest{ID: "t-unknown", Kind: "missing"})
+
+	results := validator.Validate()
+	if results[0].ValidationResult != "FAIL" || results[0].ActualResult != "port 22 open" {
+		t.Errorf("expected probe executor failure: %+v", results[0])
+	}
+	if results[1].ValidationResult != "ERROR" || results[1].Error != "unreachable" {
+		t.Errorf("expected executor error: %+v", results[1])
+	}
+	if results[2].ValidationResult != "ERROR" {
+		t.Errorf("expected missing executor error: %+v", results[2])
+	}
+
+	if tests := validator.GetControlTests(); tests[0].ActualResult != "port 22 open" || tests[0].TestedAt.IsZero() {
+		t.Errorf("executed test not updated: %+v", tests[0])
+	}
+}
+
+func TestValidateRetries(t *testing.T) {
+	registry := DefaultRegistry()
+	registry.Register("probe", ExecutorFunc(func(ctx context.Context, test ControlTest) Outcome {
+		return Outcome{Passed: false}
+	}))
+
+	validator := NewControlValidator()
+	validator.SetRegistry(registry)
+	validator.AddControlTest(ControlTest{ID: "t-probe", Kind: "probe"})
+	validator.AddControlTest(ControlTest{ID: "t-manual", Method: MethodInterview, TestedAt: time.Now()})
+
+	results, err := validator.ValidateAll(context.Background(), engine.Options{Workers: 2, Retries: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[0].Attempts != 3 {
+		t.Errorf("failing probe ran %d times, want 3", results[0].Attempts)
+	}
+	// Recorded manual results do not change on a retry
+	if results[1].ValidationResult != "FAIL" || results[1].Attempts != 1 {
+		t.Errorf("failing manual test = %s after %d attempts", results[1].ValidationResult, results[1].Attempts)
+	}
+}
+
+func TestValidateAllKeepsRegistry(t *testing.T) {
+	validator := NewControlValidator()
+	var first, second int
+	replacement := DefaultRegistry()
+	replacement.Register("probe", ExecutorFunc(func(ctx context.Context, test ControlTest) Outcome {
+		second++
+		return Outcome{Passed: true}
+	}))
+	registry := DefaultRegistry()
+	registry.Register("probe", ExecutorFunc(func(ctx context.Context, test ControlTest) Outcome {
+		first++
+		validator.SetRegistry(replacement)
+		return Outcome{Passed: false}
+	}))
+	validator.SetRegistry(registry)
+	validator.AddControlTest(ControlTest{ID: "t-probe", Kind: "probe"})
+
+	// Retries run through the registry the run started with
+	if _, err := validator.ValidateAll(context.Background(), engine.Options{Workers: 1, Retries: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if first != 2 || second != 0 {
+		t.Errorf("executions = %d and %d, want 2 and 0", first, second)
+	}
+	if validator.GetRegistry() != replacement {
+		t.Error("registry not replaced")
+	}
+}
+
+func TestShellExecutor(t *testing.T) {
+	executor := NewShellExecutor()
+	executor.SetMaxOutput(256)
+
+	test := ControlTest{
+		ID:     "t-shell",
+		Method: MethodAutomation,
+		Steps:  []string{"Query the MFA policy"},
+		Command: &Command{
+			Path:         "sh",
+			Args:         []string{"-c", `echo '{"mfa":{"enabled":true},"users":[{"name":"alice"}]}'; echo "token=$API_TOKEN" >&2`},
+			Env:          map[string]string{"API_TOKEN": "s3cr3t-value"},
+			ExpectStdout: `"enabled":true`,
+			ExpectJSON: []JSONAssertion{
+				{Path: "$.mfa.enabled", Equals: "true"},
+				{Path: "$.users[0].name", Matches: "^a"},
+			},
+		},
+	}
+
+	var steps []string
+	ctx := WithProgress(context.Background(), func(e ProgressEvent) {
+		steps = append(steps, e.Message)
+	})
+	outcome := executor.Execute(ctx, test)
+	if outcome.Err != nil || !outcome.Passed {
+		t.Fatalf("expected command to pass: %+v", outcome)
+	}
+	if want := []string{"will run: Query the MFA policy"}; !reflect.DeepEqual(steps, want) {
+		t.Errorf("steps = %q, want %q", steps, want)
+	}
+	for _, ev := range outcome.Evidence {
+		if ev.Name == "stderr" && ev.Content != "token=[REDACTED]\n" {
+			t.Errorf("secret not redacted from stderr: %q", ev.Content)
+		}
+		if ev.SHA256 == "" {
+			t.Errorf("evidence %s has no hash", ev.Name)
+		}
+	}
+
+	test.Command.ExpectJSON = []JSONAssertion{{Path: "$.mfa.enabled", Equals: "false"}}
+	outcome = executor.Execute(context.Background(), test)
+	if outcome.Passed || outcome.ActualResult != "exit code 0: $.mfa.enabled is true, expected false" {
+		t.Errorf("expected JSON assertion failure: %q", outcome.ActualResult)
+	}
+}
+
+func TestShellExecutorLimits(t *testing.T) {
+	executor := NewShellExecutor()
+	executor.SetMaxOutput(10)
+
+	outcome := executor.Execute(context.Background(), ControlTest{Command: &Command{
+		Path: "sh",
+		Args: []string{"-c", "printf '0123456789abcdef'; exit 3"},
+	}})
+	if outcome.Passed || outcome.ActualResult != "exit code 3: expected exit code 0 (output truncated to 10 bytes)" {
+		t.Errorf("unexpected outcome: %q", outcome.ActualResult)
+	}
+	if outcome.Evidence[0].Content != "0123456789" {
+		t.Errorf("stdout not capped: %q", outcome.Evidence[0].Content)
+	}
+
+	// Expectations see stdout beyond the evidence cap
+	assertJSON := ControlTest{Command: &Command{
+		Path:       "sh",
+		Args:       []string{"-c", `echo '{"mfa":{"enabled":true}}'`},
+		ExpectJSON: []JSONAssertion{{Path: "$.mfa.enabled", Equals: "true"}},
+	}}
+	outcome = executor.Execute(context.Background(), assertJSON)
+	if !outcome.Passed || outcome.Evidence[0].Content != `{"mfa":{"e` {
+		t.Errorf("assertion on capped output: passed %t, %q, evidence %q", outcome.Passed, outcome.ActualResult, outcome.Evidence[0].Content)
+	}
+
+	executor.SetMaxAssertOutput(16)
+	outcome = executor.Execute(context.Background(), assertJSON)
+	if outcome.Passed || outcome.ActualResult != "exit code 0: output truncated, cannot evaluate assertion (output truncated to 10 bytes)" {
+		t.Errorf("unexpected outcome: %q", outcome.ActualResult)
+	}
+
+	outcome = executor.Execute(context.Background(), ControlTest{Command: &Command{
+		Path:    "sleep",
+		Args:    []string{"5"},
+		Timeout: 50 * time.Millisecond,
+	}})
+	if outcome.Err == nil || outcome.Duration > 2*time.Second {
+		t.Errorf("expected timeout, got %+v", outcome)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	outcome = executor.Execute(ctx, ControlTest{Command: &Command{Path: "sleep", Args: []string{"5"}}})
+	if !errors.Is(outcome.Err, context.Canceled) {
+		t.Errorf("expected cancellation, got %v", outcome.Err)
+	}
+}
+
+func TestValidateReportsProgress(t *testing.T) {
+	validator := NewControlValidator()
+	validator.AddControlTest(ControlTest{
+		ID:       "t-manual",
+		Method:   MethodObservation,
+		Steps:    []string{"Observe badge check", "Review visitor log"},
+		Passed:   true,
+		TestedAt: time.Now(),
+	})
+
+	var events []ProgressEvent
+	ctx := WithProgress(context.Background(), func(e ProgressEvent) {
+		events = append(events, e)
+	})
+	if _, err := validator.ValidateAll(ctx, engine.Options{Workers: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	kinds := make([]ProgressKind, len(events))
+	for i, e := range events {
+		kinds[i] = e.Kind
+	}
+	want := []ProgressKind{ProgressStart, ProgressStep, ProgressStep, ProgressFinish}
+	if !reflect.DeepEqual(kinds, want) {
+		t.Fatalf("expected events %v, got %v", want, kinds)
+	}
+	if events[2].Step != 2 || events[2].Steps != 2 {
+		t.Errorf("unexpected step event: %+v", events[2])
+	}
+	if events[3].Outcome == nil || events[3].Outcome.Result() != "PASS" {
+		t.Errorf("unexpected finish event: %+v", events[3])
+	}
+}
+
+func TestInteractiveExecutor(t *testing.T) {
+	test := ControlTest{
+		ID:             "t-interview",
+		Method:         MethodInterview,
+		Steps:          []string{"Ask about onboarding", "Ask about offboarding"},
+		ExpectedResult: "Process documented",
+	}
+
+	var out strings.Builder
+	executor := NewInteractiveExecutor(strings.NewReader("p\nmaybe\ns\n\n"), &out)
+	outcome := executor.Execute(context.Background(), test)
+	if outcome.Err != nil || !outcome.Passed || outcome.ActualResult != "Process documented" {
+		t.Fatalf("expected pass with expected result: %+v", outcome)
+	}
+	if !strings.Contains(out.String(), "Please answer pass, fail or skip") {
+		t.Errorf("invalid answer not rejected:\n%s", out.String())
+	}
+	if len(outcome.Evidence) != 1 || !strings.Contains(outcome.Evidence[0].Content, "Ask about offboarding: skip") {
+		t.Errorf("unexpected transcript: %+v", outcome.Evidence)
+	}
+
+	executor = NewInteractiveExecutor(strings.NewReader("pass\nfail\nNo offboarding checklist\n"), &out)
+	outcome = executor.Execute(context.Background(), test)
+	if outcome.Passed || outcome.ActualResult != "No offboarding checklist" {
+		t.Errorf("expected failure: %+v", outcome)
+	}
+
+	executor = NewInteractiveExecutor(strings.NewReader("p\n"), &out)
+	if outcome = executor.Execute(context.Background(), test); outcome.Err == nil {
+		t.Error("expected error when input ends early")
+	}
+}
+
+func TestAddPlan(t *testing.T) {
+	r := report.New("test-plan", "Plan")
+	AddPlan(r, []ControlTest{{
+		ID:     "t-cmd",
+		Method: MethodAutomation,
+		Command: &Command{
+			Path: "sh",
+			Args: []string{"-c", "exit 0"},
+			Env:  map[string]string{"TOKEN": "s3cr3t", "REGION": "eu"},
+		},
+	}})
+
+	row := r.GetSection("plan").Rows[0]
+	if row.Get("command") != `sh -c "exit 0"` || row.Get("kind") != "automation" {
+		t.Errorf("unexpected plan row: %v", row)
+	}
+	if env := row.Get("env"); !reflect.DeepEqual(env, []string{"REGION", "TOKEN"}) {
+		t.Errorf("expected environment names only, got %v", env)
+	}
+}